@@ -0,0 +1,219 @@
+package entity
+
+import (
+	"fmt"
+	"state_sample/internal/domain/value"
+	"strings"
+)
+
+// CompletionExpr はフェーズの完了条件を表す論理式の木です
+// 葉は条件IDを参照し、節はAnd / Or / Not / Thresholdで子の真偽を組み合わせます
+//
+//	// (A and B) or (C and not D)
+//	entity.ExprOrOf(
+//		entity.ExprAndOf(entity.ExprRef(1), entity.ExprRef(2)),
+//		entity.ExprAndOf(entity.ExprRef(3), entity.ExprNotOf(entity.ExprRef(4))),
+//	)
+type CompletionExpr struct {
+	Op          value.ExprOperator
+	ConditionID value.ConditionID // ExprConditionの参照先
+	Required    int               // ExprThresholdで真である必要のある子の数
+	Children    []*CompletionExpr
+}
+
+// ExprRef は条件が満たされていれば真になる葉を作成します
+func ExprRef(id value.ConditionID) *CompletionExpr {
+	return &CompletionExpr{Op: value.ExprCondition, ConditionID: id}
+}
+
+// ExprAndOf はすべての子が真の場合に真になる節を作成します
+func ExprAndOf(children ...*CompletionExpr) *CompletionExpr {
+	return &CompletionExpr{Op: value.ExprAnd, Children: children}
+}
+
+// ExprOrOf はいずれかの子が真の場合に真になる節を作成します
+func ExprOrOf(children ...*CompletionExpr) *CompletionExpr {
+	return &CompletionExpr{Op: value.ExprOr, Children: children}
+}
+
+// ExprNotOf は子が偽の場合に真になる節を作成します
+func ExprNotOf(child *CompletionExpr) *CompletionExpr {
+	return &CompletionExpr{Op: value.ExprNot, Children: []*CompletionExpr{child}}
+}
+
+// ExprAtLeastOf はk個以上の子が真の場合に真になる節を作成します
+func ExprAtLeastOf(k int, children ...*CompletionExpr) *CompletionExpr {
+	return &CompletionExpr{Op: value.ExprThreshold, Required: k, Children: children}
+}
+
+// Evaluate は満たされている条件の集合に対して式を評価します
+func (e *CompletionExpr) Evaluate(satisfied map[value.ConditionID]bool) bool {
+	switch e.Op {
+	case value.ExprCondition:
+		return satisfied[e.ConditionID]
+	case value.ExprAnd:
+		for _, child := range e.Children {
+			if !child.Evaluate(satisfied) {
+				return false
+			}
+		}
+		return len(e.Children) > 0
+	case value.ExprOr:
+		for _, child := range e.Children {
+			if child.Evaluate(satisfied) {
+				return true
+			}
+		}
+		return false
+	case value.ExprNot:
+		return len(e.Children) == 1 && !e.Children[0].Evaluate(satisfied)
+	case value.ExprThreshold:
+		count := 0
+		for _, child := range e.Children {
+			if child.Evaluate(satisfied) {
+				count++
+			}
+		}
+		return e.Required > 0 && count >= e.Required
+	default:
+		return false
+	}
+}
+
+// CanBeSatisfied は失敗した条件の集合に対して、式がこの先真になり得るかを返します
+// 失敗した条件は偽のまま変わらず、それ以外の条件は真にも偽にもなり得るものとして扱います
+func (e *CompletionExpr) CanBeSatisfied(failed map[value.ConditionID]bool) bool {
+	canTrue, _ := e.possible(failed)
+	return canTrue
+}
+
+// possible は式が真になり得るか、偽になり得るかを返します
+func (e *CompletionExpr) possible(failed map[value.ConditionID]bool) (canTrue, canFalse bool) {
+	switch e.Op {
+	case value.ExprCondition:
+		return !failed[e.ConditionID], true
+	case value.ExprAnd:
+		canTrue = len(e.Children) > 0
+		for _, child := range e.Children {
+			t, f := child.possible(failed)
+			canTrue = canTrue && t
+			canFalse = canFalse || f
+		}
+		return canTrue, canFalse || len(e.Children) == 0
+	case value.ExprOr:
+		canFalse = true
+		for _, child := range e.Children {
+			t, f := child.possible(failed)
+			canTrue = canTrue || t
+			canFalse = canFalse && f
+		}
+		return canTrue, canFalse
+	case value.ExprNot:
+		if len(e.Children) != 1 {
+			return false, true
+		}
+		t, f := e.Children[0].possible(failed)
+		return f, t
+	case value.ExprThreshold:
+		// 真になり得る子の数と、必ず真になる子の数で判定する
+		canTrueCount, mustTrueCount := 0, 0
+		for _, child := range e.Children {
+			t, f := child.possible(failed)
+			if t {
+				canTrueCount++
+			}
+			if !f {
+				mustTrueCount++
+			}
+		}
+		return e.Required > 0 && canTrueCount >= e.Required, e.Required <= 0 || mustTrueCount < e.Required
+	default:
+		return false, true
+	}
+}
+
+// Validate は式の構造と参照している条件IDを検証します
+// conditionsにはフェーズが持つ条件IDの集合を渡します
+func (e *CompletionExpr) Validate(conditions map[value.ConditionID]bool) error {
+	switch e.Op {
+	case value.ExprCondition:
+		if len(e.Children) > 0 {
+			return fmt.Errorf("condition reference %d must not have children", e.ConditionID)
+		}
+		if !conditions[e.ConditionID] {
+			return fmt.Errorf("condition %d is not a condition of the phase", e.ConditionID)
+		}
+		return nil
+	case value.ExprAnd, value.ExprOr:
+		if len(e.Children) == 0 {
+			return fmt.Errorf("%s needs at least one operand", e.opName())
+		}
+	case value.ExprNot:
+		if len(e.Children) != 1 {
+			return fmt.Errorf("not needs exactly one operand, got %d", len(e.Children))
+		}
+	case value.ExprThreshold:
+		if e.Required < 1 || e.Required > len(e.Children) {
+			return fmt.Errorf("threshold must be between 1 and %d, got %d", len(e.Children), e.Required)
+		}
+	default:
+		return fmt.Errorf("unknown expression operator: %d", e.Op)
+	}
+
+	for _, child := range e.Children {
+		if child == nil {
+			return fmt.Errorf("%s has a nil operand", e.opName())
+		}
+		if err := child.Validate(conditions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String は式を "(1 and 2) or (3 and not 4)" のような文字列で返します
+func (e *CompletionExpr) String() string {
+	switch e.Op {
+	case value.ExprCondition:
+		return fmt.Sprintf("%d", e.ConditionID)
+	case value.ExprNot:
+		if len(e.Children) == 1 {
+			return "not " + e.Children[0].operandString()
+		}
+	case value.ExprThreshold:
+		operands := make([]string, len(e.Children))
+		for i, child := range e.Children {
+			operands[i] = child.String()
+		}
+		return fmt.Sprintf("at_least(%d, %s)", e.Required, strings.Join(operands, ", "))
+	}
+
+	operands := make([]string, len(e.Children))
+	for i, child := range e.Children {
+		operands[i] = child.operandString()
+	}
+	return strings.Join(operands, " "+e.opName()+" ")
+}
+
+// operandString は他の演算子のオペランドとして使う時の文字列を返します
+func (e *CompletionExpr) operandString() string {
+	if (e.Op == value.ExprAnd || e.Op == value.ExprOr) && len(e.Children) > 1 {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+func (e *CompletionExpr) opName() string {
+	switch e.Op {
+	case value.ExprAnd:
+		return "and"
+	case value.ExprOr:
+		return "or"
+	case value.ExprNot:
+		return "not"
+	case value.ExprThreshold:
+		return "threshold"
+	default:
+		return "condition"
+	}
+}
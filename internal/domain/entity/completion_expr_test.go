@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"context"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionExprEvaluate(t *testing.T) {
+	// (1 and 2) or (3 and not 4)
+	expr := ExprOrOf(
+		ExprAndOf(ExprRef(1), ExprRef(2)),
+		ExprAndOf(ExprRef(3), ExprNotOf(ExprRef(4))),
+	)
+	assert.Equal(t, "(1 and 2) or (3 and not 4)", expr.String())
+
+	testCases := []struct {
+		name      string
+		satisfied []value.ConditionID
+		expected  bool
+	}{
+		{name: "None", expected: false},
+		{name: "OnlyA", satisfied: []value.ConditionID{1}, expected: false},
+		{name: "AandB", satisfied: []value.ConditionID{1, 2}, expected: true},
+		{name: "C", satisfied: []value.ConditionID{3}, expected: true},
+		{name: "CandD", satisfied: []value.ConditionID{3, 4}, expected: false},
+		{name: "All", satisfied: []value.ConditionID{1, 2, 3, 4}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			satisfied := make(map[value.ConditionID]bool)
+			for _, id := range tc.satisfied {
+				satisfied[id] = true
+			}
+			assert.Equal(t, tc.expected, expr.Evaluate(satisfied))
+		})
+	}
+
+	threshold := ExprAtLeastOf(2, ExprRef(1), ExprRef(2), ExprRef(3))
+	assert.Equal(t, "at_least(2, 1, 2, 3)", threshold.String())
+	assert.False(t, threshold.Evaluate(map[value.ConditionID]bool{2: true}))
+	assert.True(t, threshold.Evaluate(map[value.ConditionID]bool{2: true, 3: true}))
+}
+
+func TestCompletionExprCanBeSatisfied(t *testing.T) {
+	// (1 and 2) or (3 and not 4)
+	expr := ExprOrOf(
+		ExprAndOf(ExprRef(1), ExprRef(2)),
+		ExprAndOf(ExprRef(3), ExprNotOf(ExprRef(4))),
+	)
+
+	testCases := []struct {
+		name     string
+		failed   []value.ConditionID
+		expected bool
+	}{
+		{name: "None", expected: true},
+		{name: "A", failed: []value.ConditionID{1}, expected: true},
+		{name: "AandC", failed: []value.ConditionID{1, 3}, expected: false},
+		{name: "BandD", failed: []value.ConditionID{2, 4}, expected: true},
+		{name: "BandC", failed: []value.ConditionID{2, 3}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failed := make(map[value.ConditionID]bool)
+			for _, id := range tc.failed {
+				failed[id] = true
+			}
+			assert.Equal(t, tc.expected, expr.CanBeSatisfied(failed))
+		})
+	}
+
+	// 失敗した条件は偽のままなので、notの子であれば必ず真になる
+	assert.True(t, ExprNotOf(ExprRef(1)).CanBeSatisfied(map[value.ConditionID]bool{1: true}))
+
+	threshold := ExprAtLeastOf(2, ExprRef(1), ExprRef(2), ExprRef(3))
+	assert.True(t, threshold.CanBeSatisfied(map[value.ConditionID]bool{1: true}))
+	assert.False(t, threshold.CanBeSatisfied(map[value.ConditionID]bool{1: true, 2: true}))
+}
+
+func TestCompletionExprValidate(t *testing.T) {
+	conditions := map[value.ConditionID]bool{1: true, 2: true}
+
+	assert.NoError(t, ExprAndOf(ExprRef(1), ExprNotOf(ExprRef(2))).Validate(conditions))
+	assert.ErrorContains(t, ExprOrOf(ExprRef(1), ExprRef(9)).Validate(conditions), "condition 9 is not a condition of the phase")
+	assert.ErrorContains(t, ExprAndOf().Validate(conditions), "and needs at least one operand")
+	assert.ErrorContains(t, (&CompletionExpr{Op: value.ExprNot}).Validate(conditions), "not needs exactly one operand")
+	assert.ErrorContains(t, ExprAtLeastOf(3, ExprRef(1), ExprRef(2)).Validate(conditions), "threshold must be between 1 and 2")
+}
+
+func TestPhaseCompletionExprRevert(t *testing.T) {
+	ctx := context.Background()
+	conditions := make([]*Condition, 0, 3)
+	for id := value.ConditionID(1); id <= 3; id++ {
+		cond := NewCondition(id, "Condition", value.KindCounter)
+		cond.AddPart(NewConditionPart(value.ConditionPartID(id), "Part"))
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		conditions = append(conditions, cond)
+	}
+	phase := NewPhase(1, "Expr Phase", 1, conditions, value.ConditionTypeUnspecified, value.GameRule_Shooting, 0, false)
+	// 1 and 2 and not 3
+	phase.Completion = ExprAndOf(ExprRef(1), ExprRef(2), ExprNotOf(ExprRef(3)))
+	for _, cond := range conditions {
+		cond.AddConditionObserver(phase)
+	}
+	require.NoError(t, phase.Activate(ctx))
+
+	conditions[0].GetParts()[0].OnUpdated(value.EventComplete)
+	conditions[2].GetParts()[0].OnUpdated(value.EventComplete)
+	assert.Equal(t, 2, phase.SatisfiedConditionCount())
+
+	// 満たされていた条件が未達成に戻ると集合から取り除かれる
+	require.NoError(t, conditions[2].Revert(ctx))
+	assert.Equal(t, 1, phase.SatisfiedConditionCount())
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+
+	conditions[1].GetParts()[0].OnUpdated(value.EventComplete)
+	assert.True(t, phase.IsClear)
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 	"time"
 
@@ -16,21 +18,26 @@ import (
 
 // Condition は状態遷移の条件を表す構造体です
 type Condition struct {
-	ID             value.ConditionID
-	Label          string
-	Kind           value.ConditionKind
-	Parts          map[value.ConditionPartID]*ConditionPart
-	Name           string
-	Description    string
-	IsClear        bool
-	StartTime      *time.Time
-	FinishTime     *time.Time
-	fsm            *fsm.FSM
-	stateObservers []service.PhaseObserver
-	condObservers  []service.ConditionObserver
-	mu             sync.RWMutex
-	log            *zap.Logger
-	satisfiedParts map[value.ConditionPartID]bool
+	ID                  value.ConditionID
+	Label               string
+	Kind                value.ConditionKind
+	Combination         value.PartCombination // パーツの組み合わせ方(既定はすべて)
+	RequiredParts       int                   // PartCombinationAtLeastで満たす必要のあるパーツ数
+	Ordered             bool                  // 優先度の高いパーツが満たされるまで後続のパーツを満たさない
+	Parts               map[value.ConditionPartID]*ConditionPart
+	Name                string
+	Description         string
+	IsClear             bool
+	StartTime           *time.Time
+	FinishTime          *time.Time
+	fsm                 *fsm.FSM
+	stateObservers      []service.PhaseObserver
+	condObservers       []service.ConditionObserver
+	transitionObservers []service.TransitionObserver
+	clock               clock.Clock
+	mu                  sync.RWMutex
+	log                 *zap.Logger
+	satisfiedParts      map[value.ConditionPartID]bool
 }
 
 // NewCondition は新しいConditionインスタンスを作成します
@@ -48,27 +55,35 @@ func NewCondition(id value.ConditionID, label string, kind value.ConditionKind)
 		StartTime:      nil,
 		FinishTime:     nil,
 		log:            log,
+		clock:          clock.Real(),
 	}
 
 	callbacks := fsm.Callbacks{
 		"enter_" + value.StateUnsatisfied: func(ctx context.Context, e *fsm.Event) {
-			now := time.Now()
+			if e.Src == value.StateSatisfied {
+				// ライブのパーツが未達成に戻った場合は、パーツを起動し直さずに差し戻す
+				c.IsClear = false
+				c.FinishTime = nil
+				c.log.Debug("Condition reverted to unsatisfied", zap.Int64("condition_id", int64(c.ID)))
+				return
+			}
+			now := c.clock.Now()
 			c.StartTime = &now
 			c.log.Debug("Condition enter_unsatisfied: setting start time",
 				zap.Time("start_time", now),
 				zap.Int64("condition_id", int64(c.ID)))
 
-			for i, part := range c.Parts {
+			for _, part := range c.GetParts() {
 				c.log.Debug("Condition enter_unsatisfied: activating part",
 					zap.Any("condition_part", part),
 				)
 				if err := part.Activate(ctx); err != nil {
-					c.log.Error("failed to activate part", zap.Int("part", int(i)), zap.Error(err))
+					c.log.Error("failed to activate part", zap.Int("part", int(part.ID)), zap.Error(err))
 				}
 			}
 		},
 		"enter_" + value.StateSatisfied: func(ctx context.Context, e *fsm.Event) {
-			now := time.Now()
+			now := c.clock.Now()
 			c.FinishTime = &now
 			c.log.Debug("Condition enter_satisfied: setting finish time",
 				zap.Time("finish_time", now),
@@ -77,6 +92,14 @@ func NewCondition(id value.ConditionID, label string, kind value.ConditionKind)
 			c.IsClear = true
 			c.NotifyConditionChanged()
 		},
+		"enter_" + value.StateFailed: func(ctx context.Context, e *fsm.Event) {
+			now := c.clock.Now()
+			c.FinishTime = &now
+			c.IsClear = false
+			c.log.Debug("Condition enter_failed: required parts can no longer be satisfied",
+				zap.Time("finish_time", now),
+				zap.Int64("condition_id", int64(c.ID)))
+		},
 		"enter_" + value.StateReady: func(ctx context.Context, e *fsm.Event) {
 			c.IsClear = false
 			c.StartTime = nil
@@ -86,6 +109,10 @@ func NewCondition(id value.ConditionID, label string, kind value.ConditionKind)
 			c.log.Debug("Condition enter_ready: resetting time information",
 				zap.Int64("condition_id", int64(c.ID)))
 		},
+		// 遷移による入れ子の遷移(フェーズ→条件→パーツ)より先に記録されるよう、状態を離れる時点で通知する
+		"leave_state": func(ctx context.Context, e *fsm.Event) {
+			c.notifyTransition(e)
+		},
 		"after_event": func(ctx context.Context, e *fsm.Event) {
 			c.log.Debug("Condition info",
 				zap.Int64("id", int64(c.ID)),
@@ -103,7 +130,8 @@ func NewCondition(id value.ConditionID, label string, kind value.ConditionKind)
 			{Name: value.EventActivate, Src: []string{value.StateReady}, Dst: value.StateUnsatisfied},
 			{Name: value.EventComplete, Src: []string{value.StateUnsatisfied}, Dst: value.StateSatisfied},
 			{Name: value.EventRevert, Src: []string{value.StateReady, value.StateSatisfied}, Dst: value.StateUnsatisfied},
-			{Name: value.EventReset, Src: []string{value.StateUnsatisfied, value.StateSatisfied}, Dst: value.StateReady},
+			{Name: value.EventFail, Src: []string{value.StateUnsatisfied}, Dst: value.StateFailed},
+			{Name: value.EventReset, Src: []string{value.StateUnsatisfied, value.StateSatisfied, value.StateFailed}, Dst: value.StateReady},
 		},
 		callbacks,
 	)
@@ -111,7 +139,8 @@ func NewCondition(id value.ConditionID, label string, kind value.ConditionKind)
 	return c
 }
 
-// GetParts は条件パーツのスライスを返します
+// GetParts は条件パーツのスライスを優先度順(Priorityの昇順、同じ場合はID順)で返します
+// パーツの起動・一時停止や通知、UIへの表示はこの順序で行われます
 func (c *Condition) GetParts() []*ConditionPart {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -120,9 +149,42 @@ func (c *Condition) GetParts() []*ConditionPart {
 	for _, part := range c.Parts {
 		parts = append(parts, part)
 	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].Priority != parts[j].Priority {
+			return parts[i].Priority < parts[j].Priority
+		}
+		return parts[i].ID < parts[j].ID
+	})
 	return parts
 }
 
+// canCompletePart はパーツが満たされてよいかを返します
+// 順序付きの条件では、優先度の高い(Priorityが小さい)パーツがすべて満たされるまで後続のパーツは満たされません
+func (c *Condition) canCompletePart(part *ConditionPart) bool {
+	if !c.isOrdered() {
+		return true
+	}
+
+	for _, other := range c.GetParts() {
+		if other.Priority >= part.Priority {
+			break
+		}
+		if !other.IsSatisfied() {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseHeldParts は順序待ちで保留していたパーツのうち、満たされてよくなったものを完了させます
+func (c *Condition) releaseHeldParts(ctx context.Context) {
+	for _, part := range c.GetParts() {
+		if part.IsHeld() {
+			part.releaseHeld(ctx)
+		}
+	}
+}
+
 // OnConditionPartChanged は条件パーツの状態が変更された時に呼び出されます
 func (c *Condition) OnConditionPartChanged(part interface{}) {
 	condPart, ok := part.(*ConditionPart)
@@ -146,7 +208,8 @@ func (c *Condition) OnConditionPartChanged(part interface{}) {
 		partsStatus[id] = part.IsSatisfied()
 	}
 
-	satisfied := c.checkAllPartsSatisfied()
+	satisfied := c.checkPartsSatisfied()
+	failed := !satisfied && c.checkPartsFailed()
 	c.mu.Unlock()
 
 	c.log.Debug("Condition: OnConditionPartChanged",
@@ -156,20 +219,93 @@ func (c *Condition) OnConditionPartChanged(part interface{}) {
 		zap.Any("all_parts_status", partsStatus),
 		zap.Int("satisfied_parts_count", len(c.satisfiedParts)),
 		zap.Int("total_parts_count", len(c.Parts)),
-		zap.Bool("all_satisfied", satisfied),
+		zap.Int("combination", int(c.Combination)),
+		zap.Bool("satisfied", satisfied),
+		zap.Bool("failed", failed),
 	)
 
+	// 失敗した条件は、リセットされるまで満たされることも差し戻されることもない
+	if c.CurrentState() == value.StateFailed {
+		return
+	}
+
 	// 条件が満たされた場合のみCompleteを呼び出す
 	if satisfied && c.CurrentState() != value.StateSatisfied {
-		c.log.Debug("Condition: All parts satisfied, completing condition",
+		c.log.Debug("Condition: required parts satisfied, completing condition",
 			zap.Int64("condition_id", int64(c.ID)))
 		_ = c.Complete(context.Background())
+	} else if !satisfied && c.CurrentState() == value.StateSatisfied {
+		// ライブのパーツが未達成に戻り、必要なパーツ数を下回った場合は条件も未達成に戻す
+		c.log.Debug("Condition: required parts no longer satisfied, reverting condition",
+			zap.Int64("condition_id", int64(c.ID)))
+		_ = c.fsm.Event(context.Background(), value.EventRevert)
+	} else if failed && c.CurrentState() == value.StateUnsatisfied {
+		// 失敗したパーツが増え、残りのパーツをすべて満たしても必要な数に届かない場合は条件も失敗させる
+		c.log.Debug("Condition: required parts can no longer be satisfied, failing condition",
+			zap.Int64("condition_id", int64(c.ID)))
+		_ = c.fsm.Event(context.Background(), value.EventFail)
+	}
+
+	// 先行するパーツが満たされたことで、保留していたパーツが満たされてよくなる場合がある
+	if condPart.IsSatisfied() && c.isOrdered() {
+		c.releaseHeldParts(context.Background())
 	}
 }
 
-// checkAllPartsSatisfied は全ての条件パーツが満たされているかチェックします
-func (c *Condition) checkAllPartsSatisfied() bool {
-	return len(c.satisfiedParts) == len(c.Parts)
+func (c *Condition) isOrdered() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Ordered
+}
+
+// checkPartsSatisfied は組み合わせ方に応じて必要な数の条件パーツが満たされているかチェックします
+func (c *Condition) checkPartsSatisfied() bool {
+	return len(c.satisfiedParts) >= c.requiredPartCount()
+}
+
+// checkPartsFailed は失敗したパーツのために、必要な数の条件パーツを満たせなくなったかチェックします
+func (c *Condition) checkPartsFailed() bool {
+	failed := 0
+	for _, part := range c.Parts {
+		if part.IsFailed() {
+			failed++
+		}
+	}
+	return failed > 0 && len(c.Parts)-failed < c.requiredPartCount()
+}
+
+// requiredPartCount は条件を満たすために必要なパーツ数を返します
+func (c *Condition) requiredPartCount() int {
+	switch c.Combination {
+	case value.PartCombinationAny:
+		return 1
+	case value.PartCombinationAtLeast:
+		return c.RequiredParts
+	default:
+		return len(c.Parts)
+	}
+}
+
+// RequiredPartCount は条件を満たすために必要なパーツ数を返します
+func (c *Condition) RequiredPartCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.requiredPartCount()
+}
+
+// ValidateCombination はパーツの組み合わせ方の妥当性を検証します
+func (c *Condition) ValidateCombination() error {
+	switch c.Combination {
+	case value.PartCombinationAll, value.PartCombinationAny:
+		return nil
+	case value.PartCombinationAtLeast:
+		if c.RequiredParts < 1 || c.RequiredParts > len(c.Parts) {
+			return fmt.Errorf("required_parts must be between 1 and %d, got %d", len(c.Parts), c.RequiredParts)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown part combination: %d", c.Combination)
+	}
 }
 
 // Validate は条件の妥当性を検証します
@@ -177,6 +313,9 @@ func (c *Condition) Validate() error {
 	if len(c.Parts) == 0 {
 		return errors.New("condition must have at least one part")
 	}
+	if err := c.ValidateCombination(); err != nil {
+		return err
+	}
 
 	// 各パーツの検証
 	for _, part := range c.Parts {
@@ -193,6 +332,11 @@ func (c *Condition) CurrentState() string {
 	return c.fsm.Current()
 }
 
+// IsFailed は条件が失敗したかどうかを返します
+func (c *Condition) IsFailed() bool {
+	return c.fsm.Is(value.StateFailed)
+}
+
 // Activate は条件をアクティブにします
 func (c *Condition) Activate(ctx context.Context) error {
 	return c.fsm.Event(ctx, value.EventActivate)
@@ -237,22 +381,43 @@ func (c *Condition) Reset(ctx context.Context) error {
 	c.StartTime = nil
 	c.FinishTime = nil
 
-	// パーツをリセット
-	for i, part := range c.Parts {
+	// パーツを優先度順にリセット
+	for _, part := range c.GetParts() {
 		if err := part.Reset(ctx); err != nil {
-			return fmt.Errorf("failed to reset part %d: %w", i, err)
+			return fmt.Errorf("failed to reset part %d: %w", part.ID, err)
 		}
 	}
 
 	return c.fsm.Event(ctx, value.EventReset)
 }
 
+// Pause は条件の全パーツを一時停止します
+func (c *Condition) Pause(ctx context.Context) error {
+	for _, part := range c.GetParts() {
+		if err := part.Pause(ctx); err != nil {
+			return fmt.Errorf("failed to pause part %d: %w", part.ID, err)
+		}
+	}
+	return nil
+}
+
+// Resume は条件の全パーツを再開します
+func (c *Condition) Resume(ctx context.Context) error {
+	for _, part := range c.GetParts() {
+		if err := part.Resume(ctx); err != nil {
+			return fmt.Errorf("failed to resume part %d: %w", part.ID, err)
+		}
+	}
+	return nil
+}
+
 // AddPart は条件パーツを追加します
 func (c *Condition) AddPart(part *ConditionPart) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	part.AddConditionPartObserver(c)
+	part.setCompletionGuard(c.canCompletePart)
 	c.Parts[part.ID] = part
 }
 
@@ -335,3 +500,41 @@ func (c *Condition) NotifyConditionChanged() {
 		observer.OnConditionChanged(c)
 	}
 }
+
+// SetClock は条件とその条件パーツが時刻の記録に使うClockを設定します
+func (c *Condition) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	c.clock = clk
+	c.mu.Unlock()
+
+	for _, part := range c.GetParts() {
+		part.SetClock(clk)
+	}
+}
+
+// AddTransitionObserver 状態遷移オブザーバーを追加します
+func (c *Condition) AddTransitionObserver(observer service.TransitionObserver) {
+	if observer == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transitionObservers = append(c.transitionObservers, observer)
+}
+
+// notifyTransition 状態遷移を通知します
+// 同じ状態への遷移(NoTransition)は通知しません
+func (c *Condition) notifyTransition(e *fsm.Event) {
+	if e.Src == e.Dst {
+		return
+	}
+
+	c.mu.RLock()
+	observers := make([]service.TransitionObserver, len(c.transitionObservers))
+	copy(observers, c.transitionObservers)
+	c.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnTransition(c, e.Event, e.Src, e.Dst)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 	"time"
 
@@ -25,17 +26,36 @@ type ConditionPart struct {
 	ReferenceValueInt    int64
 	ReferenceValueFloat  float64
 	ReferenceValueString string
+	ReferenceValueSet    []int64  // In / NotIn で比較する値の集合(順序入力条件では入力すべき順番)
+	ReferenceStringSet   []string // 文字列条件の In / NotIn で比較する値の集合
 	MinValue             int64
 	MaxValue             int64
-	Priority             int32
+	MinValueFloat        float64                  // 小数条件のBetweenの下限
+	MaxValueFloat        float64                  // 小数条件のBetweenの上限
+	DurationSeconds      int64                    // 比較が成り立ち続ける必要のある秒数(持続条件)、入力を数える時間窓の秒数(時間窓条件)
+	OnMiss               value.SequenceMissPolicy // 順序入力条件で誤った入力があった場合の扱い
+	Priority             int32                    // 評価・表示の順序(値が小さいほど優先)
+	Live                 bool                     // trueのとき満たされた後も評価を続け、値が基準を外れると未達成に戻る
+	DeadlineSeconds      int64                    // 満たす必要のある期限の秒数(0の場合は期限なし)。期限までに満たさないと失敗する
 	StartTime            *time.Time
 	FinishTime           *time.Time
+	paused               bool
+	heldEvent            string                    // 順序待ちで保留している完了イベント
+	completionGuard      func(*ConditionPart) bool // 満たされてよいかを判定する関数(順序付きの条件が設定)
+	deadlineTicker       clock.Ticker              // 期限のタイマー(期限がない場合や停止中はnil)
+	deadlineAt           time.Time                 // 期限の時刻
+	deadlineRemaining    time.Duration             // 一時停止時点での期限までの残り時間
+	deadlineStop         chan struct{}
 	fsm                  *fsm.FSM
 	mu                   sync.RWMutex
 	log                  *zap.Logger
+	clock                clock.Clock
 
-	strategy      service.PartStrategy
-	partObservers []service.ConditionPartObserver
+	strategy            service.PartStrategy
+	partObservers       []service.ConditionPartObserver
+	transitionObservers []service.TransitionObserver
+	// ResetはmuをロックしたままFSMを遷移させるため、遷移オブザーバーは別のロックで保護する
+	transitionMu sync.RWMutex
 }
 
 // NewConditionPart は新しいConditionPartインスタンスを作成します
@@ -49,16 +69,29 @@ func NewConditionPart(id value.ConditionPartID, label string) *ConditionPart {
 		StartTime:     nil,
 		FinishTime:    nil,
 		log:           log,
+		clock:         clock.Real(),
 	}
 
 	callbacks := fsm.Callbacks{
 		"enter_" + value.StateUnsatisfied: func(ctx context.Context, e *fsm.Event) {
-			now := time.Now()
+			if e.Event == value.EventRevert || e.Event == value.EventExpire {
+				// 差し戻しでは開始時刻と戦略の状態を引き継ぐ
+				p.IsClear = false
+				p.FinishTime = nil
+				p.log.Debug("ConditionPart reverted to unsatisfied", zap.Int64("id", int64(p.ID)))
+				return
+			}
+			now := p.clock.Now()
 			p.StartTime = &now
 			log.Debug("ConditionPart enter_unsatisfied",
 				zap.Int64("id", int64(p.ID)),
 				zap.Time("start_time", now),
 			)
+			if p.DeadlineSeconds > 0 {
+				p.mu.Lock()
+				p.startDeadlineLocked(time.Duration(p.DeadlineSeconds) * time.Second)
+				p.mu.Unlock()
+			}
 			if p.strategy != nil {
 				if err := p.strategy.Start(ctx, p); err != nil {
 					p.log.Error("failed to evaluate strategy", zap.Error(err))
@@ -67,13 +100,38 @@ func NewConditionPart(id value.ConditionPartID, label string) *ConditionPart {
 		},
 		"enter_" + value.StateProcessing: func(ctx context.Context, e *fsm.Event) {},
 		"enter_" + value.StateSatisfied: func(ctx context.Context, e *fsm.Event) {
-			now := time.Now()
+			now := p.clock.Now()
 			p.FinishTime = &now
 			p.IsClear = true
 			p.log.Debug("part satisfied",
 				zap.Bool("IsClear", p.IsClear),
 				zap.Time("finish_time", now),
 			)
+			// ライブのパーツは満たされた後も評価を続けるため、戦略の値と監視を残す
+			// 期限までに未達成に戻った場合は失敗させるため、期限のタイマーも残す
+			if p.Live {
+				return
+			}
+			p.mu.Lock()
+			p.stopDeadlineLocked()
+			p.mu.Unlock()
+			if p.strategy != nil {
+				if err := p.strategy.Cleanup(); err != nil {
+					p.log.Error("failed to cleanup strategy", zap.Error(err))
+				}
+			}
+		},
+		"enter_" + value.StateFailed: func(ctx context.Context, e *fsm.Event) {
+			now := p.clock.Now()
+			p.FinishTime = &now
+			p.IsClear = false
+			p.mu.Lock()
+			p.heldEvent = ""
+			p.mu.Unlock()
+			p.log.Debug("part failed",
+				zap.Int64("id", int64(p.ID)),
+				zap.Time("finish_time", now),
+			)
 			if p.strategy != nil {
 				if err := p.strategy.Cleanup(); err != nil {
 					p.log.Error("failed to cleanup strategy", zap.Error(err))
@@ -87,6 +145,10 @@ func NewConditionPart(id value.ConditionPartID, label string) *ConditionPart {
 			p.log.Debug("ConditionPart enter_ready: resetting time information",
 				zap.Int64("id", int64(p.ID)))
 		},
+		// 遷移による入れ子の遷移(フェーズ→条件→パーツ)より先に記録されるよう、状態を離れる時点で通知する
+		"leave_state": func(ctx context.Context, e *fsm.Event) {
+			p.notifyTransition(e)
+		},
 		"after_event": func(ctx context.Context, e *fsm.Event) {
 			p.log.Debug("ConditionPart Info",
 				zap.Int64("id", int64(p.ID)),
@@ -104,8 +166,10 @@ func NewConditionPart(id value.ConditionPartID, label string) *ConditionPart {
 			{Name: value.EventProcess, Src: []string{value.StateUnsatisfied, value.StateProcessing}, Dst: value.StateProcessing},
 			{Name: value.EventComplete, Src: []string{value.StateProcessing, value.StateUnsatisfied}, Dst: value.StateSatisfied},
 			{Name: value.EventTimeout, Src: []string{value.StateProcessing, value.StateUnsatisfied}, Dst: value.StateSatisfied},
-			{Name: value.EventRevert, Src: []string{value.StateProcessing}, Dst: value.StateUnsatisfied},
-			{Name: value.EventReset, Src: []string{value.StateUnsatisfied, value.StateProcessing, value.StateSatisfied}, Dst: value.StateReady},
+			{Name: value.EventRevert, Src: []string{value.StateProcessing, value.StateSatisfied}, Dst: value.StateUnsatisfied},
+			{Name: value.EventExpire, Src: []string{value.StateSatisfied}, Dst: value.StateUnsatisfied},
+			{Name: value.EventFail, Src: []string{value.StateUnsatisfied, value.StateProcessing}, Dst: value.StateFailed},
+			{Name: value.EventReset, Src: []string{value.StateUnsatisfied, value.StateProcessing, value.StateSatisfied, value.StateFailed}, Dst: value.StateReady},
 		},
 		callbacks,
 	)
@@ -117,6 +181,26 @@ func (p *ConditionPart) GetReferenceValueInt() int64 {
 	return p.ReferenceValueInt
 }
 
+// GetReferenceValueSet はIn / NotInで比較する値の集合を返します
+func (p *ConditionPart) GetReferenceValueSet() []int64 {
+	return p.ReferenceValueSet
+}
+
+// GetReferenceValueFloat は小数条件の基準値を返します
+func (p *ConditionPart) GetReferenceValueFloat() float64 {
+	return p.ReferenceValueFloat
+}
+
+// GetReferenceValueString は文字列条件の基準値を返します
+func (p *ConditionPart) GetReferenceValueString() string {
+	return p.ReferenceValueString
+}
+
+// GetReferenceStringSet は文字列条件のIn / NotInで比較する値の集合を返します
+func (p *ConditionPart) GetReferenceStringSet() []string {
+	return p.ReferenceStringSet
+}
+
 func (p *ConditionPart) GetComparisonOperator() value.ComparisonOperator {
 	return p.ComparisonOperator
 }
@@ -129,10 +213,45 @@ func (p *ConditionPart) GetMinValue() int64 {
 	return p.MinValue
 }
 
+// GetMinValueFloat は小数条件のBetweenの下限を返します
+func (p *ConditionPart) GetMinValueFloat() float64 {
+	return p.MinValueFloat
+}
+
+// GetMaxValueFloat は小数条件のBetweenの上限を返します
+func (p *ConditionPart) GetMaxValueFloat() float64 {
+	return p.MaxValueFloat
+}
+
+// GetSequence は順序入力条件で入力すべき値を順番に返します
+func (p *ConditionPart) GetSequence() []int64 {
+	return p.ReferenceValueSet
+}
+
+// GetOnMiss は順序入力条件で誤った入力があった場合の扱いを返します
+func (p *ConditionPart) GetOnMiss() value.SequenceMissPolicy {
+	return p.OnMiss
+}
+
+// GetDurationSeconds は時間を伴う条件で使う秒数を返します
+func (p *ConditionPart) GetDurationSeconds() int64 {
+	return p.DurationSeconds
+}
+
+// HasStrategy は戦略が設定されているかどうかを返します
+func (p *ConditionPart) HasStrategy() bool {
+	return p.strategy != nil
+}
+
 func (p *ConditionPart) IsSatisfied() bool {
 	return p.fsm.Is(value.StateSatisfied)
 }
 
+// IsFailed は期限切れなどで条件パーツが失敗したかどうかを返します
+func (p *ConditionPart) IsFailed() bool {
+	return p.fsm.Is(value.StateFailed)
+}
+
 func (p *ConditionPart) GetCurrentValue() interface{} {
 	if p.strategy == nil {
 		return int64(0) // strategyがnilの場合はデフォルト値を返す
@@ -147,14 +266,76 @@ func (p *ConditionPart) OnUpdated(event string) {
 		zap.Int64("id", int64(p.ID)))
 
 	switch {
+	case (event == value.EventTimeout || event == value.EventComplete) && !p.canComplete():
+		// 順序付きの条件で先行するパーツが満たされていない場合は、満たされるまで完了を保留する
+		p.log.Debug("ConditionPart.OnUpdated: Holding completion until preceding parts are satisfied")
+		p.mu.Lock()
+		p.heldEvent = event
+		p.mu.Unlock()
 	case event == value.EventTimeout:
 		p.log.Debug("ConditionPart.OnUpdated: Calling Timeout")
 		p.Timeout(context.Background())
 	case event == value.EventComplete:
 		p.log.Debug("ConditionPart.OnUpdated: Calling Complete")
 		p.Complete(context.Background())
-	case event == value.EventProcess:
+	case event == value.EventProcess || event == value.EventExpire:
 		p.log.Debug("ConditionPart.OnUpdated: Calling Process for EventProcess")
+		// 保留中に条件を満たさなくなった場合は保留を取り消す
+		p.mu.Lock()
+		p.heldEvent = ""
+		p.mu.Unlock()
+		// ライブのパーツは値が基準を外れたら未達成に戻す
+		// 入力の期限切れによる差し戻しは、ジャーナルから再生できるよう入力による差し戻しと区別する
+		if p.Live && p.IsSatisfied() {
+			p.log.Debug("ConditionPart.OnUpdated: Reverting live part")
+			if event == value.EventExpire {
+				p.Expire(context.Background())
+			} else {
+				p.Revert(context.Background())
+			}
+		}
+	}
+	p.NotifyPartChanged(p)
+}
+
+// IsHeld は順序待ちで完了を保留しているかを返します
+func (p *ConditionPart) IsHeld() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.heldEvent != ""
+}
+
+// canComplete はパーツが満たされてよいかを返します
+func (p *ConditionPart) canComplete() bool {
+	p.mu.RLock()
+	guard := p.completionGuard
+	p.mu.RUnlock()
+	return guard == nil || guard(p)
+}
+
+// setCompletionGuard は満たされてよいかを判定する関数を設定します
+func (p *ConditionPart) setCompletionGuard(guard func(*ConditionPart) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completionGuard = guard
+}
+
+// releaseHeld は保留していた完了イベントを適用します
+// 保留していない場合や、まだ満たされてはいけない場合は何もしません
+func (p *ConditionPart) releaseHeld(ctx context.Context) {
+	p.mu.Lock()
+	event := p.heldEvent
+	p.mu.Unlock()
+	if event == "" || !p.canComplete() {
+		return
+	}
+
+	p.mu.Lock()
+	p.heldEvent = ""
+	p.mu.Unlock()
+	if err := p.fsm.Event(ctx, event); err != nil {
+		p.log.Error("failed to release held completion", zap.Int64("id", int64(p.ID)), zap.Error(err))
+		return
 	}
 	p.NotifyPartChanged(p)
 }
@@ -165,13 +346,36 @@ func (p *ConditionPart) Validate() error {
 		return errors.New("comparison operator must be specified")
 	}
 
-	// 比較演算子がBetweenの場合、MinValueとMaxValueが必要
+	// 比較演算子がBetweenの場合、MinValueとMaxValue(小数条件ではMinValueFloatとMaxValueFloat)が必要
+	// どちらの範囲を使うかは条件の種類によって決まります
 	if p.ComparisonOperator == value.ComparisonOperatorBetween {
-		if p.MinValue >= p.MaxValue {
+		if p.MinValue >= p.MaxValue && p.MinValueFloat >= p.MaxValueFloat {
 			return errors.New("min_value must be less than max_value")
 		}
 	}
 
+	// 比較演算子がIn / NotInの場合、重複のない値の集合が必要
+	// 数値の集合と文字列の集合のどちらを使うかは条件の種類によって決まり、ValidatePhasesで照合します
+	if p.ComparisonOperator == value.ComparisonOperatorIn || p.ComparisonOperator == value.ComparisonOperatorNotIn {
+		if len(p.ReferenceValueSet) == 0 && len(p.ReferenceStringSet) == 0 {
+			return errors.New("reference_value_set must not be empty")
+		}
+		seen := make(map[int64]bool, len(p.ReferenceValueSet))
+		for _, v := range p.ReferenceValueSet {
+			if seen[v] {
+				return fmt.Errorf("reference_value_set has duplicate value: %d", v)
+			}
+			seen[v] = true
+		}
+		seenString := make(map[string]bool, len(p.ReferenceStringSet))
+		for _, v := range p.ReferenceStringSet {
+			if seenString[v] {
+				return fmt.Errorf("reference_string_set has duplicate value: %q", v)
+			}
+			seenString[v] = true
+		}
+	}
+
 	return nil
 }
 
@@ -189,32 +393,50 @@ func isNotTransitionError(err error) bool {
 	return errors.As(err, &noTransitionError)
 }
 
+// Process はカウンターの増分を入力して条件パーツを評価します
 func (p *ConditionPart) Process(ctx context.Context, increment int64) error {
+	return p.ProcessValue(ctx, increment)
+}
+
+// ProcessValue は戦略に応じた型の評価値を入力して条件パーツを評価します
+// カウンターはint64の増分、小数条件はfloat64の測定値、文字列条件はstringの入力値を受け取ります
+func (p *ConditionPart) ProcessValue(ctx context.Context, input interface{}) error {
 	currentState := p.fsm.Current()
 	p.log.Debug("Part Process",
 		zap.Int64("id", int64(p.ID)),
 		zap.String("current_state", currentState),
-		zap.Int64("increment", increment))
+		zap.Any("input", input))
 
-	// すでに状態が満たされていたらスキップ
-	if p.fsm.Current() == value.StateSatisfied {
+	// すでに状態が満たされていたらスキップ(ライブのパーツは評価を続ける)
+	if p.fsm.Current() == value.StateSatisfied && !p.Live {
 		p.log.Debug("Part Process: State changed to satisfied during evaluation, skipping process event")
 		return nil
 	}
 
+	// 一時停止中や失敗した後は入力を受け付けない
+	if p.IsPaused() {
+		return fmt.Errorf("part %d is paused", p.ID)
+	}
+	if p.IsFailed() {
+		return fmt.Errorf("part %d has failed", p.ID)
+	}
+
 	// 状態遷移を先にしてからStrategyを実行(じゃないと、OnUpdatedでの通知で状態変更がUIに反映されない)
-	err := p.fsm.Event(ctx, value.EventProcess)
-	if err != nil && !isNotTransitionError(err) {
-		// NoTransitionError以外のエラーの場合のみエラーとして扱う
-		p.log.Error("Failed to transition state", zap.Error(err))
-		return err
-	} else {
-		p.log.Debug("State transition successful", zap.String("new_state", p.fsm.Current()))
+	// 満たされているライブのパーツは、値が基準を外れるまで満たされたまま評価する
+	if p.fsm.Current() != value.StateSatisfied {
+		err := p.fsm.Event(ctx, value.EventProcess)
+		if err != nil && !isNotTransitionError(err) {
+			// NoTransitionError以外のエラーの場合のみエラーとして扱う
+			p.log.Error("Failed to transition state", zap.Error(err))
+			return err
+		} else {
+			p.log.Debug("State transition successful", zap.String("new_state", p.fsm.Current()))
+		}
 	}
 
 	// 複数人から呼ばれる部分なのでmutex
 	if p.strategy != nil {
-		if err := p.strategy.Evaluate(ctx, p, increment); err != nil {
+		if err := p.strategy.Evaluate(ctx, p, input); err != nil {
 			p.log.Error("failed to evaluate strategy", zap.Error(err))
 			// エラーが発生した場合は状態遷移を行わない
 			return err
@@ -246,6 +468,101 @@ func (p *ConditionPart) Revert(ctx context.Context) error {
 	return p.fsm.Event(ctx, value.EventRevert)
 }
 
+// Expire は時間窓から入力が外れたことで、満たされたパーツを未達成に戻します
+func (p *ConditionPart) Expire(ctx context.Context) error {
+	return p.fsm.Event(ctx, value.EventExpire)
+}
+
+// Fail は条件パーツを失敗させます
+// 満たされているパーツや開始前のパーツは失敗させられません
+func (p *ConditionPart) Fail(ctx context.Context) error {
+	p.mu.Lock()
+	p.stopDeadlineLocked()
+	p.mu.Unlock()
+	return p.fsm.Event(ctx, value.EventFail)
+}
+
+// DeadlineRemaining は期限までの残り時間を返します
+// 期限がない場合や期限のタイマーが止まっている場合は0を返します
+func (p *ConditionPart) DeadlineRemaining() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.deadlineRemainingLocked()
+}
+
+// deadlineRemainingLocked は期限までの残り時間を返します。呼び出し側でロックを取得している必要があります
+func (p *ConditionPart) deadlineRemainingLocked() time.Duration {
+	switch {
+	case p.paused:
+		return p.deadlineRemaining
+	case p.deadlineTicker != nil:
+		remaining := p.deadlineAt.Sub(p.clock.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining
+	default:
+		return 0
+	}
+}
+
+// startDeadlineLocked は指定した時間の後に期限切れとなるタイマーを開始します
+// 呼び出し側でロックを取得している必要があります
+func (p *ConditionPart) startDeadlineLocked(d time.Duration) {
+	p.stopDeadlineLocked()
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	p.deadlineAt = p.clock.Now().Add(d)
+	p.deadlineTicker = p.clock.NewTicker(d)
+	p.deadlineStop = make(chan struct{})
+	go p.waitDeadline(p.deadlineTicker, p.deadlineStop)
+}
+
+// stopDeadlineLocked は期限のタイマーを停止します。呼び出し側でロックを取得している必要があります
+func (p *ConditionPart) stopDeadlineLocked() {
+	if p.deadlineTicker == nil {
+		return
+	}
+	p.deadlineTicker.Stop()
+	p.deadlineTicker = nil
+	close(p.deadlineStop)
+}
+
+// waitDeadline は期限を待ち、それまでに満たされていなければ条件パーツを失敗させます
+func (p *ConditionPart) waitDeadline(ticker clock.Ticker, stop chan struct{}) {
+	select {
+	case <-ticker.C():
+		p.mu.Lock()
+		// 待っている間に停止・再開されて別のタイマーに切り替わっていないことを確認
+		if p.deadlineTicker != ticker {
+			p.mu.Unlock()
+			return
+		}
+		p.stopDeadlineLocked()
+		p.mu.Unlock()
+
+		switch p.CurrentState() {
+		case value.StateUnsatisfied, value.StateProcessing:
+			p.log.Debug("ConditionPart deadline passed, failing part", zap.Int64("id", int64(p.ID)))
+			if err := p.ExpireDeadline(context.Background()); err != nil {
+				p.log.Error("failed to fail part", zap.Int64("id", int64(p.ID)), zap.Error(err))
+			}
+		}
+	case <-stop:
+	}
+}
+
+// ExpireDeadline は期限を迎えた時と同じく条件パーツを失敗させ、オブザーバーに通知します
+// ジャーナルの再生では、期限のタイマーを待たずに記録された期限切れを再現するために使います
+func (p *ConditionPart) ExpireDeadline(ctx context.Context) error {
+	if err := p.Fail(ctx); err != nil {
+		return err
+	}
+	p.NotifyPartChanged(p)
+	return nil
+}
+
 func (p *ConditionPart) Reset(ctx context.Context) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -269,9 +586,13 @@ func (p *ConditionPart) Reset(ctx context.Context) error {
 		zap.Int64("id", int64(p.ID)),
 		zap.String("label", p.Label))
 
-	// 時間情報をリセット
+	// 時間情報と一時停止、保留中の完了、期限をリセット
 	p.StartTime = nil
 	p.FinishTime = nil
+	p.paused = false
+	p.heldEvent = ""
+	p.stopDeadlineLocked()
+	p.deadlineRemaining = 0
 
 	// 戦略をリセット
 	if p.strategy != nil {
@@ -293,6 +614,67 @@ func (p *ConditionPart) Reset(ctx context.Context) error {
 	return p.fsm.Event(ctx, value.EventReset)
 }
 
+// Pause は条件パーツを一時停止します
+// タイマーは残り時間を保持して止まり、カウンターは再開するまで入力を受け付けません
+func (p *ConditionPart) Pause(ctx context.Context) error {
+	p.mu.Lock()
+	if p.paused {
+		p.mu.Unlock()
+		return nil
+	}
+	if p.deadlineTicker != nil {
+		// 期限の直前に一時停止した場合も、再開後に期限切れとなるよう残り時間を残す
+		p.deadlineRemaining = max(p.deadlineRemainingLocked(), time.Nanosecond)
+		p.stopDeadlineLocked()
+	}
+	p.paused = true
+	strategy := p.strategy
+	p.mu.Unlock()
+
+	if s, ok := strategy.(service.PausableStrategy); ok {
+		if err := s.Pause(); err != nil {
+			return fmt.Errorf("failed to pause strategy: %w", err)
+		}
+	}
+
+	p.log.Debug("ConditionPart paused", zap.Int64("id", int64(p.ID)))
+	p.NotifyPartChanged(p)
+	return nil
+}
+
+// Resume は一時停止した条件パーツを再開します
+func (p *ConditionPart) Resume(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.paused {
+		p.mu.Unlock()
+		return nil
+	}
+	p.paused = false
+	if p.deadlineRemaining > 0 {
+		p.startDeadlineLocked(p.deadlineRemaining)
+		p.deadlineRemaining = 0
+	}
+	strategy := p.strategy
+	p.mu.Unlock()
+
+	if s, ok := strategy.(service.PausableStrategy); ok {
+		if err := s.Resume(); err != nil {
+			return fmt.Errorf("failed to resume strategy: %w", err)
+		}
+	}
+
+	p.log.Debug("ConditionPart resumed", zap.Int64("id", int64(p.ID)))
+	p.NotifyPartChanged(p)
+	return nil
+}
+
+// IsPaused は条件パーツが一時停止中かどうかを返します
+func (p *ConditionPart) IsPaused() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.paused
+}
+
 func (p *ConditionPart) SetStrategy(strategy service.PartStrategy) error {
 	if p.strategy != nil {
 		if err := p.strategy.Cleanup(); err != nil {
@@ -343,3 +725,37 @@ func (p *ConditionPart) NotifyPartChanged(part interface{}) {
 		observer.OnConditionPartChanged(p)
 	}
 }
+
+// SetClock は条件パーツが時刻の記録に使うClockを設定します
+func (p *ConditionPart) SetClock(c clock.Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = c
+}
+
+// AddTransitionObserver 状態遷移オブザーバーを追加します
+func (p *ConditionPart) AddTransitionObserver(observer service.TransitionObserver) {
+	if observer == nil {
+		return
+	}
+	p.transitionMu.Lock()
+	defer p.transitionMu.Unlock()
+	p.transitionObservers = append(p.transitionObservers, observer)
+}
+
+// notifyTransition 状態遷移を通知します
+// 同じ状態への遷移(NoTransition)は通知しません
+func (p *ConditionPart) notifyTransition(e *fsm.Event) {
+	if e.Src == e.Dst {
+		return
+	}
+
+	p.transitionMu.RLock()
+	observers := make([]service.TransitionObserver, len(p.transitionObservers))
+	copy(observers, p.transitionObservers)
+	p.transitionMu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnTransition(p, e.Event, e.Src, e.Dst)
+	}
+}
@@ -337,6 +337,25 @@ func TestConditionPartValidation(t *testing.T) {
 	part.MaxValue = 10
 	err = part.Validate()
 	assert.NoError(t, err)
+
+	// In / NotIn演算子で値の集合が空の場合
+	for _, operator := range []value.ComparisonOperator{value.ComparisonOperatorIn, value.ComparisonOperatorNotIn} {
+		part.ComparisonOperator = operator
+		part.ReferenceValueSet = nil
+		err = part.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "reference_value_set must not be empty")
+
+		// 値が重複している場合
+		part.ReferenceValueSet = []int64{1, 2, 1}
+		err = part.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate value: 1")
+
+		part.ReferenceValueSet = []int64{1, 2, 3}
+		err = part.Validate()
+		assert.NoError(t, err)
+	}
 }
 
 func TestConditionPartIsSatisfied(t *testing.T) {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockStateObserver は PhaseObserver インターフェースのモック実装です
@@ -192,3 +195,217 @@ func TestConditionValidation(t *testing.T) {
 	err = condition.Validate()
 	assert.NoError(t, err)
 }
+
+func TestConditionPartCombination(t *testing.T) {
+	testCases := []struct {
+		name          string
+		combination   value.PartCombination
+		requiredParts int
+		completeParts int
+		expected      string
+	}{
+		{name: "All_Partial", combination: value.PartCombinationAll, completeParts: 2, expected: value.StateUnsatisfied},
+		{name: "All_Complete", combination: value.PartCombinationAll, completeParts: 3, expected: value.StateSatisfied},
+		{name: "Any_One", combination: value.PartCombinationAny, completeParts: 1, expected: value.StateSatisfied},
+		{name: "AtLeast_Below", combination: value.PartCombinationAtLeast, requiredParts: 2, completeParts: 1, expected: value.StateUnsatisfied},
+		{name: "AtLeast_Reached", combination: value.PartCombinationAtLeast, requiredParts: 2, completeParts: 2, expected: value.StateSatisfied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := NewCondition(1, "Test Condition", value.KindCounter)
+			condition.Combination = tc.combination
+			condition.RequiredParts = tc.requiredParts
+			parts := []*ConditionPart{NewConditionPart(1, "Part 1"), NewConditionPart(2, "Part 2"), NewConditionPart(3, "Part 3")}
+			for _, part := range parts {
+				condition.AddPart(part)
+			}
+			assert.NoError(t, condition.InitializePartStrategies(&MockStrategyFactory{}))
+			assert.NoError(t, condition.Activate(context.Background()))
+
+			for _, part := range parts[:tc.completeParts] {
+				part.OnUpdated(value.EventComplete)
+			}
+			assert.Equal(t, tc.expected, condition.CurrentState())
+		})
+	}
+}
+
+func TestConditionValidateCombination(t *testing.T) {
+	condition := NewCondition(1, "Test Condition", value.KindCounter)
+	part := NewConditionPart(1, "Part 1")
+	part.ComparisonOperator = value.ComparisonOperatorEQ
+	condition.AddPart(part)
+
+	condition.Combination = value.PartCombinationAtLeast
+	condition.RequiredParts = 2
+	err := condition.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required_parts must be between 1 and 1")
+
+	condition.RequiredParts = 1
+	assert.NoError(t, condition.Validate())
+	assert.Equal(t, 1, condition.RequiredPartCount())
+}
+
+func TestConditionGetPartsPriorityOrder(t *testing.T) {
+	condition := NewCondition(1, "Test Condition", value.KindCounter)
+	for _, p := range []struct {
+		id       value.ConditionPartID
+		priority int32
+	}{{1, 3}, {2, 1}, {3, 2}, {4, 1}} {
+		part := NewConditionPart(p.id, "Part")
+		part.Priority = p.priority
+		condition.AddPart(part)
+	}
+
+	ids := make([]value.ConditionPartID, 0, 4)
+	for _, part := range condition.GetParts() {
+		ids = append(ids, part.ID)
+	}
+	// 優先度の昇順、同じ優先度はID順
+	assert.Equal(t, []value.ConditionPartID{2, 4, 3, 1}, ids)
+}
+
+func TestConditionOrderedParts(t *testing.T) {
+	ctx := context.Background()
+	condition := NewCondition(1, "Steps", value.KindCounter)
+	condition.Ordered = true
+	step1 := NewConditionPart(1, "Step 1")
+	step1.Priority = 1
+	step2 := NewConditionPart(2, "Step 2")
+	step2.Priority = 2
+	step3 := NewConditionPart(3, "Step 3")
+	step3.Priority = 3
+	for _, part := range []*ConditionPart{step3, step1, step2} {
+		condition.AddPart(part)
+	}
+	assert.NoError(t, condition.InitializePartStrategies(&MockStrategyFactory{}))
+	assert.NoError(t, condition.Activate(ctx))
+
+	// 先行するパーツが満たされるまで完了は保留される
+	step3.OnUpdated(value.EventComplete)
+	assert.False(t, step3.IsSatisfied())
+	assert.True(t, step3.IsHeld())
+
+	step2.OnUpdated(value.EventComplete)
+	assert.True(t, step2.IsHeld())
+
+	// 保留が取り消されたパーツは先行するパーツが満たされても完了しない
+	step2.OnUpdated(value.EventProcess)
+	assert.False(t, step2.IsHeld())
+
+	step1.OnUpdated(value.EventComplete)
+	assert.True(t, step1.IsSatisfied())
+	assert.False(t, step2.IsSatisfied())
+	assert.True(t, step3.IsHeld())
+
+	// step2が満たされると保留していたstep3も続けて満たされる
+	step2.OnUpdated(value.EventComplete)
+	assert.True(t, step2.IsSatisfied())
+	assert.True(t, step3.IsSatisfied())
+	assert.Equal(t, value.StateSatisfied, condition.CurrentState())
+}
+
+func TestConditionLivePartsRevert(t *testing.T) {
+	ctx := context.Background()
+	condition := NewCondition(1, "Hold", value.KindCounter)
+	left := NewConditionPart(1, "Left")
+	left.Live = true
+	right := NewConditionPart(2, "Right")
+	right.Live = true
+	condition.AddPart(left)
+	condition.AddPart(right)
+	assert.NoError(t, condition.InitializePartStrategies(&MockStrategyFactory{}))
+	assert.NoError(t, condition.Activate(ctx))
+
+	left.OnUpdated(value.EventComplete)
+	right.OnUpdated(value.EventComplete)
+	assert.Equal(t, value.StateSatisfied, condition.CurrentState())
+	assert.True(t, condition.IsClear)
+
+	// ライブのパーツが基準を外れると、条件も未達成に戻る
+	left.OnUpdated(value.EventProcess)
+	assert.Equal(t, value.StateUnsatisfied, left.CurrentState())
+	assert.False(t, left.IsClear)
+	assert.Equal(t, value.StateUnsatisfied, condition.CurrentState())
+	assert.False(t, condition.IsClear)
+	assert.True(t, right.IsSatisfied())
+
+	// 再び満たせば条件も満たされる
+	left.OnUpdated(value.EventComplete)
+	assert.Equal(t, value.StateSatisfied, condition.CurrentState())
+
+	// ライブでないパーツは満たされたまま
+	latched := NewCondition(2, "Latched", value.KindCounter)
+	part := NewConditionPart(3, "Part")
+	latched.AddPart(part)
+	assert.NoError(t, latched.InitializePartStrategies(&MockStrategyFactory{}))
+	assert.NoError(t, latched.Activate(ctx))
+	part.OnUpdated(value.EventComplete)
+	part.OnUpdated(value.EventProcess)
+	assert.True(t, part.IsSatisfied())
+	assert.Equal(t, value.StateSatisfied, latched.CurrentState())
+}
+
+// newDeadlineCondition はすべてのパーツに期限を設定した条件を作成します
+func newDeadlineCondition(t *testing.T, fake *clock.Fake, combination value.PartCombination, seconds int64) (*Condition, *ConditionPart, *ConditionPart) {
+	condition := NewCondition(1, "Deadline", value.KindCounter)
+	condition.Combination = combination
+	first := NewConditionPart(1, "First")
+	first.DeadlineSeconds = seconds
+	second := NewConditionPart(2, "Second")
+	second.DeadlineSeconds = seconds
+	condition.AddPart(first)
+	condition.AddPart(second)
+	require.NoError(t, condition.InitializePartStrategies(&MockStrategyFactory{}))
+	condition.SetClock(fake)
+	t.Cleanup(func() { _ = condition.Reset(context.Background()) })
+	return condition, first, second
+}
+
+func TestConditionDeadlineFails(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	condition, first, second := newDeadlineCondition(t, fake, value.PartCombinationAll, 5)
+	require.NoError(t, condition.Activate(ctx))
+
+	// 期限までに満たしたパーツは失敗しない
+	first.OnUpdated(value.EventComplete)
+	fake.Advance(4 * time.Second)
+	assert.Equal(t, int64(1000), second.DeadlineRemaining().Milliseconds())
+	fake.Advance(time.Second)
+	assert.Eventually(t, second.IsFailed, time.Second, time.Millisecond)
+	assert.True(t, first.IsSatisfied())
+	assert.Error(t, second.Process(ctx, 1))
+
+	// すべてのパーツが必要な条件は、1つでも失敗すると失敗する
+	assert.Equal(t, value.StateFailed, condition.CurrentState())
+	assert.True(t, condition.IsFailed())
+	assert.False(t, condition.IsClear)
+
+	// リセットすると期限も最初から数え直す
+	require.NoError(t, condition.Reset(ctx))
+	assert.Equal(t, value.StateReady, second.CurrentState())
+	require.NoError(t, condition.Activate(ctx))
+	assert.Equal(t, int64(5000), second.DeadlineRemaining().Milliseconds())
+}
+
+func TestConditionDeadlineAnyPart(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	condition, first, second := newDeadlineCondition(t, fake, value.PartCombinationAny, 5)
+	require.NoError(t, condition.Activate(ctx))
+
+	// 一時停止中は期限が進まない
+	require.NoError(t, second.Pause(ctx))
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, first.IsFailed, time.Second, time.Millisecond)
+	assert.Equal(t, int64(5000), second.DeadlineRemaining().Milliseconds())
+
+	// 残りのパーツで満たせるうちは条件は失敗しない
+	assert.Equal(t, value.StateUnsatisfied, condition.CurrentState())
+	require.NoError(t, second.Resume(ctx))
+	second.OnUpdated(value.EventComplete)
+	assert.Equal(t, value.StateSatisfied, condition.CurrentState())
+}
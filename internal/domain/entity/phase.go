@@ -7,6 +7,7 @@ import (
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 	"time"
 
@@ -24,6 +25,8 @@ type Phase struct {
 	Description         string
 	Rule                value.GameRule
 	ConditionType       value.ConditionType
+	RequiredConditions  int             // ConditionTypeThresholdで満たす必要のある条件数
+	Completion          *CompletionExpr // 完了条件式(nilの場合はConditionTypeから組み立てる)
 	ConditionIDs        []value.ConditionID
 	SatisfiedConditions map[value.ConditionID]bool
 	Conditions          map[value.ConditionID]*Condition
@@ -31,6 +34,8 @@ type Phase struct {
 	FinishTime          *time.Time
 	fsm                 *fsm.FSM
 	observers           []service.PhaseObserver
+	clock               clock.Clock
+	transitionObservers []service.TransitionObserver
 	mu                  sync.RWMutex
 	log                 *zap.Logger
 
@@ -39,6 +44,25 @@ type Phase struct {
 	Parent                         *Phase        // 親フェーズへの参照
 	Children                       []*Phase      // 子フェーズのスライス
 	AutoProgressOnChildrenComplete bool          // 子フェーズ完了時に自動的に進捗するかどうか
+
+	// 並列領域
+	Parallel           bool                     // 子フェーズを並列領域として同時に実行するかどうか
+	ParallelCompletion value.ParallelCompletion // Parallelの場合に親フェーズが完了する条件
+
+	// 繰り返し
+	RepeatCount int             // 繰り返す回数の上限(0の場合は回数では終えない)
+	RepeatUntil *CompletionExpr // 完了時に真であれば繰り返しを終える式(nilの場合は回数だけで終える)
+	Iteration   int             // 現在の繰り返し回数(1から数え、開始前は0)
+
+	// フェーズ間の遷移
+	Transitions []PhaseTransition // 終わった後に移るフェーズを決める遷移の辺(空の場合はOrderの次に進む)
+	CompletedBy value.ConditionID // フェーズを完了させた条件のID
+
+	// 失敗時の遷移
+	Outcome          value.PhaseOutcome  // フェーズの結果(完了・失敗・中断)
+	OnFailure        value.FailureAction // 失敗した場合の遷移先
+	OnFailurePhaseID value.PhaseID       // OnFailureがFailureGotoの場合に移る兄弟フェーズのID
+	MaxRetries       int                 // OnFailureがFailureRetryの場合のやり直し回数の上限(0は無制限)
 }
 
 // NewPhase は新しいPhaseインスタンスを作成します
@@ -60,6 +84,7 @@ func NewPhase(id value.PhaseID, name string, order int, conditions []*Condition,
 		StartTime:           nil,
 		FinishTime:          nil,
 		log:                 log,
+		clock:               clock.Real(),
 
 		// 階層構造のフィールドを初期化
 		ParentID:                       parentID,
@@ -75,10 +100,23 @@ func NewPhase(id value.PhaseID, name string, order int, conditions []*Condition,
 
 	callbacks := fsm.Callbacks{
 		"enter_" + value.StateActive: func(ctx context.Context, e *fsm.Event) {
+			// 一時停止からの再開では開始時刻を保持し、条件を最初からではなく続きから動かす
+			if e.Event == value.EventResume {
+				for _, id := range p.ConditionIDs {
+					if err := p.Conditions[id].Resume(ctx); err != nil {
+						p.log.Error("Failed to resume condition",
+							zap.Error(err),
+							zap.Int64("condition_id", int64(id)))
+					}
+				}
+				return
+			}
+
 			p.isActive = true
-			now := time.Now()
+			now := p.clock.Now()
 			p.StartTime = &now
-			for _, c := range p.Conditions {
+			for _, id := range p.ConditionIDs {
+				c := p.Conditions[id]
 				p.log.Debug("Phase enter_active: Activating condition", zap.Any("condition", c))
 				if err := c.Activate(ctx); err != nil {
 					p.log.Error("Failed to activate condition",
@@ -87,10 +125,47 @@ func NewPhase(id value.PhaseID, name string, order int, conditions []*Condition,
 				}
 			}
 		},
+		"enter_" + value.StatePaused: func(ctx context.Context, e *fsm.Event) {
+			for _, id := range p.ConditionIDs {
+				if err := p.Conditions[id].Pause(ctx); err != nil {
+					p.log.Error("Failed to pause condition",
+						zap.Error(err),
+						zap.Int64("condition_id", int64(id)))
+				}
+			}
+		},
+		// 繰り返しでリセットした場合は回数を保っているので、アクティブ化のたびに1つ進める
+		"before_" + value.EventActivate: func(ctx context.Context, e *fsm.Event) {
+			p.Iteration++
+		},
 		"enter_" + value.StateNext: func(ctx context.Context, e *fsm.Event) {},
+		// 結果は状態が変わる前に記録し、状態を見た側が必ず結果も読めるようにする
+		"before_" + value.EventNext: func(ctx context.Context, e *fsm.Event) {
+			p.Outcome = value.OutcomeSucceeded
+			if len(e.Args) > 0 {
+				if outcome, ok := e.Args[0].(value.PhaseOutcome); ok {
+					p.Outcome = outcome
+				}
+			}
+		},
+		"before_" + value.EventSkip: func(ctx context.Context, e *fsm.Event) {
+			p.Outcome = value.OutcomeSkipped
+		},
+		"before_" + value.EventFail: func(ctx context.Context, e *fsm.Event) {
+			p.isActive = false
+			p.IsClear = false
+			now := p.clock.Now()
+			p.FinishTime = &now
+			p.Outcome = value.OutcomeFailed
+			if len(e.Args) > 0 {
+				if outcome, ok := e.Args[0].(value.PhaseOutcome); ok {
+					p.Outcome = outcome
+				}
+			}
+		},
 		"enter_" + value.StateFinish: func(ctx context.Context, e *fsm.Event) {
 			p.isActive = false
-			now := time.Now()
+			now := p.clock.Now()
 			p.FinishTime = &now
 		},
 		"enter_" + value.StateReady: func(ctx context.Context, e *fsm.Event) {
@@ -98,7 +173,16 @@ func NewPhase(id value.PhaseID, name string, order int, conditions []*Condition,
 			p.IsClear = false
 			p.StartTime = nil
 			p.FinishTime = nil
+			p.Outcome = value.OutcomeNone
+			p.CompletedBy = 0
 			p.SatisfiedConditions = make(map[value.ConditionID]bool)
+			if !isRepeat(e) {
+				p.Iteration = 0
+			}
+		},
+		// 遷移による入れ子の遷移(フェーズ→条件→パーツ)より先に記録されるよう、状態を離れる時点で通知する
+		"leave_state": func(ctx context.Context, e *fsm.Event) {
+			p.notifyTransition(e)
 		},
 		"after_event": func(ctx context.Context, e *fsm.Event) {
 			p.log.Debug("Phase transition", zap.String("Name", p.Name), zap.String("from", e.Src), zap.String("to", e.Dst))
@@ -120,7 +204,11 @@ func NewPhase(id value.PhaseID, name string, order int, conditions []*Condition,
 			{Name: value.EventActivate, Src: []string{value.StateReady}, Dst: value.StateActive},
 			{Name: value.EventNext, Src: []string{value.StateActive}, Dst: value.StateNext},
 			{Name: value.EventFinish, Src: []string{value.StateNext}, Dst: value.StateFinish},
-			{Name: value.EventReset, Src: []string{value.StateActive, value.StateNext, value.StateFinish}, Dst: value.StateReady},
+			{Name: value.EventPause, Src: []string{value.StateActive}, Dst: value.StatePaused},
+			{Name: value.EventResume, Src: []string{value.StatePaused}, Dst: value.StateActive},
+			{Name: value.EventFail, Src: []string{value.StateActive, value.StatePaused}, Dst: value.StateFailed},
+			{Name: value.EventSkip, Src: []string{value.StateActive, value.StatePaused, value.StateNext, value.StateFailed}, Dst: value.StateFinish},
+			{Name: value.EventReset, Src: []string{value.StateActive, value.StateNext, value.StateFinish, value.StatePaused, value.StateFailed}, Dst: value.StateReady},
 		},
 		callbacks,
 	)
@@ -136,17 +224,23 @@ func (p *Phase) OnConditionChanged(condition interface{}) {
 		return
 	}
 
-	if cond.CurrentState() != value.StateSatisfied {
-		return
-	}
-
+	// 満たされた条件が未達成に戻った場合も集合から取り除き、完了条件式を評価し直す
 	p.mu.Lock()
-	p.SatisfiedConditions[cond.ID] = true
+	if cond.CurrentState() == value.StateSatisfied {
+		p.SatisfiedConditions[cond.ID] = true
+	} else {
+		delete(p.SatisfiedConditions, cond.ID)
+	}
 	satisfied := p.checkConditionsSatisfied()
+	currentState := p.CurrentState()
 	if satisfied {
+		if !p.IsClear {
+			p.CompletedBy = cond.ID
+		}
 		p.IsClear = true
+	} else if currentState == value.StateActive {
+		p.IsClear = false
 	}
-	currentState := p.CurrentState()
 	p.mu.Unlock()
 
 	p.log.Debug("Phase.OnConditionChanged",
@@ -155,6 +249,17 @@ func (p *Phase) OnConditionChanged(condition interface{}) {
 		zap.Int64("condition_id", int64(cond.ID)),
 		zap.String("current_state", currentState))
 
+	// 条件の失敗で完了し得なくなったアクティブなフェーズは失敗させる
+	if cond.IsFailed() && currentState == value.StateActive && !p.CanComplete() {
+		p.log.Warn("Phase.OnConditionChanged: phase can no longer be completed",
+			zap.String("phase", p.Name),
+			zap.Int64("failed_condition_id", int64(cond.ID)))
+		if err := p.Fail(context.Background(), value.OutcomeFailed); err != nil {
+			p.log.Error("Failed to fail phase", zap.Error(err))
+		}
+		return
+	}
+
 	// 条件が満たされ、かつフェーズがactive状態の場合のみNextを呼び出す
 	if satisfied && currentState == value.StateActive {
 		p.log.Debug("Phase.OnConditionChanged: Moving to next state",
@@ -177,16 +282,91 @@ func (p *Phase) checkConditionsSatisfied() bool {
 		return false
 	}
 
+	expr := p.completionExpr()
+	if expr == nil {
+		return false
+	}
+	return expr.Evaluate(p.SatisfiedConditions)
+}
+
+// FailedConditions は失敗した条件のIDの集合を返します
+func (p *Phase) FailedConditions() map[value.ConditionID]bool {
+	failed := make(map[value.ConditionID]bool)
+	for _, id := range p.ConditionIDs {
+		if p.Conditions[id].IsFailed() {
+			failed[id] = true
+		}
+	}
+	return failed
+}
+
+// CanComplete は失敗した条件を除いて、フェーズがこの先完了し得るかを返します
+// 完了条件式が組み立てられない場合はfalseを返します
+func (p *Phase) CanComplete() bool {
+	p.mu.RLock()
+	expr := p.completionExpr()
+	p.mu.RUnlock()
+	if expr == nil {
+		return false
+	}
+	return expr.CanBeSatisfied(p.FailedConditions())
+}
+
+// completionExpr は評価に使う完了条件式を返します
+// 完了条件式が設定されていない場合はConditionTypeに応じて条件IDを並べた式を組み立てます
+func (p *Phase) completionExpr() *CompletionExpr {
+	if p.Completion != nil {
+		return p.Completion
+	}
+
+	refs := make([]*CompletionExpr, 0, len(p.ConditionIDs))
+	for _, id := range p.ConditionIDs {
+		refs = append(refs, ExprRef(id))
+	}
+	switch p.ConditionType {
+	case value.ConditionTypeAnd:
+		return ExprAndOf(refs...)
+	case value.ConditionTypeOr:
+		return ExprOrOf(refs...)
+	case value.ConditionTypeThreshold:
+		return ExprAtLeastOf(p.RequiredConditions, refs...)
+	default:
+		return nil
+	}
+}
+
+// requiredConditionCount は条件の組み合わせ方に応じてフェーズの完了に必要な条件数を返します
+// 完了条件式で完了するフェーズは条件数で表せないため、組み合わせ方が未指定の場合と同じく0を返します
+func (p *Phase) requiredConditionCount() int {
+	if p.Completion != nil {
+		return 0
+	}
 	switch p.ConditionType {
 	case value.ConditionTypeOr:
-		return len(p.SatisfiedConditions) > 0
+		return 1
 	case value.ConditionTypeAnd:
-		return len(p.SatisfiedConditions) == len(p.ConditionIDs)
+		return len(p.ConditionIDs)
+	case value.ConditionTypeThreshold:
+		return p.RequiredConditions
 	default:
-		return false
+		return 0
 	}
 }
 
+// RequiredConditionCount はフェーズの完了に必要な条件数を返します
+func (p *Phase) RequiredConditionCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.requiredConditionCount()
+}
+
+// SatisfiedConditionCount は満たされた条件数を返します
+func (p *Phase) SatisfiedConditionCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.SatisfiedConditions)
+}
+
 // CurrentState は現在の状態を返します
 func (p *Phase) CurrentState() string {
 	return p.fsm.Current()
@@ -217,13 +397,116 @@ func (p *Phase) Next(ctx context.Context) error {
 	return p.fsm.Event(ctx, value.EventNext)
 }
 
+// SkipNext はオペレーターが飛ばしたものとして結果にskippedを記録し、next状態に進めます
+// 通常の完了と同じく次のフェーズへ遷移しますが、遷移の辺はskippedの結果で照合されます
+// 条件とパーツはリセットしてタイマーなどを止めます
+func (p *Phase) SkipNext(ctx context.Context) error {
+	if !p.fsm.Can(value.EventNext) {
+		return fmt.Errorf("phase %d (%s) cannot be skipped in state %s", p.ID, p.Name, p.CurrentState())
+	}
+	if err := p.resetConditions(ctx); err != nil {
+		return err
+	}
+	return p.fsm.Event(ctx, value.EventNext, value.OutcomeSkipped)
+}
+
 // Finish はフェーズを終了します
 func (p *Phase) Finish(ctx context.Context) error {
 	return p.fsm.Event(ctx, value.EventFinish)
 }
 
+// Fail はフェーズを失敗させ、結果を記録します
+// 失敗したフェーズはリセットされるまでfailed状態に留まります
+func (p *Phase) Fail(ctx context.Context, outcome value.PhaseOutcome) error {
+	return p.fsm.Event(ctx, value.EventFail, outcome)
+}
+
+// Skip はオペレーターが別のフェーズに移るために、フェーズを飛ばして終了させます
+// 条件とパーツはリセットしてタイマーなどを止め、次のフェーズへの遷移は行いません
+func (p *Phase) Skip(ctx context.Context) error {
+	if !p.fsm.Can(value.EventSkip) {
+		return fmt.Errorf("phase %d (%s) cannot be skipped in state %s", p.ID, p.Name, p.CurrentState())
+	}
+	if err := p.resetConditions(ctx); err != nil {
+		return err
+	}
+	return p.fsm.Event(ctx, value.EventSkip)
+}
+
+// IsFailed はフェーズが失敗したかどうかを返します
+func (p *Phase) IsFailed() bool {
+	return p.CurrentState() == value.StateFailed
+}
+
+// Pause はフェーズを一時停止します
+// 条件パーツのタイマーは残り時間を保持して止まり、カウンターは入力を受け付けなくなります
+func (p *Phase) Pause(ctx context.Context) error {
+	return p.fsm.Event(ctx, value.EventPause)
+}
+
+// Resume は一時停止したフェーズを再開します
+// 一時停止中に条件が満たされていた場合は、再開後にnext状態へ進みます
+func (p *Phase) Resume(ctx context.Context) error {
+	if err := p.fsm.Event(ctx, value.EventResume); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	isClear := p.IsClear
+	p.mu.RUnlock()
+
+	if isClear && p.CurrentState() == value.StateActive {
+		return p.Next(ctx)
+	}
+	return nil
+}
+
+// IsPaused フェーズが一時停止中かどうかを返します
+func (p *Phase) IsPaused() bool {
+	return p.CurrentState() == value.StatePaused
+}
+
 // Reset はフェーズをリセットします
 func (p *Phase) Reset(ctx context.Context) error {
+	return p.reset(ctx)
+}
+
+// repeatReset はリセットが繰り返しのためのものであることを示すイベント引数です
+type repeatReset struct{}
+
+// isRepeat はリセットが繰り返しのためのものかを返します
+func isRepeat(e *fsm.Event) bool {
+	for _, arg := range e.Args {
+		if _, ok := arg.(repeatReset); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Repeat は次の繰り返しに備えて、繰り返し回数を保ったままフェーズの条件とパーツをリセットします
+// 子フェーズはリセットしないため、呼び出し側でリセットしてください
+func (p *Phase) Repeat(ctx context.Context) error {
+	return p.reset(ctx, repeatReset{})
+}
+
+// ShouldRepeat は完了したフェーズをもう一度繰り返すかを返します
+// RepeatUntilが真になった場合と、繰り返し回数がRepeatCountに達した場合は繰り返しません
+func (p *Phase) ShouldRepeat() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.RepeatCount == 0 && p.RepeatUntil == nil {
+		return false
+	}
+	if p.RepeatUntil != nil && p.RepeatUntil.Evaluate(p.SatisfiedConditions) {
+		return false
+	}
+	return p.RepeatCount == 0 || p.Iteration < p.RepeatCount
+}
+
+// reset は条件とパーツをリセットしてからフェーズをready状態に戻します
+func (p *Phase) reset(ctx context.Context, args ...interface{}) error {
 	if p.CurrentState() == value.StateReady {
 		return nil
 	}
@@ -248,13 +531,27 @@ func (p *Phase) Reset(ctx context.Context) error {
 		zap.Int("phase_order", p.Order))
 
 	// 条件とパーツをリセット
-	for _, cond := range p.Conditions {
+	if err := p.resetConditions(ctx); err != nil {
+		return err
+	}
+
+	return p.fsm.Event(ctx, value.EventReset, args...)
+}
+
+// resetConditions は条件とパーツをConditionIDsの順にリセットします
+// ジャーナルに記録される順序を再生時と揃えるため、マップの順には回しません
+// 飛ばしたフェーズのようにすでにリセット済みの条件はそのままにします
+func (p *Phase) resetConditions(ctx context.Context) error {
+	for _, id := range p.ConditionIDs {
+		cond := p.Conditions[id]
+		if cond.CurrentState() == value.StateReady {
+			continue
+		}
 		if err := cond.Reset(ctx); err != nil {
 			return fmt.Errorf("failed to reset condition: %w", err)
 		}
 	}
-
-	return p.fsm.Event(ctx, value.EventReset)
+	return nil
 }
 
 // AddObserver オブザーバーを追加します
@@ -337,6 +634,16 @@ func (p Phases) GetByOrder(order int) *Phase {
 	return nil
 }
 
+// GetByID は指定されたIDを持つフェーズを返します
+func (p Phases) GetByID(id value.PhaseID) *Phase {
+	for _, phase := range p {
+		if phase.ID == id {
+			return phase
+		}
+	}
+	return nil
+}
+
 // GetNextByOrder は現在のOrderの次のOrderを持つフェーズを返します
 func (p Phases) GetNextByOrder(currentOrder int) *Phase {
 	return p.GetByOrder(currentOrder + 1)
@@ -422,6 +729,10 @@ func InitializePhaseHierarchy(phases Phases) {
 			if parent, ok := phaseByID[phase.ParentID]; ok {
 				parent.AddChild(phase)
 				phase.Parent = parent
+			} else {
+				phase.log.Warn("InitializePhaseHierarchy: parent phase not found",
+					zap.String("phase", phase.Name),
+					zap.Int("parent_id", int(phase.ParentID)))
 			}
 		}
 	}
@@ -485,3 +796,41 @@ func (p Phases) ProcessAndActivateByNextOrder(ctx context.Context) (*Phase, erro
 	}
 	return current, nil
 }
+
+// SetClock はフェーズとその条件・条件パーツが時刻の記録に使うClockを設定します
+func (p *Phase) SetClock(c clock.Clock) {
+	p.mu.Lock()
+	p.clock = c
+	p.mu.Unlock()
+
+	for _, id := range p.ConditionIDs {
+		p.Conditions[id].SetClock(c)
+	}
+}
+
+// AddTransitionObserver 状態遷移オブザーバーを追加します
+func (p *Phase) AddTransitionObserver(observer service.TransitionObserver) {
+	if observer == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.transitionObservers = append(p.transitionObservers, observer)
+}
+
+// notifyTransition 状態遷移を通知します
+// 同じ状態への遷移(NoTransition)は通知しません
+func (p *Phase) notifyTransition(e *fsm.Event) {
+	if e.Src == e.Dst {
+		return
+	}
+
+	p.mu.RLock()
+	observers := make([]service.TransitionObserver, len(p.transitionObservers))
+	copy(observers, p.transitionObservers)
+	p.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnTransition(p, e.Event, e.Src, e.Dst)
+	}
+}
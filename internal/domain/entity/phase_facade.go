@@ -3,6 +3,7 @@ package entity
 import (
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 
 	"go.uber.org/zap"
@@ -13,6 +14,7 @@ type PhaseFacade struct {
 	allPhases       Phases
 	phaseMap        PhaseMap
 	currentPhaseMap CurrentPhaseMap
+	clock           clock.Clock
 	mu              sync.RWMutex
 	log             *zap.Logger
 }
@@ -31,10 +33,23 @@ func NewPhaseFacade(phases Phases) *PhaseFacade {
 		allPhases:       phases,
 		phaseMap:        phaseMap,
 		currentPhaseMap: make(CurrentPhaseMap),
+		clock:           clock.Real(),
 		log:             log,
 	}
 }
 
+// SetClock は全フェーズとスナップショットが時刻の記録に使うClockを設定します
+func (pf *PhaseFacade) SetClock(c clock.Clock) {
+	pf.mu.Lock()
+	pf.clock = c
+	phases := pf.allPhases
+	pf.mu.Unlock()
+
+	for _, phase := range phases {
+		phase.SetClock(c)
+	}
+}
+
 // GetAllPhases は全フェーズを取得します
 func (pf *PhaseFacade) GetAllPhases() Phases {
 	pf.mu.RLock()
@@ -63,8 +78,9 @@ func (pf *PhaseFacade) GetCurrentPhase(parentID value.PhaseID) *Phase {
 	return pf.currentPhaseMap[parentID]
 }
 
-// GetCurrentLeafPhase は現在アクティブな最下層のフェーズを返します
-func (pf *PhaseFacade) GetCurrentLeafPhase() *Phase {
+// GetCurrentLeafPhases は現在アクティブな最下層のフェーズの集合を返します
+// 並列領域を持つフェーズの下では、動いている領域ごとの最下層のフェーズを返します
+func (pf *PhaseFacade) GetCurrentLeafPhases() Phases {
 	pf.mu.RLock()
 	defer pf.mu.RUnlock()
 
@@ -75,32 +91,43 @@ func (pf *PhaseFacade) GetCurrentLeafPhase() *Phase {
 	}
 
 	// 子フェーズがある場合は再帰的に最下層のフェーズを探す
-	return pf.FindCurrentLeafPhase(rootPhase)
+	return pf.FindCurrentLeafPhases(rootPhase)
 }
 
-// FindCurrentLeafPhase は再帰的に最下層のフェーズを探します
-func (pf *PhaseFacade) FindCurrentLeafPhase(phase *Phase) *Phase {
+// FindCurrentLeafPhases は再帰的に最下層のフェーズを探します
+// 呼び出し側でロックを取得している必要があります
+func (pf *PhaseFacade) FindCurrentLeafPhases(phase *Phase) Phases {
 	if phase == nil {
-		pf.log.Error("FindCurrentLeafPhase: phase is nil")
+		pf.log.Error("FindCurrentLeafPhases: phase is nil")
 		return nil
 	}
 
 	if !phase.HasChildren() {
-		return phase
+		return Phases{phase}
 	}
 
-	childPhase := pf.currentPhaseMap[phase.ID]
-	if childPhase == nil {
-		return phase
+	if phase.Parallel {
+		// 開始前の領域と、完了・失敗して終わった領域は含めない
+		leaves := make(Phases, 0)
+		for _, region := range phase.GetChildren() {
+			switch region.CurrentState() {
+			case value.StateReady, value.StateFinish, value.StateFailed:
+				continue
+			}
+			leaves = append(leaves, pf.FindCurrentLeafPhases(region)...)
+		}
+		if len(leaves) == 0 {
+			return Phases{phase}
+		}
+		return leaves
 	}
 
-	// 再帰呼び出しの結果がnilの場合は現在のフェーズを返す
-	result := pf.FindCurrentLeafPhase(childPhase)
-	if result == nil {
-		return phase
+	childPhase := pf.currentPhaseMap[phase.ID]
+	if childPhase == nil {
+		return Phases{phase}
 	}
 
-	return result
+	return pf.FindCurrentLeafPhases(childPhase)
 }
 
 // GetPhasesByParentID は指定された親IDに対するフェーズのスライスを返します
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockPhaseStateObserver は PhaseObserver インターフェースのモック実装です
@@ -196,6 +197,29 @@ func TestPhaseConditionTypes(t *testing.T) {
 	}
 }
 
+func TestPhaseThresholdConditionType(t *testing.T) {
+	conditions := []*Condition{
+		NewCondition(1, "Condition 1", value.KindCounter),
+		NewCondition(2, "Condition 2", value.KindCounter),
+		NewCondition(3, "Condition 3", value.KindCounter),
+	}
+	phase := NewPhase(1, "Test Phase", 1, conditions, value.ConditionTypeThreshold, value.GameRule_Shooting, 0, false)
+	phase.RequiredConditions = 2
+	assert.NoError(t, phase.Activate(context.Background()))
+	assert.Equal(t, 2, phase.RequiredConditionCount())
+
+	phase.SatisfiedConditions[1] = true
+	assert.False(t, phase.checkConditionsSatisfied())
+	assert.Equal(t, 1, phase.SatisfiedConditionCount())
+
+	phase.SatisfiedConditions[3] = true
+	assert.True(t, phase.checkConditionsSatisfied())
+
+	// 必要数が未設定の閾値型は満たされない
+	phase.RequiredConditions = 0
+	assert.False(t, phase.checkConditionsSatisfied())
+}
+
 func TestPhaseGetStateInfo(t *testing.T) {
 	// テスト用のPhase
 	phase := NewPhase(1, "Test Phase", 1, []*Condition{}, value.ConditionTypeOr, value.GameRule_Shooting, 0, false)
@@ -337,6 +361,64 @@ func TestPhaseReset(t *testing.T) {
 	assert.Equal(t, value.StateReady, condition.CurrentState())
 }
 
+func TestPhaseFail(t *testing.T) {
+	condition := NewCondition(1, "Test Condition", value.KindCounter)
+	condition.AddPart(NewConditionPart(1, "Test Part"))
+	phase := NewPhase(1, "Test Phase", 1, []*Condition{condition}, value.ConditionTypeOr, value.GameRule_Shooting, 0, false)
+	ctx := context.Background()
+
+	// アクティブになる前は失敗させられない
+	assert.Error(t, phase.Fail(ctx, value.OutcomeAborted))
+
+	require.NoError(t, phase.Activate(ctx))
+	require.NoError(t, phase.Fail(ctx, value.OutcomeAborted))
+	assert.True(t, phase.IsFailed())
+	assert.False(t, phase.IsActive())
+	assert.Equal(t, value.OutcomeAborted, phase.Outcome)
+	assert.NotNil(t, phase.FinishTime)
+	assert.Equal(t, "Failed", phase.GetStateInfo().Name)
+
+	// リセットすると結果も消える
+	require.NoError(t, phase.Reset(ctx))
+	assert.Equal(t, value.StateReady, phase.CurrentState())
+	assert.Equal(t, value.OutcomeNone, phase.Outcome)
+
+	// 完了したフェーズの結果は成功になる
+	require.NoError(t, phase.Activate(ctx))
+	require.NoError(t, phase.Next(ctx))
+	assert.Equal(t, value.OutcomeSucceeded, phase.Outcome)
+	assert.Error(t, phase.Fail(ctx, value.OutcomeFailed))
+	assert.Equal(t, value.OutcomeSucceeded, phase.Outcome)
+}
+
+func TestPhaseSkip(t *testing.T) {
+	condition := NewCondition(1, "Test Condition", value.KindCounter)
+	condition.AddPart(NewConditionPart(1, "Test Part"))
+	phase := NewPhase(1, "Test Phase", 1, []*Condition{condition}, value.ConditionTypeOr, value.GameRule_Shooting, 0, false)
+	ctx := context.Background()
+
+	// 開始していないフェーズは飛ばせない
+	assert.Error(t, phase.Skip(ctx))
+
+	// 飛ばすと条件をリセットして終了し、結果はskippedになる
+	require.NoError(t, phase.Activate(ctx))
+	require.NoError(t, phase.Skip(ctx))
+	assert.Equal(t, value.StateFinish, phase.CurrentState())
+	assert.Equal(t, value.OutcomeSkipped, phase.Outcome)
+	assert.Equal(t, value.StateReady, condition.CurrentState())
+
+	// 条件がリセット済みでもフェーズはリセットできる
+	require.NoError(t, phase.Reset(ctx))
+	assert.Equal(t, value.StateReady, phase.CurrentState())
+	assert.Equal(t, value.OutcomeNone, phase.Outcome)
+
+	// 次のフェーズに進めるために飛ばすとnext状態になり、結果はskippedになる
+	require.NoError(t, phase.Activate(ctx))
+	require.NoError(t, phase.SkipNext(ctx))
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+	assert.Equal(t, value.OutcomeSkipped, phase.Outcome)
+}
+
 func TestPhaseHierarchy(t *testing.T) {
 	// 親フェーズの作成
 	parentPhase := NewPhase(1, "Parent Phase", 1, []*Condition{}, value.ConditionTypeOr, value.GameRule_Shooting, 0, true)
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"fmt"
+	"state_sample/internal/domain/value"
+)
+
+// PhaseTransition はフェーズが終わった後に移る兄弟フェーズを表す遷移の辺です
+// 辺は宣言順に調べられ、最初に一致した辺に従います。一致する辺がない場合はOrderの次のフェーズに進みます
+type PhaseTransition struct {
+	ConditionID value.ConditionID  // この条件が満たされてフェーズが完了した場合に従う(0の場合はどの条件でもよい)
+	Outcome     value.PhaseOutcome // この結果でフェーズが終わった場合に従う(空の場合はsucceeded)
+	To          value.PhaseID      // 移り先の兄弟フェーズのID(0の場合は兄弟フェーズに移らずに終える)
+}
+
+// outcome は辺が一致するフェーズの結果を返します
+func (t PhaseTransition) outcome() value.PhaseOutcome {
+	if t.Outcome == value.OutcomeNone {
+		return value.OutcomeSucceeded
+	}
+	return t.Outcome
+}
+
+// String は遷移の辺を文字列で返します
+func (t PhaseTransition) String() string {
+	trigger := string(t.outcome())
+	if t.ConditionID != 0 {
+		trigger = fmt.Sprintf("%s(%d)", trigger, t.ConditionID)
+	}
+	if t.To == 0 {
+		return trigger + " -> end"
+	}
+	return fmt.Sprintf("%s -> %d", trigger, t.To)
+}
+
+// MatchTransition はフェーズの結果と完了させた条件に一致する遷移の辺を探し、移り先のフェーズIDを返します
+// 一致する辺がない場合はfalseを返します
+func (p *Phase) MatchTransition() (value.PhaseID, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, t := range p.Transitions {
+		if t.outcome() != p.Outcome {
+			continue
+		}
+		if t.ConditionID != 0 && t.ConditionID != p.CompletedBy {
+			continue
+		}
+		return t.To, true
+	}
+	return 0, false
+}
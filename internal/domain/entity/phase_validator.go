@@ -0,0 +1,533 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"state_sample/internal/domain/value"
+	"strings"
+)
+
+// ValidationErrorKind はシナリオ検証で見つかった問題の種類を表す型です
+type ValidationErrorKind string
+
+const (
+	ValidationDuplicatePhaseID     ValidationErrorKind = "duplicate_phase_id"
+	ValidationDuplicateConditionID ValidationErrorKind = "duplicate_condition_id"
+	ValidationDuplicatePartID      ValidationErrorKind = "duplicate_part_id"
+	ValidationOrphanParent         ValidationErrorKind = "orphan_parent"
+	ValidationParentCycle          ValidationErrorKind = "parent_cycle"
+	ValidationDuplicateOrder       ValidationErrorKind = "duplicate_order"
+	ValidationOrderGap             ValidationErrorKind = "order_gap"
+	ValidationOrderStart           ValidationErrorKind = "order_start"
+	ValidationMissingStrategy      ValidationErrorKind = "missing_strategy"
+	ValidationInvalidPart          ValidationErrorKind = "invalid_part"
+	ValidationInvalidTimePart      ValidationErrorKind = "invalid_time_part"
+	ValidationUnsupportedOperator  ValidationErrorKind = "unsupported_operator"
+	ValidationInvalidCombination   ValidationErrorKind = "invalid_combination"
+	ValidationInvalidThreshold     ValidationErrorKind = "invalid_threshold"
+	ValidationInvalidCompletion    ValidationErrorKind = "invalid_completion"
+	ValidationInvalidDuration      ValidationErrorKind = "invalid_duration"
+	ValidationInvalidSequence      ValidationErrorKind = "invalid_sequence"
+	ValidationInvalidFailure       ValidationErrorKind = "invalid_failure"
+	ValidationInvalidTransition    ValidationErrorKind = "invalid_transition"
+	ValidationInvalidParallel      ValidationErrorKind = "invalid_parallel"
+	ValidationInvalidRepeat        ValidationErrorKind = "invalid_repeat"
+)
+
+// ValidationError はシナリオ検証で見つかった1件の問題です
+// 問題の発生箇所に応じてPhaseID / ConditionID / PartIDが設定されます
+type ValidationError struct {
+	Kind        ValidationErrorKind   `json:"kind"`
+	PhaseID     value.PhaseID         `json:"phase_id,omitempty"`
+	ConditionID value.ConditionID     `json:"condition_id,omitempty"`
+	PartID      value.ConditionPartID `json:"part_id,omitempty"`
+	Message     string                `json:"message"`
+}
+
+// Error はerrorインターフェースを実装します
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+// ValidationErrors は検証で見つかった全ての問題をまとめたエラーです
+type ValidationErrors []ValidationError
+
+// Error はerrorインターフェースを実装します
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, ve := range e {
+		messages[i] = ve.Error()
+	}
+	return fmt.Sprintf("%d validation error(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// HasKind は指定された種類の問題が含まれているかを返します
+func (e ValidationErrors) HasKind(kind ValidationErrorKind) bool {
+	for _, ve := range e {
+		if ve.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePhases はフェーズツリー全体の構造を検証します
+// 問題がなければnilを、問題があれば全件をValidationErrorsとして返します
+func ValidatePhases(phases Phases) error {
+	var errs ValidationErrors
+
+	phaseByID := make(map[value.PhaseID]*Phase)
+	for _, phase := range phases {
+		if _, exists := phaseByID[phase.ID]; exists {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationDuplicatePhaseID,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase id %d is used by more than one phase (%s)", phase.ID, phase.Name),
+			})
+			continue
+		}
+		phaseByID[phase.ID] = phase
+	}
+
+	errs = append(errs, validateParents(phases, phaseByID)...)
+	errs = append(errs, validateOrders(phases)...)
+	errs = append(errs, validateConditions(phases)...)
+	errs = append(errs, validateThresholds(phases)...)
+	errs = append(errs, validateCompletions(phases)...)
+	errs = append(errs, validateRepeats(phases)...)
+	errs = append(errs, validateFailures(phases, phaseByID)...)
+	errs = append(errs, validateTransitions(phases, phaseByID)...)
+	errs = append(errs, validateParallels(phases, phaseByID)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateParents は存在しない親IDと親子関係の循環を検出します
+func validateParents(phases Phases, phaseByID map[value.PhaseID]*Phase) ValidationErrors {
+	var errs ValidationErrors
+
+	for _, phase := range phases {
+		if phase.ParentID == 0 {
+			continue
+		}
+		if _, ok := phaseByID[phase.ParentID]; !ok {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationOrphanParent,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) refers to missing parent %d", phase.ID, phase.Name, phase.ParentID),
+			})
+		}
+	}
+
+	// 循環はメンバーのうち最小のIDで1度だけ報告する
+	reported := make(map[value.PhaseID]bool)
+	for _, phase := range phases {
+		visited := make(map[value.PhaseID]bool)
+		path := make([]value.PhaseID, 0)
+		current := phase
+		for current != nil && current.ParentID != 0 {
+			if visited[current.ID] {
+				break
+			}
+			visited[current.ID] = true
+			path = append(path, current.ID)
+			current = phaseByID[current.ParentID]
+		}
+		if current == nil || current.ParentID == 0 || current.ID != phase.ID {
+			continue
+		}
+
+		cycle := append([]value.PhaseID(nil), path...)
+		sort.Slice(cycle, func(i, j int) bool { return cycle[i] < cycle[j] })
+		if reported[cycle[0]] {
+			continue
+		}
+		reported[cycle[0]] = true
+		errs = append(errs, ValidationError{
+			Kind:    ValidationParentCycle,
+			PhaseID: cycle[0],
+			Message: fmt.Sprintf("parent chain forms a cycle: %v", path),
+		})
+	}
+
+	return errs
+}
+
+// validateOrders は同じ親を持つフェーズのOrderが1から始まり、重複なく連番になっているかを検証します
+func validateOrders(phases Phases) ValidationErrors {
+	var errs ValidationErrors
+
+	phaseMap := GroupPhasesByParentID(phases)
+	parentIDs := make([]value.PhaseID, 0, len(phaseMap))
+	for parentID := range phaseMap {
+		parentIDs = append(parentIDs, parentID)
+	}
+	sort.Slice(parentIDs, func(i, j int) bool { return parentIDs[i] < parentIDs[j] })
+
+	for _, parentID := range parentIDs {
+		siblings := phaseMap[parentID]
+		// 兄弟の前後はOrderの連番で辿るため、Orderは1から始まっている必要がある
+		if first := siblings[0]; first.Order != 1 {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationOrderStart,
+				PhaseID: first.ID,
+				Message: fmt.Sprintf("orders under parent %d start at %d instead of 1", parentID, first.Order),
+			})
+		}
+		for i := 1; i < len(siblings); i++ {
+			prev, cur := siblings[i-1], siblings[i]
+			switch {
+			case cur.Order == prev.Order:
+				errs = append(errs, ValidationError{
+					Kind:    ValidationDuplicateOrder,
+					PhaseID: cur.ID,
+					Message: fmt.Sprintf("phases %d and %d under parent %d share order %d", prev.ID, cur.ID, parentID, cur.Order),
+				})
+			case cur.Order != prev.Order+1:
+				errs = append(errs, ValidationError{
+					Kind:    ValidationOrderGap,
+					PhaseID: cur.ID,
+					Message: fmt.Sprintf("order jumps from %d to %d under parent %d", prev.Order, cur.Order, parentID),
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateThresholds は閾値型のフェーズの必要条件数が条件数の範囲内にあるかを検証します
+func validateThresholds(phases Phases) ValidationErrors {
+	var errs ValidationErrors
+	for _, phase := range phases {
+		if phase.ConditionType != value.ConditionTypeThreshold {
+			continue
+		}
+		if phase.RequiredConditions < 1 || phase.RequiredConditions > len(phase.ConditionIDs) {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidThreshold,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) requires %d conditions but has %d",
+					phase.ID, phase.Name, phase.RequiredConditions, len(phase.ConditionIDs)),
+			})
+		}
+	}
+	return errs
+}
+
+// validateCompletions は完了条件式の構造と、式がフェーズの条件だけを参照しているかを検証します
+func validateCompletions(phases Phases) ValidationErrors {
+	var errs ValidationErrors
+	for _, phase := range phases {
+		if phase.Completion == nil {
+			continue
+		}
+		conditions := make(map[value.ConditionID]bool, len(phase.ConditionIDs))
+		for _, id := range phase.ConditionIDs {
+			conditions[id] = true
+		}
+		if err := phase.Completion.Validate(conditions); err != nil {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidCompletion,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) completion: %v", phase.ID, phase.Name, err),
+			})
+		}
+	}
+	return errs
+}
+
+// validateRepeats は繰り返し回数の上限が負でなく、繰り返しを終える式がフェーズ自身の条件だけを参照しているかを検証します
+func validateRepeats(phases Phases) ValidationErrors {
+	var errs ValidationErrors
+	for _, phase := range phases {
+		if phase.RepeatCount < 0 {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidRepeat,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) needs a non-negative repeat count, got %d", phase.ID, phase.Name, phase.RepeatCount),
+			})
+		}
+		if phase.RepeatUntil == nil {
+			continue
+		}
+		conditions := make(map[value.ConditionID]bool, len(phase.ConditionIDs))
+		for _, id := range phase.ConditionIDs {
+			conditions[id] = true
+		}
+		if err := phase.RepeatUntil.Validate(conditions); err != nil {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidRepeat,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) repeat until: %v", phase.ID, phase.Name, err),
+			})
+		}
+	}
+	return errs
+}
+
+// validateFailures は失敗時の移り先が兄弟フェーズを指しているか、やり直し回数の上限が負でないかを検証します
+func validateFailures(phases Phases, phaseByID map[value.PhaseID]*Phase) ValidationErrors {
+	var errs ValidationErrors
+	for _, phase := range phases {
+		if phase.MaxRetries < 0 {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidFailure,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) needs a non-negative retry limit, got %d", phase.ID, phase.Name, phase.MaxRetries),
+			})
+		}
+		if phase.OnFailure != value.FailureGoto {
+			continue
+		}
+		target, ok := phaseByID[phase.OnFailurePhaseID]
+		if !ok || target.ParentID != phase.ParentID {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidFailure,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) moves to phase %d on failure, which is not a sibling", phase.ID, phase.Name, phase.OnFailurePhaseID),
+			})
+		}
+	}
+	return errs
+}
+
+// validateTransitions は遷移の辺が兄弟フェーズを指し、フェーズ自身の条件と正しい結果を参照しているかを検証します
+func validateTransitions(phases Phases, phaseByID map[value.PhaseID]*Phase) ValidationErrors {
+	var errs ValidationErrors
+	for _, phase := range phases {
+		newError := func(t PhaseTransition, reason string) ValidationError {
+			return ValidationError{
+				Kind:    ValidationInvalidTransition,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) transition %s: %s", phase.ID, phase.Name, t, reason),
+			}
+		}
+
+		for _, t := range phase.Transitions {
+			if t.To != 0 {
+				if target, ok := phaseByID[t.To]; !ok || target.ParentID != phase.ParentID {
+					errs = append(errs, newError(t, "target is not a sibling"))
+				}
+			}
+			if t.ConditionID != 0 && phase.Conditions[t.ConditionID] == nil {
+				errs = append(errs, newError(t, "condition does not belong to the phase"))
+			}
+			switch t.outcome() {
+			case value.OutcomeSucceeded:
+			case value.OutcomeFailed, value.OutcomeAborted, value.OutcomeSkipped:
+				// 失敗したフェーズや飛ばしたフェーズを完了させた条件はないため、条件を指定できない
+				if t.ConditionID != 0 {
+					errs = append(errs, newError(t, "condition can only be used for succeeded phases"))
+				}
+			default:
+				errs = append(errs, newError(t, fmt.Sprintf("unknown outcome %q", t.Outcome)))
+			}
+		}
+	}
+	return errs
+}
+
+// validateConditions は条件とパーツのID重複、パーツの組み合わせ方、戦略の設定、パーツ単体の妥当性を検証します
+func validateConditions(phases Phases) ValidationErrors {
+	var errs ValidationErrors
+
+	conditionOwner := make(map[value.ConditionID]value.PhaseID)
+	partOwner := make(map[value.ConditionPartID]value.ConditionID)
+
+	for _, phase := range phases {
+		seenInPhase := make(map[value.ConditionID]bool)
+		for _, condID := range phase.ConditionIDs {
+			if seenInPhase[condID] {
+				errs = append(errs, ValidationError{
+					Kind:        ValidationDuplicateConditionID,
+					PhaseID:     phase.ID,
+					ConditionID: condID,
+					Message:     fmt.Sprintf("condition id %d appears more than once in phase %d", condID, phase.ID),
+				})
+				continue
+			}
+			seenInPhase[condID] = true
+
+			if owner, exists := conditionOwner[condID]; exists {
+				errs = append(errs, ValidationError{
+					Kind:        ValidationDuplicateConditionID,
+					PhaseID:     phase.ID,
+					ConditionID: condID,
+					Message:     fmt.Sprintf("condition id %d is used by phases %d and %d", condID, owner, phase.ID),
+				})
+				continue
+			}
+			conditionOwner[condID] = phase.ID
+
+			cond := phase.Conditions[condID]
+			if cond == nil {
+				continue
+			}
+			if err := cond.ValidateCombination(); err != nil {
+				errs = append(errs, ValidationError{
+					Kind:        ValidationInvalidCombination,
+					PhaseID:     phase.ID,
+					ConditionID: cond.ID,
+					Message:     fmt.Sprintf("condition %d (%s): %v", cond.ID, cond.Label, err),
+				})
+			}
+			for _, part := range cond.GetParts() {
+				if owner, exists := partOwner[part.ID]; exists {
+					errs = append(errs, ValidationError{
+						Kind:        ValidationDuplicatePartID,
+						PhaseID:     phase.ID,
+						ConditionID: cond.ID,
+						PartID:      part.ID,
+						Message:     fmt.Sprintf("part id %d is used by conditions %d and %d", part.ID, owner, cond.ID),
+					})
+				} else {
+					partOwner[part.ID] = cond.ID
+				}
+				errs = append(errs, validatePart(phase, cond, part)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validatePart はパーツ単体の妥当性を検証します
+func validatePart(phase *Phase, cond *Condition, part *ConditionPart) ValidationErrors {
+	var errs ValidationErrors
+	newError := func(kind ValidationErrorKind, message string) ValidationError {
+		return ValidationError{
+			Kind:        kind,
+			PhaseID:     phase.ID,
+			ConditionID: cond.ID,
+			PartID:      part.ID,
+			Message:     message,
+		}
+	}
+
+	if !part.HasStrategy() {
+		errs = append(errs, newError(ValidationMissingStrategy,
+			fmt.Sprintf("part %d (%s) has no strategy", part.ID, part.Label)))
+	}
+
+	if part.DeadlineSeconds < 0 {
+		errs = append(errs, newError(ValidationInvalidDuration,
+			fmt.Sprintf("part %d (%s) needs a non-negative deadline, got %d", part.ID, part.Label, part.DeadlineSeconds)))
+	}
+
+	// 数値の集合と文字列の集合はどちらか一方だけを使う
+	if len(part.GetReferenceValueSet()) > 0 && len(part.GetReferenceStringSet()) > 0 {
+		errs = append(errs, newError(ValidationInvalidPart,
+			fmt.Sprintf("part %d (%s) sets both reference_value_set and reference_string_set", part.ID, part.Label)))
+	}
+
+	// 時間条件は比較演算子を使わないため、基準値のみを検証する
+	if cond.Kind == value.KindTime {
+		if part.GetReferenceValueInt() <= 0 {
+			errs = append(errs, newError(ValidationInvalidTimePart,
+				fmt.Sprintf("time part %d (%s) needs a positive reference value, got %d", part.ID, part.Label, part.GetReferenceValueInt())))
+		}
+		return errs
+	}
+
+	// 順序入力条件は比較演算子を使わないため、入力すべき順番のみを検証する
+	if cond.Kind == value.KindSequence {
+		if len(part.GetSequence()) == 0 {
+			errs = append(errs, newError(ValidationInvalidSequence,
+				fmt.Sprintf("sequence part %d (%s) needs at least one step", part.ID, part.Label)))
+		}
+		return errs
+	}
+
+	if err := part.Validate(); err != nil {
+		errs = append(errs, newError(ValidationInvalidPart,
+			fmt.Sprintf("part %d (%s): %v", part.ID, part.Label, err)))
+	} else if op := part.GetComparisonOperator(); op == value.ComparisonOperatorIn || op == value.ComparisonOperatorNotIn {
+		// In / NotInの集合は、文字列条件では文字列の集合を、それ以外では数値の集合を使う
+		if cond.Kind == value.KindString && len(part.GetReferenceStringSet()) == 0 {
+			errs = append(errs, newError(ValidationInvalidPart,
+				fmt.Sprintf("string part %d (%s) needs a non-empty reference_string_set", part.ID, part.Label)))
+		}
+		if cond.Kind != value.KindString && len(part.GetReferenceValueSet()) == 0 {
+			errs = append(errs, newError(ValidationInvalidPart,
+				fmt.Sprintf("part %d (%s) needs a non-empty reference_value_set", part.ID, part.Label)))
+		}
+	} else if op == value.ComparisonOperatorBetween {
+		// Betweenの範囲は、小数条件では小数の範囲を、それ以外では整数の範囲を使う
+		if cond.Kind == value.KindFloat && part.GetMinValueFloat() >= part.GetMaxValueFloat() {
+			errs = append(errs, newError(ValidationInvalidPart,
+				fmt.Sprintf("float part %d (%s) needs min_value_float less than max_value_float, got %g - %g",
+					part.ID, part.Label, part.GetMinValueFloat(), part.GetMaxValueFloat())))
+		}
+		if cond.Kind != value.KindFloat && part.GetMinValue() >= part.GetMaxValue() {
+			errs = append(errs, newError(ValidationInvalidPart,
+				fmt.Sprintf("part %d (%s) needs min_value less than max_value, got %d - %d",
+					part.ID, part.Label, part.GetMinValue(), part.GetMaxValue())))
+		}
+	}
+
+	// 持続条件と時間窓条件は時間の長さとして秒数が必要
+	if (cond.Kind == value.KindSustained || cond.Kind == value.KindWindow) && part.GetDurationSeconds() <= 0 {
+		errs = append(errs, newError(ValidationInvalidDuration,
+			fmt.Sprintf("part %d (%s) needs a positive duration, got %d", part.ID, part.Label, part.GetDurationSeconds())))
+	}
+
+	if !supportsOperator(cond.Kind, part.GetComparisonOperator()) {
+		errs = append(errs, newError(ValidationUnsupportedOperator,
+			fmt.Sprintf("part %d (%s): comparison operator %d is not supported for condition kind %d",
+				part.ID, part.Label, part.GetComparisonOperator(), cond.Kind)))
+	}
+
+	return errs
+}
+
+// supportsOperator は条件の種類で比較演算子が使えるかを返します
+// 小数は集合との比較を、文字列は大小比較と範囲指定をサポートしません
+func supportsOperator(kind value.ConditionKind, op value.ComparisonOperator) bool {
+	switch kind {
+	case value.KindFloat:
+		return op != value.ComparisonOperatorIn && op != value.ComparisonOperatorNotIn
+	case value.KindString:
+		return op == value.ComparisonOperatorEQ || op == value.ComparisonOperatorNEQ ||
+			op == value.ComparisonOperatorIn || op == value.ComparisonOperatorNotIn
+	default:
+		return true
+	}
+}
+
+// validateParallels は並列領域を持つフェーズに子フェーズがあり、各領域が兄弟フェーズに移らないかを検証します
+func validateParallels(phases Phases, phaseByID map[value.PhaseID]*Phase) ValidationErrors {
+	var errs ValidationErrors
+
+	childCount := make(map[value.PhaseID]int)
+	for _, phase := range phases {
+		childCount[phase.ParentID]++
+	}
+
+	for _, phase := range phases {
+		if phase.Parallel && childCount[phase.ID] == 0 {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidParallel,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("parallel phase %d (%s) has no children", phase.ID, phase.Name),
+			})
+		}
+
+		// 並列領域は同時に動くため、領域から兄弟の領域に移ることはできない
+		parent, ok := phaseByID[phase.ParentID]
+		if !ok || !parent.Parallel {
+			continue
+		}
+		if len(phase.Transitions) > 0 || phase.OnFailure == value.FailureGoto {
+			errs = append(errs, ValidationError{
+				Kind:    ValidationInvalidParallel,
+				PhaseID: phase.ID,
+				Message: fmt.Sprintf("phase %d (%s) is a region of parallel phase %d and cannot move to a sibling", phase.ID, phase.Name, parent.ID),
+			})
+		}
+	}
+	return errs
+}
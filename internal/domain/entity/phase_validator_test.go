@@ -0,0 +1,456 @@
+package entity
+
+import (
+	"errors"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newValidCounterCondition は戦略を設定済みのカウンター条件を作成します
+func newValidCounterCondition(t *testing.T, condID value.ConditionID, partID value.ConditionPartID) *Condition {
+	part := NewConditionPart(partID, "Counter Part")
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	part.ReferenceValueInt = 3
+
+	cond := NewCondition(condID, "Counter Condition", value.KindCounter)
+	cond.AddPart(part)
+	require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+	return cond
+}
+
+// validationErrors はエラーをValidationErrorsとして取り出します
+func validationErrors(t *testing.T, err error) ValidationErrors {
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs), "expected ValidationErrors, got %v", err)
+	return errs
+}
+
+func TestValidatePhasesValid(t *testing.T) {
+	root := NewPhase(1, "Root", 1, []*Condition{newValidCounterCondition(t, 1, 1)}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	child1 := NewPhase(2, "Child 1", 1, []*Condition{newValidCounterCondition(t, 2, 2)}, value.ConditionTypeOr, value.GameRule_Shooting, 1, false)
+	child2 := NewPhase(3, "Child 2", 2, []*Condition{newValidCounterCondition(t, 3, 3)}, value.ConditionTypeOr, value.GameRule_Shooting, 1, false)
+
+	assert.NoError(t, ValidatePhases(Phases{root, child1, child2}))
+}
+
+func TestValidatePhasesStructure(t *testing.T) {
+	testCases := []struct {
+		name   string
+		phases func() Phases
+		kind   ValidationErrorKind
+	}{
+		{
+			name: "DuplicatePhaseID",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(1, "B", 2, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+				}
+			},
+			kind: ValidationDuplicatePhaseID,
+		},
+		{
+			name: "OrphanParent",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(2, "B", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 99, false),
+				}
+			},
+			kind: ValidationOrphanParent,
+		},
+		{
+			name: "ParentCycle",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(2, "B", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 3, false),
+					NewPhase(3, "C", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 2, false),
+				}
+			},
+			kind: ValidationParentCycle,
+		},
+		{
+			name: "SelfParent",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 1, false),
+				}
+			},
+			kind: ValidationParentCycle,
+		},
+		{
+			name: "OrderGap",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(2, "B", 3, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+				}
+			},
+			kind: ValidationOrderGap,
+		},
+		{
+			name: "OrderStart",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(2, "B", 2, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 1, false),
+					NewPhase(3, "C", 3, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 1, false),
+				}
+			},
+			kind: ValidationOrderStart,
+		},
+		{
+			name: "DuplicateOrder",
+			phases: func() Phases {
+				return Phases{
+					NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+					NewPhase(2, "B", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+				}
+			},
+			kind: ValidationDuplicateOrder,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validationErrors(t, ValidatePhases(tc.phases()))
+			assert.True(t, errs.HasKind(tc.kind), "expected %s in %v", tc.kind, errs)
+		})
+	}
+}
+
+func TestValidatePhasesConditions(t *testing.T) {
+	t.Run("DuplicateConditionID", func(t *testing.T) {
+		phases := Phases{
+			NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1)}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+			NewPhase(2, "B", 2, []*Condition{newValidCounterCondition(t, 1, 2)}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+		}
+		errs := validationErrors(t, ValidatePhases(phases))
+		assert.True(t, errs.HasKind(ValidationDuplicateConditionID))
+	})
+
+	t.Run("DuplicatePartID", func(t *testing.T) {
+		phases := Phases{
+			NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1), newValidCounterCondition(t, 2, 1)}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+		}
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationDuplicatePartID, errs[0].Kind)
+		assert.Equal(t, value.ConditionID(2), errs[0].ConditionID)
+		assert.Equal(t, value.ConditionPartID(1), errs[0].PartID)
+	})
+
+	t.Run("MissingStrategy", func(t *testing.T) {
+		part := NewConditionPart(1, "No Strategy")
+		part.ComparisonOperator = value.ComparisonOperatorEQ
+		cond := NewCondition(1, "Cond", value.KindCounter)
+		cond.AddPart(part)
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		assert.True(t, errs.HasKind(ValidationMissingStrategy))
+	})
+
+	t.Run("InvalidPart", func(t *testing.T) {
+		part := NewConditionPart(1, "Bad Between")
+		part.ComparisonOperator = value.ComparisonOperatorBetween
+		part.MinValue = 10
+		part.MaxValue = 5
+		cond := NewCondition(1, "Cond", value.KindCounter)
+		cond.AddPart(part)
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationInvalidPart, errs[0].Kind)
+		assert.Contains(t, errs[0].Message, "min_value must be less than max_value")
+	})
+
+	t.Run("ReferenceSetForKind", func(t *testing.T) {
+		newSetCondition := func(id value.ConditionID, kind value.ConditionKind, values []int64, strs []string) *Condition {
+			part := NewConditionPart(value.ConditionPartID(id), "Set")
+			part.ComparisonOperator = value.ComparisonOperatorIn
+			part.ReferenceValueSet = values
+			part.ReferenceStringSet = strs
+			cond := NewCondition(id, "Cond", kind)
+			cond.AddPart(part)
+			require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+			return cond
+		}
+
+		// カウンターに文字列の集合、文字列条件に数値の集合を指定すると満たせないため検出する
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{
+			newSetCondition(1, value.KindCounter, nil, []string{"a"}),
+			newSetCondition(2, value.KindString, []int64{1}, nil),
+			newSetCondition(3, value.KindCounter, []int64{1}, []string{"a"}),
+		}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 3)
+		assert.Equal(t, value.ConditionID(1), errs[0].ConditionID)
+		assert.Contains(t, errs[0].Message, "needs a non-empty reference_value_set")
+		assert.Equal(t, value.ConditionID(2), errs[1].ConditionID)
+		assert.Contains(t, errs[1].Message, "needs a non-empty reference_string_set")
+		assert.Equal(t, value.ConditionID(3), errs[2].ConditionID)
+		assert.Contains(t, errs[2].Message, "sets both")
+
+		phases = Phases{NewPhase(1, "A", 1, []*Condition{
+			newSetCondition(1, value.KindCounter, []int64{1, 2}, nil),
+			newSetCondition(2, value.KindString, nil, []string{"a", "b"}),
+		}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+		assert.NoError(t, ValidatePhases(phases))
+	})
+
+	t.Run("BetweenBoundsForKind", func(t *testing.T) {
+		// 小数条件は小数の範囲を、それ以外は整数の範囲を使う
+		floatPart := NewConditionPart(1, "Int Bounds On Float")
+		floatPart.ComparisonOperator = value.ComparisonOperatorBetween
+		floatPart.MinValue = 0
+		floatPart.MaxValue = 2
+		floatCond := NewCondition(1, "Float", value.KindFloat)
+		floatCond.AddPart(floatPart)
+		require.NoError(t, floatCond.InitializePartStrategies(&MockStrategyFactory{}))
+
+		counterPart := NewConditionPart(2, "Float Bounds On Counter")
+		counterPart.ComparisonOperator = value.ComparisonOperatorBetween
+		counterPart.MinValueFloat = 0.5
+		counterPart.MaxValueFloat = 1.5
+		counterCond := NewCondition(2, "Counter", value.KindCounter)
+		counterCond.AddPart(counterPart)
+		require.NoError(t, counterCond.InitializePartStrategies(&MockStrategyFactory{}))
+
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{floatCond, counterCond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 2)
+		assert.Equal(t, ValidationInvalidPart, errs[0].Kind)
+		assert.Contains(t, errs[0].Message, "min_value_float")
+		assert.Equal(t, ValidationInvalidPart, errs[1].Kind)
+		assert.Contains(t, errs[1].Message, "needs min_value less than max_value")
+
+		// 小数の範囲を持つ小数条件は妥当
+		floatPart.MinValueFloat = 0.5
+		floatPart.MaxValueFloat = 1.5
+		counterPart.MinValue = 1
+		counterPart.MaxValue = 3
+		assert.NoError(t, ValidatePhases(phases))
+	})
+
+	t.Run("InvalidTimePart", func(t *testing.T) {
+		part := NewConditionPart(1, "Zero Timer")
+		cond := NewCondition(1, "Cond", value.KindTime)
+		cond.AddPart(part)
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationInvalidTimePart, errs[0].Kind)
+	})
+
+	t.Run("InvalidCombination", func(t *testing.T) {
+		cond := newValidCounterCondition(t, 1, 1)
+		cond.Combination = value.PartCombinationAtLeast
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationInvalidCombination, errs[0].Kind)
+		assert.Equal(t, value.ConditionID(1), errs[0].ConditionID)
+	})
+
+	t.Run("UnsupportedOperator", func(t *testing.T) {
+		part := NewConditionPart(1, "String GT")
+		part.ComparisonOperator = value.ComparisonOperatorGT
+		part.ReferenceValueString = "a"
+		cond := NewCondition(1, "Cond", value.KindString)
+		cond.AddPart(part)
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationUnsupportedOperator, errs[0].Kind)
+	})
+
+	t.Run("InvalidDuration", func(t *testing.T) {
+		part := NewConditionPart(1, "No Duration")
+		part.ComparisonOperator = value.ComparisonOperatorGTE
+		part.ReferenceValueInt = 1
+		cond := NewCondition(1, "Cond", value.KindSustained)
+		cond.AddPart(part)
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationInvalidDuration, errs[0].Kind)
+	})
+
+	t.Run("InvalidSequence", func(t *testing.T) {
+		cond := NewCondition(1, "Cond", value.KindSequence)
+		cond.AddPart(NewConditionPart(1, "No Steps"))
+		require.NoError(t, cond.InitializePartStrategies(&MockStrategyFactory{}))
+		phases := Phases{NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)}
+
+		errs := validationErrors(t, ValidatePhases(phases))
+		require.Len(t, errs, 1)
+		assert.Equal(t, ValidationInvalidSequence, errs[0].Kind)
+	})
+}
+
+func TestValidatePhasesThreshold(t *testing.T) {
+	phase := NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1), newValidCounterCondition(t, 2, 2)},
+		value.ConditionTypeThreshold, value.GameRule_Shooting, 0, false)
+
+	phase.RequiredConditions = 3
+	errs := validationErrors(t, ValidatePhases(Phases{phase}))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidThreshold, errs[0].Kind)
+	assert.Equal(t, value.PhaseID(1), errs[0].PhaseID)
+
+	phase.RequiredConditions = 2
+	assert.NoError(t, ValidatePhases(Phases{phase}))
+}
+
+func TestValidatePhasesCompletion(t *testing.T) {
+	phase := NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1), newValidCounterCondition(t, 2, 2)},
+		value.ConditionTypeUnspecified, value.GameRule_Shooting, 0, false)
+
+	// 他のフェーズの条件を参照する式は検証で弾かれる
+	phase.Completion = ExprOrOf(ExprRef(1), ExprRef(3))
+	errs := validationErrors(t, ValidatePhases(Phases{phase}))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidCompletion, errs[0].Kind)
+
+	phase.Completion = ExprOrOf(ExprRef(1), ExprNotOf(ExprRef(2)))
+	assert.NoError(t, ValidatePhases(Phases{phase}))
+}
+
+func TestValidatePhasesRepeat(t *testing.T) {
+	phase := NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	phases := Phases{phase}
+
+	phase.RepeatCount = 3
+	phase.RepeatUntil = ExprRef(1)
+	assert.NoError(t, ValidatePhases(phases))
+
+	// 繰り返しを終える式はフェーズ自身の条件だけを参照できる
+	phase.RepeatUntil = ExprRef(2)
+	errs := validationErrors(t, ValidatePhases(phases))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidRepeat, errs[0].Kind)
+
+	phase.RepeatUntil = nil
+	phase.RepeatCount = -1
+	errs = validationErrors(t, ValidatePhases(phases))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidRepeat, errs[0].Kind)
+}
+
+func TestValidatePhasesFailure(t *testing.T) {
+	phase := NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	consolation := NewPhase(2, "B", 2, []*Condition{newValidCounterCondition(t, 2, 2)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	child := NewPhase(3, "C", 1, []*Condition{newValidCounterCondition(t, 3, 3)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 2, false)
+	phases := Phases{phase, consolation, child}
+
+	// 失敗時の移り先は兄弟フェーズでなければならない
+	phase.OnFailure = value.FailureGoto
+	phase.OnFailurePhaseID = 3
+	errs := validationErrors(t, ValidatePhases(phases))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidFailure, errs[0].Kind)
+	assert.Equal(t, value.PhaseID(1), errs[0].PhaseID)
+
+	phase.OnFailurePhaseID = 2
+	assert.NoError(t, ValidatePhases(phases))
+
+	phase.OnFailure = value.FailureRetry
+	phase.MaxRetries = -1
+	errs = validationErrors(t, ValidatePhases(phases))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidFailure, errs[0].Kind)
+}
+
+func TestValidatePhasesTransition(t *testing.T) {
+	phase := NewPhase(1, "A", 1, []*Condition{newValidCounterCondition(t, 1, 1)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	sibling := NewPhase(2, "B", 2, []*Condition{newValidCounterCondition(t, 2, 2)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	child := NewPhase(3, "C", 1, []*Condition{newValidCounterCondition(t, 3, 3)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 2, false)
+	phases := Phases{phase, sibling, child}
+
+	phase.Transitions = []PhaseTransition{
+		{ConditionID: 1, To: 2},
+		{Outcome: value.OutcomeFailed, To: 0},
+	}
+	assert.NoError(t, ValidatePhases(phases))
+
+	cases := map[string]PhaseTransition{
+		"not sibling":       {To: 3},
+		"foreign condition": {ConditionID: 2, To: 2},
+		"failed condition":  {ConditionID: 1, Outcome: value.OutcomeFailed, To: 2},
+		"skipped condition": {ConditionID: 1, Outcome: value.OutcomeSkipped, To: 2},
+		"unknown outcome":   {Outcome: "timedout", To: 2},
+	}
+	for name, transition := range cases {
+		t.Run(name, func(t *testing.T) {
+			phase.Transitions = []PhaseTransition{transition}
+			errs := validationErrors(t, ValidatePhases(phases))
+			require.Len(t, errs, 1)
+			assert.Equal(t, ValidationInvalidTransition, errs[0].Kind)
+			assert.Equal(t, value.PhaseID(1), errs[0].PhaseID)
+		})
+	}
+}
+
+func TestValidatePhasesParallel(t *testing.T) {
+	parent := NewPhase(1, "A", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false)
+	region1 := NewPhase(2, "B", 1, []*Condition{newValidCounterCondition(t, 1, 1)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 1, false)
+	region2 := NewPhase(3, "C", 2, []*Condition{newValidCounterCondition(t, 2, 2)},
+		value.ConditionTypeAnd, value.GameRule_Shooting, 1, false)
+	parent.Parallel = true
+	assert.NoError(t, ValidatePhases(Phases{parent, region1, region2}))
+
+	// 並列領域は兄弟の領域に移れない
+	region1.Transitions = []PhaseTransition{{To: 3}}
+	errs := validationErrors(t, ValidatePhases(Phases{parent, region1, region2}))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidParallel, errs[0].Kind)
+	assert.Equal(t, value.PhaseID(2), errs[0].PhaseID)
+
+	// 子フェーズのない並列フェーズは実行できない
+	errs = validationErrors(t, ValidatePhases(Phases{parent}))
+	require.Len(t, errs, 1)
+	assert.Equal(t, ValidationInvalidParallel, errs[0].Kind)
+	assert.Equal(t, value.PhaseID(1), errs[0].PhaseID)
+}
+
+func TestValidatePhasesReportsEveryProblem(t *testing.T) {
+	part := NewConditionPart(1, "No Strategy")
+	cond := NewCondition(1, "Cond", value.KindCounter)
+	cond.AddPart(part)
+
+	phases := Phases{
+		NewPhase(1, "A", 1, []*Condition{cond}, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+		NewPhase(1, "B", 3, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 0, false),
+		NewPhase(2, "C", 1, nil, value.ConditionTypeAnd, value.GameRule_Shooting, 42, false),
+	}
+
+	errs := validationErrors(t, ValidatePhases(phases))
+	assert.True(t, errs.HasKind(ValidationDuplicatePhaseID))
+	assert.True(t, errs.HasKind(ValidationOrderGap))
+	assert.True(t, errs.HasKind(ValidationOrphanParent))
+	assert.True(t, errs.HasKind(ValidationMissingStrategy))
+	assert.True(t, errs.HasKind(ValidationInvalidPart))
+	assert.Contains(t, errs.Error(), "validation error(s)")
+}
@@ -0,0 +1,347 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SnapshotVersion はスナップショット形式のバージョンです
+const SnapshotVersion = 1
+
+// Snapshot はフェーズツリー全体の状態を表すシリアライズ可能な構造体です
+type Snapshot struct {
+	Version       int                             `json:"version"`
+	TakenAt       time.Time                       `json:"taken_at"`
+	Phases        []PhaseSnapshot                 `json:"phases"`
+	CurrentPhases map[value.PhaseID]value.PhaseID `json:"current_phases"` // 親ID -> 現在のフェーズID
+}
+
+// PhaseSnapshot はフェーズの状態を表します
+type PhaseSnapshot struct {
+	ID                  value.PhaseID       `json:"id"`
+	State               string              `json:"state"`
+	Active              bool                `json:"active"`
+	IsClear             bool                `json:"is_clear"`
+	Outcome             value.PhaseOutcome  `json:"outcome,omitempty"`
+	CompletedBy         value.ConditionID   `json:"completed_by,omitempty"`
+	Iteration           int                 `json:"iteration,omitempty"`
+	StartTime           *time.Time          `json:"start_time,omitempty"`
+	FinishTime          *time.Time          `json:"finish_time,omitempty"`
+	SatisfiedConditions []value.ConditionID `json:"satisfied_conditions,omitempty"`
+	Conditions          []ConditionSnapshot `json:"conditions,omitempty"`
+}
+
+// ConditionSnapshot は条件の状態を表します
+type ConditionSnapshot struct {
+	ID             value.ConditionID       `json:"id"`
+	State          string                  `json:"state"`
+	IsClear        bool                    `json:"is_clear"`
+	StartTime      *time.Time              `json:"start_time,omitempty"`
+	FinishTime     *time.Time              `json:"finish_time,omitempty"`
+	SatisfiedParts []value.ConditionPartID `json:"satisfied_parts,omitempty"`
+	Parts          []PartSnapshot          `json:"parts,omitempty"`
+}
+
+// PartSnapshot は条件パーツの状態を表します
+type PartSnapshot struct {
+	ID         value.ConditionPartID     `json:"id"`
+	State      string                    `json:"state"`
+	IsClear    bool                      `json:"is_clear"`
+	Paused     bool                      `json:"paused,omitempty"`
+	HeldEvent  string                    `json:"held_event,omitempty"` // 順序待ちで保留している完了イベント
+	StartTime  *time.Time                `json:"start_time,omitempty"`
+	FinishTime *time.Time                `json:"finish_time,omitempty"`
+	Strategy   *service.StrategySnapshot `json:"strategy,omitempty"`
+
+	DeadlineRemainingMs int64 `json:"deadline_remaining_ms,omitempty"` // 期限までの残り時間(ミリ秒)
+}
+
+// copyTime は時刻のポインタを複製します
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// Snapshot は条件パーツの状態を保存します
+func (p *ConditionPart) Snapshot() PartSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snapshot := PartSnapshot{
+		ID:         p.ID,
+		State:      p.fsm.Current(),
+		IsClear:    p.IsClear,
+		Paused:     p.paused,
+		HeldEvent:  p.heldEvent,
+		StartTime:  copyTime(p.StartTime),
+		FinishTime: copyTime(p.FinishTime),
+
+		DeadlineRemainingMs: p.deadlineRemainingLocked().Milliseconds(),
+	}
+	if s, ok := p.strategy.(service.SnapshotStrategy); ok {
+		strategySnapshot := s.Snapshot()
+		snapshot.Strategy = &strategySnapshot
+	}
+	return snapshot
+}
+
+// Restore はスナップショットから条件パーツの状態を復元します
+// FSMのコールバックは呼び出さず、状態を直接設定します
+func (p *ConditionPart) Restore(ctx context.Context, snapshot PartSnapshot) error {
+	p.mu.Lock()
+	p.fsm.SetState(snapshot.State)
+	p.IsClear = snapshot.IsClear
+	p.paused = snapshot.Paused
+	p.heldEvent = snapshot.HeldEvent
+	p.StartTime = copyTime(snapshot.StartTime)
+	p.FinishTime = copyTime(snapshot.FinishTime)
+	// 期限のタイマーは最初からではなく保存時の残り時間から再開する
+	p.stopDeadlineLocked()
+	p.deadlineRemaining = 0
+	if snapshot.DeadlineRemainingMs > 0 {
+		remaining := time.Duration(snapshot.DeadlineRemainingMs) * time.Millisecond
+		if p.paused {
+			p.deadlineRemaining = remaining
+		} else {
+			p.startDeadlineLocked(remaining)
+		}
+	}
+	strategy := p.strategy
+	p.mu.Unlock()
+
+	if snapshot.Strategy == nil {
+		return nil
+	}
+	s, ok := strategy.(service.SnapshotStrategy)
+	if !ok {
+		return fmt.Errorf("part %d: strategy %T does not support snapshots", p.ID, strategy)
+	}
+	if err := s.Restore(ctx, p, *snapshot.Strategy); err != nil {
+		return fmt.Errorf("part %d: failed to restore strategy: %w", p.ID, err)
+	}
+	return nil
+}
+
+// Snapshot は条件とその条件パーツの状態を保存します
+func (c *Condition) Snapshot() ConditionSnapshot {
+	c.mu.RLock()
+	snapshot := ConditionSnapshot{
+		ID:             c.ID,
+		State:          c.fsm.Current(),
+		IsClear:        c.IsClear,
+		StartTime:      copyTime(c.StartTime),
+		FinishTime:     copyTime(c.FinishTime),
+		SatisfiedParts: make([]value.ConditionPartID, 0, len(c.satisfiedParts)),
+	}
+	for id := range c.satisfiedParts {
+		snapshot.SatisfiedParts = append(snapshot.SatisfiedParts, id)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(snapshot.SatisfiedParts, func(i, j int) bool {
+		return snapshot.SatisfiedParts[i] < snapshot.SatisfiedParts[j]
+	})
+
+	for _, part := range c.GetParts() {
+		snapshot.Parts = append(snapshot.Parts, part.Snapshot())
+	}
+	return snapshot
+}
+
+// Restore はスナップショットから条件とその条件パーツの状態を復元します
+func (c *Condition) Restore(ctx context.Context, snapshot ConditionSnapshot) error {
+	c.mu.Lock()
+	c.fsm.SetState(snapshot.State)
+	c.IsClear = snapshot.IsClear
+	c.StartTime = copyTime(snapshot.StartTime)
+	c.FinishTime = copyTime(snapshot.FinishTime)
+	c.satisfiedParts = make(map[value.ConditionPartID]bool)
+	for _, id := range snapshot.SatisfiedParts {
+		c.satisfiedParts[id] = true
+	}
+	c.mu.Unlock()
+
+	for _, partSnapshot := range snapshot.Parts {
+		part := c.Parts[partSnapshot.ID]
+		if err := part.Restore(ctx, partSnapshot); err != nil {
+			return fmt.Errorf("condition %d: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// Snapshot はフェーズとその条件の状態を保存します
+func (p *Phase) Snapshot() PhaseSnapshot {
+	p.mu.RLock()
+	snapshot := PhaseSnapshot{
+		ID:                  p.ID,
+		State:               p.fsm.Current(),
+		Active:              p.isActive,
+		IsClear:             p.IsClear,
+		Outcome:             p.Outcome,
+		CompletedBy:         p.CompletedBy,
+		Iteration:           p.Iteration,
+		StartTime:           copyTime(p.StartTime),
+		FinishTime:          copyTime(p.FinishTime),
+		SatisfiedConditions: make([]value.ConditionID, 0, len(p.SatisfiedConditions)),
+	}
+	for id := range p.SatisfiedConditions {
+		snapshot.SatisfiedConditions = append(snapshot.SatisfiedConditions, id)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(snapshot.SatisfiedConditions, func(i, j int) bool {
+		return snapshot.SatisfiedConditions[i] < snapshot.SatisfiedConditions[j]
+	})
+
+	for _, id := range p.ConditionIDs {
+		snapshot.Conditions = append(snapshot.Conditions, p.Conditions[id].Snapshot())
+	}
+	return snapshot
+}
+
+// Restore はスナップショットからフェーズとその条件の状態を復元します
+func (p *Phase) Restore(ctx context.Context, snapshot PhaseSnapshot) error {
+	p.mu.Lock()
+	p.fsm.SetState(snapshot.State)
+	p.isActive = snapshot.Active
+	p.IsClear = snapshot.IsClear
+	p.Outcome = snapshot.Outcome
+	p.CompletedBy = snapshot.CompletedBy
+	p.Iteration = snapshot.Iteration
+	p.StartTime = copyTime(snapshot.StartTime)
+	p.FinishTime = copyTime(snapshot.FinishTime)
+	p.SatisfiedConditions = make(map[value.ConditionID]bool)
+	for _, id := range snapshot.SatisfiedConditions {
+		p.SatisfiedConditions[id] = true
+	}
+	p.mu.Unlock()
+
+	for _, condSnapshot := range snapshot.Conditions {
+		cond := p.Conditions[condSnapshot.ID]
+		if err := cond.Restore(ctx, condSnapshot); err != nil {
+			return fmt.Errorf("phase %d: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// Snapshot はフェーズツリー全体と現在のフェーズマップを保存します
+func (pf *PhaseFacade) Snapshot() *Snapshot {
+	pf.mu.RLock()
+	phases := pf.allPhases
+	takenAt := pf.clock.Now()
+	current := make(map[value.PhaseID]value.PhaseID, len(pf.currentPhaseMap))
+	for parentID, phase := range pf.currentPhaseMap {
+		current[parentID] = phase.ID
+	}
+	pf.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Version:       SnapshotVersion,
+		TakenAt:       takenAt,
+		Phases:        make([]PhaseSnapshot, 0, len(phases)),
+		CurrentPhases: current,
+	}
+	for _, phase := range phases {
+		snapshot.Phases = append(snapshot.Phases, phase.Snapshot())
+	}
+	return snapshot
+}
+
+// Restore はスナップショットからフェーズツリー全体と現在のフェーズマップを復元します
+// 復元前に全フェーズをリセットします。スナップショットがこのフェーズツリーのものでない場合は何も変更せずにエラーを返します
+func (pf *PhaseFacade) Restore(ctx context.Context, snapshot *Snapshot) error {
+	phaseByID, err := pf.checkSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	// 実行中のタイマーを止めるため、復元前に全フェーズをリセットする
+	if err := pf.GetAllPhases().ResetAll(ctx); err != nil {
+		return fmt.Errorf("failed to reset phases before restore: %w", err)
+	}
+
+	for _, phaseSnapshot := range snapshot.Phases {
+		if err := phaseByID[phaseSnapshot.ID].Restore(ctx, phaseSnapshot); err != nil {
+			return err
+		}
+	}
+
+	currentPhaseMap := make(CurrentPhaseMap, len(snapshot.CurrentPhases))
+	for parentID, phaseID := range snapshot.CurrentPhases {
+		currentPhaseMap[parentID] = phaseByID[phaseID]
+	}
+
+	pf.mu.Lock()
+	pf.currentPhaseMap = currentPhaseMap
+	pf.mu.Unlock()
+
+	pf.log.Debug("PhaseFacade.Restore",
+		zap.Time("taken_at", snapshot.TakenAt),
+		zap.Int("phases", len(snapshot.Phases)))
+	return nil
+}
+
+// checkSnapshot はスナップショットのIDがすべてこのフェーズツリーに存在するか確認します
+func (pf *PhaseFacade) checkSnapshot(snapshot *Snapshot) (map[value.PhaseID]*Phase, error) {
+	if snapshot == nil {
+		return nil, fmt.Errorf("snapshot is nil")
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+
+	phaseByID := make(map[value.PhaseID]*Phase)
+	for _, phase := range pf.GetAllPhases() {
+		phaseByID[phase.ID] = phase
+	}
+
+	for _, phaseSnapshot := range snapshot.Phases {
+		phase, ok := phaseByID[phaseSnapshot.ID]
+		if !ok {
+			return nil, fmt.Errorf("snapshot phase %d not found", phaseSnapshot.ID)
+		}
+		if phaseSnapshot.State == "" {
+			return nil, fmt.Errorf("snapshot phase %d has no state", phaseSnapshot.ID)
+		}
+		for _, condSnapshot := range phaseSnapshot.Conditions {
+			cond, ok := phase.Conditions[condSnapshot.ID]
+			if !ok {
+				return nil, fmt.Errorf("snapshot condition %d not found in phase %d", condSnapshot.ID, phase.ID)
+			}
+			if condSnapshot.State == "" {
+				return nil, fmt.Errorf("snapshot condition %d has no state", condSnapshot.ID)
+			}
+			for _, partSnapshot := range condSnapshot.Parts {
+				if _, ok := cond.Parts[partSnapshot.ID]; !ok {
+					return nil, fmt.Errorf("snapshot part %d not found in condition %d", partSnapshot.ID, cond.ID)
+				}
+				if partSnapshot.State == "" {
+					return nil, fmt.Errorf("snapshot part %d has no state", partSnapshot.ID)
+				}
+			}
+		}
+	}
+
+	for parentID, phaseID := range snapshot.CurrentPhases {
+		phase, ok := phaseByID[phaseID]
+		if !ok {
+			return nil, fmt.Errorf("snapshot current phase %d not found", phaseID)
+		}
+		if phase.ParentID != parentID {
+			return nil, fmt.Errorf("snapshot current phase %d is not a child of %d", phaseID, parentID)
+		}
+	}
+
+	return phaseByID, nil
+}
@@ -15,6 +15,11 @@ type ConditionObserver interface {
 	OnConditionChanged(condition interface{})
 }
 
+// TransitionObserver エンティティの状態遷移を監視するインターフェース
+type TransitionObserver interface {
+	OnTransition(entity interface{}, event, from, to string)
+}
+
 type ControllerObserver interface {
 	OnEntityChanged(entity interface{})
 }
@@ -31,3 +31,34 @@ type StrategySubject interface {
 type StrategyObserver interface {
 	OnUpdated(event string)
 }
+
+// StrategySnapshot は戦略の内部状態を保存・復元するための値です
+type StrategySnapshot struct {
+	CurrentValue int64   `json:"current_value"`          // カウンターの現在値
+	FloatValue   float64 `json:"float_value,omitempty"`  // 小数条件の最新の測定値
+	StringValue  string  `json:"string_value,omitempty"` // 文字列条件の最新の入力値
+	Running      bool    `json:"running,omitempty"`      // タイマーが動作中かどうか
+	Paused       bool    `json:"paused,omitempty"`       // 一時停止中かどうか
+	RemainingMs  int64   `json:"remaining_ms,omitempty"` // タイマー発火までの残り時間(ミリ秒)
+	Held         bool    `json:"held,omitempty"`         // 持続条件で保持時間を満了済みかどうか
+
+	Window []WindowEventSnapshot `json:"window,omitempty"` // 時間窓条件で窓内に残っている入力
+}
+
+// WindowEventSnapshot は時間窓条件の窓内に残っている1件の入力です
+type WindowEventSnapshot struct {
+	Count       int64 `json:"count"`        // 入力された回数
+	RemainingMs int64 `json:"remaining_ms"` // 窓から外れるまでの残り時間(ミリ秒)
+}
+
+// SnapshotStrategy 内部状態のスナップショットを扱える戦略のインターフェース
+type SnapshotStrategy interface {
+	Snapshot() StrategySnapshot
+	Restore(ctx context.Context, part interface{}, snapshot StrategySnapshot) error
+}
+
+// PausableStrategy 一時停止・再開できる戦略のインターフェース
+type PausableStrategy interface {
+	Pause() error
+	Resume() error
+}
@@ -20,13 +20,19 @@ func GetGameStateInfo(state string) *GameStateInfo {
 		StateActive: {
 			Name:        "Active",
 			Description: "アクティブ状態",
-			AllowedNext: []string{EventNext},
+			AllowedNext: []string{EventNext, EventPause, EventFail, EventSkip},
 			Message:     "処理中...",
 		},
+		StatePaused: {
+			Name:        "Paused",
+			Description: "一時停止状態",
+			AllowedNext: []string{EventResume, EventSkip},
+			Message:     "一時停止中。再開すると続きから処理します。",
+		},
 		StateNext: {
 			Name:        "Next",
 			Description: "次状態への準備",
-			AllowedNext: []string{EventActivate, EventFinish},
+			AllowedNext: []string{EventActivate, EventFinish, EventSkip},
 			Message:     "次の状態を判定中...",
 		},
 		StateFinish: {
@@ -35,6 +41,12 @@ func GetGameStateInfo(state string) *GameStateInfo {
 			AllowedNext: []string{EventReset},
 			Message:     "処理が完了しました。リセットして再開できます。",
 		},
+		StateFailed: {
+			Name:        "Failed",
+			Description: "失敗状態",
+			AllowedNext: []string{EventReset, EventSkip},
+			Message:     "フェーズを完了できませんでした。",
+		},
 	}
 
 	if info, exists := stateInfoMap[state]; exists {
@@ -7,6 +7,11 @@ const (
 	KindUnspecified ConditionKind = iota
 	KindTime                      // 時間に基づく条件
 	KindCounter                   // カウンターに基づく条件
+	KindFloat                     // 小数の測定値に基づく条件
+	KindString                    // 文字列の入力値に基づく条件
+	KindSustained                 // 比較が一定時間成り立ち続けることに基づく条件
+	KindSequence                  // 決められた順番での入力に基づく条件
+	KindWindow                    // 一定時間内の入力回数に基づく条件
 )
 
 // ComparisonOperator は比較演算子を表す型です
@@ -50,6 +55,63 @@ const (
 	ConditionTypeUnspecified ConditionType = iota
 	ConditionTypeAnd                       // すべての条件を満たす必要がある
 	ConditionTypeOr                        // いずれかの条件を満たせばよい
+	ConditionTypeThreshold                 // 指定した数以上の条件を満たせばよい
+)
+
+// ExprOperator はフェーズの完了条件式のノードの種類を表す型です
+type ExprOperator int
+
+const (
+	ExprCondition ExprOperator = iota // 条件IDを参照する葉
+	ExprAnd                           // すべての子が真
+	ExprOr                            // いずれかの子が真
+	ExprNot                           // 子が偽
+	ExprThreshold                     // 指定した数以上の子が真
+)
+
+// PartCombination は条件内の条件パーツの組み合わせ方を表す型です
+type PartCombination int
+
+const (
+	PartCombinationAll     PartCombination = iota // すべてのパーツを満たす必要がある
+	PartCombinationAny                            // いずれかのパーツを満たせばよい
+	PartCombinationAtLeast                        // 指定した数以上のパーツを満たせばよい
+)
+
+// SequenceMissPolicy は順序入力条件で誤った入力があった場合の扱いを表す型です
+type SequenceMissPolicy int
+
+const (
+	SequenceMissReset SequenceMissPolicy = iota // 最初からやり直す
+	SequenceMissStay                            // 進み具合を保ったまま次の入力を待つ
+)
+
+// PhaseOutcome はフェーズの結果を表す型です
+type PhaseOutcome string
+
+const (
+	OutcomeNone      PhaseOutcome = ""          // まだ結果が出ていない
+	OutcomeSucceeded PhaseOutcome = "succeeded" // 完了条件を満たして完了した
+	OutcomeFailed    PhaseOutcome = "failed"    // 期限切れなどで完了できなくなった
+	OutcomeAborted   PhaseOutcome = "aborted"   // オペレーターが中断した
+	OutcomeSkipped   PhaseOutcome = "skipped"   // オペレーターが別のフェーズに移るために飛ばした
+)
+
+// FailureAction はフェーズが失敗した場合の遷移先を表す型です
+type FailureAction int
+
+const (
+	FailureEnd   FailureAction = iota // 子フェーズは親フェーズも失敗させ、ルートフェーズはゲームを終了する
+	FailureRetry                      // フェーズを最初からやり直す
+	FailureGoto                       // 指定した兄弟フェーズ(残念賞など)に移る
+)
+
+// ParallelCompletion は並列領域を持つフェーズが完了する条件を表す型です
+type ParallelCompletion int
+
+const (
+	ParallelAll ParallelCompletion = iota // 全ての領域が完了したら完了する
+	ParallelAny                           // いずれかの領域が完了したら完了し、残りの領域は打ち切る
 )
 
 // ゲーム状態の定義
@@ -58,6 +120,8 @@ const (
 	StateActive = "active"
 	StateNext   = "next"
 	StateFinish = "finish"
+	StatePaused = "paused"
+	// 失敗したフェーズはStateFailedに遷移します(条件状態と同じ値を使います)
 )
 
 // ゲームイベントの定義
@@ -66,6 +130,9 @@ const (
 	EventNext     = "next"
 	EventFinish   = "finish"
 	EventReset    = "reset"
+	EventPause    = "pause"
+	EventResume   = "resume"
+	EventSkip     = "skip" // オペレーターがフェーズを飛ばして終了させる
 )
 
 // 条件状態の定義
@@ -73,6 +140,7 @@ const (
 	StateUnsatisfied = "unsatisfied" // 条件未達成
 	StateProcessing  = "processing"  // 処理中
 	StateSatisfied   = "satisfied"   // 条件達成
+	StateFailed      = "failed"      // 期限切れなどで達成できなくなった
 )
 
 // 条件イベントの定義
@@ -81,4 +149,6 @@ const (
 	EventComplete = "complete" // 条件達成で完了
 	EventTimeout  = "timeout"  // (時間系の)条件達成で完了
 	EventRevert   = "revert"   // 条件未達で差し戻し
+	EventExpire   = "expire"   // (時間系の)入力の期限切れで差し戻し
+	EventFail     = "fail"     // 期限切れなどで失敗
 )
@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake はAdvanceで手動で進めるテスト用のClockです
+// Sleepは自分では時計を進めず、他の呼び出し元のAdvanceで時刻に達するまで待ちます
+type Fake struct {
+	now     time.Time
+	tickers []*fakeTicker
+	sleeps  []*fakeSleep
+	mu      sync.Mutex
+}
+
+// fakeSleep はSleepで待っている呼び出し元です
+type fakeSleep struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// NewFake は指定した時刻から始まるFakeを作成します
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now は現在の時刻を返します
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep は他の呼び出し元のAdvanceで時計がdだけ進むまで待ちます
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	s := &fakeSleep{until: f.now.Add(d), done: make(chan struct{})}
+	f.sleeps = append(f.sleeps, s)
+	f.mu.Unlock()
+
+	<-s.done
+}
+
+// NewTicker はAdvanceに合わせて発火するティッカーを作成します
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTicker{
+		clock:  f,
+		c:      make(chan time.Time, 1),
+		period: d,
+		next:   f.now.Add(d),
+		active: true,
+	}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance は時計をdだけ進め、その間に期限を迎えたティッカーを発火させ、Sleepを終わらせます
+// time.Tickerと同様に、受信されていない発火は1つを残して破棄されます
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	for _, t := range f.tickers {
+		for t.active && !t.next.After(f.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+
+	sleeps := f.sleeps[:0]
+	for _, s := range f.sleeps {
+		if s.until.After(f.now) {
+			sleeps = append(sleeps, s)
+			continue
+		}
+		close(s.done)
+	}
+	f.sleeps = sleeps
+}
+
+// fakeTicker はFakeが作成するティッカーです
+type fakeTicker struct {
+	clock  *Fake
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+	active bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.period = d
+	t.next = t.clock.now.Add(d)
+	t.active = true
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.active = false
+}
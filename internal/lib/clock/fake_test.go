@@ -0,0 +1,80 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(5 * time.Second)
+	assert.Equal(t, start.Add(5*time.Second), c.Now())
+}
+
+func TestFakeSleep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	// 0以下なら待たずに戻る
+	c.Sleep(0)
+
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(2 * time.Second)
+		close(done)
+	}()
+
+	// Sleepは自分では時計を進めない
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.sleeps) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(time.Second)
+	select {
+	case <-done:
+		t.Fatal("Sleep returned before the clock reached its deadline")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return after the clock reached its deadline")
+	}
+	assert.Equal(t, start.Add(2*time.Second), c.Now())
+}
+
+func TestFakeTicker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	ticker := c.NewTicker(2 * time.Second)
+
+	c.Advance(time.Second)
+	assert.Empty(t, ticker.C())
+
+	c.Advance(time.Second)
+	assert.Equal(t, start.Add(2*time.Second), <-ticker.C())
+
+	// 受信されていない発火は1つだけ残る
+	c.Advance(10 * time.Second)
+	assert.Len(t, ticker.C(), 1)
+	<-ticker.C()
+
+	// Resetで次の発火は現在時刻から数え直す
+	ticker.Reset(time.Second)
+	c.Advance(time.Second)
+	assert.Equal(t, start.Add(13*time.Second), <-ticker.C())
+
+	ticker.Stop()
+	c.Advance(10 * time.Second)
+	assert.Empty(t, ticker.C())
+}
@@ -3,41 +3,93 @@ package ui
 import (
 	"state_sample/internal/domain/entity"
 	"state_sample/internal/domain/value"
+	"state_sample/internal/usecase/state"
 	"time"
 )
 
 // PhaseDTO はUI層で使用するフェーズのデータ転送オブジェクト
 type PhaseDTO struct {
-	ID          value.PhaseID `json:"id"`
-	ParentID    value.PhaseID `json:"parent_id"`
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Order       int           `json:"order"`
-	State       string        `json:"state"`
-	IsClear     bool          `json:"is_clear"`
-	IsActive    bool          `json:"is_active"`
-	HasChildren bool          `json:"has_children"`
-	StartTime   *time.Time    `json:"start_time,omitempty"`
-	FinishTime  *time.Time    `json:"finish_time,omitempty"`
+	ID                  value.PhaseID     `json:"id"`
+	ParentID            value.PhaseID     `json:"parent_id"`
+	Name                string            `json:"name"`
+	Description         string            `json:"description"`
+	Order               int               `json:"order"`
+	State               string            `json:"state"`
+	IsClear             bool              `json:"is_clear"`
+	Outcome             string            `json:"outcome,omitempty"` // フェーズの結果: succeeded / failed / aborted / skipped
+	IsActive            bool              `json:"is_active"`
+	HasChildren         bool              `json:"has_children"`
+	SatisfiedConditions int               `json:"satisfied_conditions"`   // 満たされた条件数
+	RequiredConditions  int               `json:"required_conditions"`    // フェーズの完了に必要な条件数(完了条件式で完了する場合は0)
+	FailedConditions    int               `json:"failed_conditions"`      // 期限切れなどで失敗した条件数
+	Completion          string            `json:"completion,omitempty"`   // 完了条件式
+	CompletedBy         value.ConditionID `json:"completed_by,omitempty"` // フェーズを完了させた条件のID
+	Transitions         []string          `json:"transitions,omitempty"`  // 遷移の辺
+	Parallel            string            `json:"parallel,omitempty"`     // 並列領域の完了条件: all / any(並列でない場合は空)
+	Iteration           int               `json:"iteration,omitempty"`    // 現在の繰り返し回数(1から)
+	RepeatCount         int               `json:"repeat_count,omitempty"` // 繰り返す回数の上限(0は上限なし)
+	StartTime           *time.Time        `json:"start_time,omitempty"`
+	FinishTime          *time.Time        `json:"finish_time,omitempty"`
 }
 
 // ConvertPhaseToDTO はPhaseオブジェクトをDTOに変換する
 func ConvertPhaseToDTO(phase *entity.Phase) PhaseDTO {
 	return PhaseDTO{
-		ID:          phase.ID,
-		ParentID:    phase.ParentID,
-		Name:        phase.Name,
-		Description: phase.Description,
-		Order:       phase.Order,
-		State:       phase.CurrentState(),
-		IsClear:     phase.IsClear,
-		IsActive:    phase.IsActive(),
-		HasChildren: phase.HasChildren(),
-		StartTime:   phase.StartTime,
-		FinishTime:  phase.FinishTime,
+		ID:                  phase.ID,
+		ParentID:            phase.ParentID,
+		Name:                phase.Name,
+		Description:         phase.Description,
+		Order:               phase.Order,
+		State:               phase.CurrentState(),
+		IsClear:             phase.IsClear,
+		Outcome:             string(phase.Outcome),
+		IsActive:            phase.IsActive(),
+		HasChildren:         phase.HasChildren(),
+		SatisfiedConditions: phase.SatisfiedConditionCount(),
+		RequiredConditions:  phase.RequiredConditionCount(),
+		FailedConditions:    len(phase.FailedConditions()),
+		Completion:          completionString(phase),
+		CompletedBy:         phase.CompletedBy,
+		Transitions:         transitionStrings(phase),
+		Parallel:            parallelString(phase),
+		Iteration:           phase.Iteration,
+		RepeatCount:         phase.RepeatCount,
+		StartTime:           phase.StartTime,
+		FinishTime:          phase.FinishTime,
 	}
 }
 
+// completionString はフェーズの完了条件式を文字列で返します
+func completionString(phase *entity.Phase) string {
+	if phase.Completion == nil {
+		return ""
+	}
+	return phase.Completion.String()
+}
+
+// transitionStrings はフェーズの遷移の辺を文字列で返します
+func transitionStrings(phase *entity.Phase) []string {
+	if len(phase.Transitions) == 0 {
+		return nil
+	}
+	transitions := make([]string, len(phase.Transitions))
+	for i, t := range phase.Transitions {
+		transitions[i] = t.String()
+	}
+	return transitions
+}
+
+// parallelString は並列領域を持つフェーズの完了条件を文字列で返します
+func parallelString(phase *entity.Phase) string {
+	if !phase.Parallel {
+		return ""
+	}
+	if phase.ParallelCompletion == value.ParallelAny {
+		return "any"
+	}
+	return "all"
+}
+
 // GetAllPhasesDTO は全てのフェーズをDTOに変換する
 func GetAllPhasesDTO(phases entity.Phases) []PhaseDTO {
 	result := make([]PhaseDTO, len(phases))
@@ -46,3 +98,25 @@ func GetAllPhasesDTO(phases entity.Phases) []PhaseDTO {
 	}
 	return result
 }
+
+// SessionDTO はUI層で使用するセッションのデータ転送オブジェクト
+type SessionDTO struct {
+	ID           state.SessionID `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Clients      int             `json:"clients"`
+	CurrentPhase *PhaseDTO       `json:"current_phase,omitempty"`
+}
+
+// ConvertSessionToDTO はセッションをDTOに変換する
+func ConvertSessionToDTO(session *state.Session, clients int) SessionDTO {
+	dto := SessionDTO{
+		ID:        session.ID,
+		CreatedAt: session.CreatedAt,
+		Clients:   clients,
+	}
+	if phase := session.Facade.GetCurrentPhase(0); phase != nil {
+		phaseDTO := ConvertPhaseToDTO(phase)
+		dto.CurrentPhase = &phaseDTO
+	}
+	return dto
+}
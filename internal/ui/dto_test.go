@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/usecase/state"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertPhaseToDTOCompletion(t *testing.T) {
+	phases, err := state.BuildPhases(
+		state.Phase("AND").ID(1).All(
+			state.Counter("a").ID(1).GTE(1),
+			state.Counter("b").ID(2).GTE(1),
+		),
+		state.Phase("EXPR").ID(2).When(
+			entity.ExprOrOf(entity.ExprRef(3), entity.ExprAndOf(entity.ExprRef(4), entity.ExprRef(5))),
+			state.Counter("c").ID(3).GTE(1),
+			state.Counter("d").ID(4).GTE(1),
+			state.Counter("e").ID(5).GTE(1),
+		),
+	)
+	require.NoError(t, err)
+
+	and := ConvertPhaseToDTO(phases.GetByID(1))
+	assert.Equal(t, 2, and.RequiredConditions)
+	assert.Empty(t, and.Completion)
+
+	// 完了条件式で完了するフェーズは必要な条件数ではなく式で表す
+	expr := ConvertPhaseToDTO(phases.GetByID(2))
+	assert.Equal(t, 0, expr.RequiredConditions)
+	assert.NotEmpty(t, expr.Completion)
+}
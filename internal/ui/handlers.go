@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"state_sample/internal/domain/entity"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/usecase/state"
 	"strconv"
 
 	"go.uber.org/zap"
@@ -15,32 +17,54 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// sessionIDFromRequest はリクエストが対象とするセッションIDを返します
+// /api/sessions/{session_id}/... 以外のルートはデフォルトセッションを対象にします
+func sessionIDFromRequest(r *http.Request) state.SessionID {
+	if id := mux.Vars(r)["session_id"]; id != "" {
+		return state.SessionID(id)
+	}
+	return state.DefaultSessionID
+}
+
+// hubFromRequest はリクエストが対象とするセッションのハブを返します
+// セッションが存在しない場合は404を返します
+func (s *StateServer) hubFromRequest(w http.ResponseWriter, r *http.Request) (*sessionHub, bool) {
+	id := sessionIDFromRequest(r)
+	hub, ok := s.getHub(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("session not found: %s", id), http.StatusNotFound)
+		return nil, false
+	}
+	return hub, true
+}
+
 // handleWebSocket WebSocket接続を処理
 func (s *StateServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error("Error upgrading connection", zap.Error(err))
 		return
 	}
 
-	s.mu.Lock()
-	s.clients[conn] = true
-	s.mu.Unlock()
+	hub.addClient(conn)
 
 	// WebSocket接続時には初期状態を送信しない
 	// 初期状態はクライアント側で/api/initial-stateエンドポイントから取得する
 
-	go func() { _ = s.recvWsMessage(conn) }()
+	go func() { _ = s.recvWsMessage(hub, conn) }()
 }
 
-func (s *StateServer) recvWsMessage(conn *websocket.Conn) error {
+func (s *StateServer) recvWsMessage(hub *sessionHub, conn *websocket.Conn) error {
 	log := logger.DefaultLogger()
 	defer func() {
 		log.Debug("recvWsMessage: Closing connection")
-		s.mu.Lock()
-		delete(s.clients, conn)
-		s.mu.Unlock()
+		hub.removeClient(conn)
 		err := conn.Close()
 		if err != nil {
 			log.Error("Error closing connection", zap.Error(err))
@@ -50,32 +74,68 @@ func (s *StateServer) recvWsMessage(conn *websocket.Conn) error {
 
 	for {
 		var msg struct {
-			Event string `json:"event"`
+			Event   string        `json:"event"`
+			PhaseID value.PhaseID `json:"phase_id"` // jumpで移るフェーズのID
 		}
 		if err := conn.ReadJSON(&msg); err != nil {
 			log.Error("Error reading message", zap.Error(err))
 			return err
 		}
 
-		log.Debug("WS: Received message", zap.String("event", msg.Event))
-		err := s.handleActionRequest(msg.Event)
-		if err != nil {
-			log.Error("Error handling action request", zap.Error(err))
-			return err
+		log.Debug("WS: Received message",
+			zap.String("session_id", string(hub.session.ID)),
+			zap.String("event", msg.Event))
+		// 操作の失敗はクライアントに返して読み込みを続け、接続を閉じるのは通信や読み込みのエラーのみとする
+		if err := s.handleActionRequest(hub.session.Facade, msg.Event, msg.PhaseID); err != nil {
+			log.Warn("Error handling action request",
+				zap.String("event", msg.Event),
+				zap.Error(err))
+			if err := hub.sendToClient(conn, newErrorMessage(err)); err != nil {
+				log.Error("Error sending error message", zap.Error(err))
+				return err
+			}
 		}
 	}
 }
 
-func (s *StateServer) handleActionRequest(action string) error {
+// errorMessage は操作に失敗したことをクライアントに伝えるメッセージです
+type errorMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// newErrorMessage はエラーからerrorMessageを作成します
+func newErrorMessage(err error) errorMessage {
+	return errorMessage{Type: "error", Error: err.Error()}
+}
+
+// handleActionRequest はゲーム全体への操作を実行します
+// phaseIDはjumpで移るフェーズのIDで、それ以外の操作では使いません
+func (s *StateServer) handleActionRequest(facade *state.GameFacade, action string, phaseID value.PhaseID) error {
 	log := logger.DefaultLogger()
 	var err error
 	switch action {
-	case "start", "activate":
-		err = s.stateFacade.Start(context.Background())
+	case state.ActionStart, "activate":
+		err = facade.Start(context.Background())
 	case "stop":
-		err = s.stateFacade.Reset(context.Background())
-	case "reset", "finish":
-		err = s.stateFacade.Reset(context.Background())
+		err = facade.Reset(context.Background())
+	case state.ActionReset, "finish":
+		err = facade.Reset(context.Background())
+	case state.ActionPause:
+		err = facade.Pause(context.Background())
+	case state.ActionResume:
+		err = facade.Resume(context.Background())
+	case state.ActionAbort:
+		err = facade.Abort(context.Background())
+	case state.ActionSkip:
+		err = facade.Skip(context.Background())
+	case state.ActionJump:
+		if phaseID == 0 {
+			return fmt.Errorf("phase_id is required for %s", action)
+		}
+		err = facade.JumpTo(context.Background(), phaseID)
+	case state.ActionRewind:
+		err = facade.Rewind(context.Background())
 	default:
 		log.Error("Invalid action", zap.String("action", action))
 	}
@@ -85,11 +145,28 @@ func (s *StateServer) handleActionRequest(action string) error {
 // handleAutoTransition 自動遷移の制御を処理
 func (s *StateServer) handleAutoTransition(w http.ResponseWriter, r *http.Request) {
 	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	action := r.URL.Query().Get("action")
 	log.Debug("Received auto-transition control request", zap.String("action", action))
 
-	log.Debug("HTTP: Received message", zap.String("event", action))
-	err := s.handleActionRequest(action)
+	var phaseID value.PhaseID
+	if v := r.URL.Query().Get("phase_id"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid phase_id", http.StatusBadRequest)
+			return
+		}
+		phaseID = value.PhaseID(parsed)
+	}
+
+	log.Debug("HTTP: Received message",
+		zap.String("session_id", string(hub.session.ID)),
+		zap.String("event", action))
+	err := s.handleActionRequest(hub.session.Facade, action, phaseID)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -103,18 +180,28 @@ func (s *StateServer) handleAutoTransition(w http.ResponseWriter, r *http.Reques
 func (s *StateServer) handleConditionPartEvaluate(w http.ResponseWriter, r *http.Request) {
 	log := logger.DefaultLogger()
 	vars := mux.Vars(r)
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+	facade := hub.session.Facade
 
-	currentPhase := s.stateFacade.GetCurrentLeafPhase()
-	if currentPhase == nil {
+	leaves := facade.GetCurrentLeafPhases()
+	if len(leaves) == 0 {
 		http.Error(w, "no active phase", http.StatusBadRequest)
 		return
 	}
 
-	if currentPhase.CurrentState() == value.StateReady {
+	if allReady(leaves) {
 		http.Error(w, "state is ready", http.StatusBadRequest)
 		return
 	}
 
+	if facade.IsPaused() {
+		http.Error(w, "state is paused", http.StatusBadRequest)
+		return
+	}
+
 	// URLパラメータの取得と検証
 	conditionID := vars["condition_id"]
 	partID := vars["part_id"]
@@ -124,12 +211,9 @@ func (s *StateServer) handleConditionPartEvaluate(w http.ResponseWriter, r *http
 	}
 
 	log.Debug("Received condition part evaluation request")
-	// リクエストボディの解析
-	var request struct {
-		Increment int64 `json:"increment"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	increment, input, err := decodeEvaluateRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -146,14 +230,17 @@ func (s *StateServer) handleConditionPartEvaluate(w http.ResponseWriter, r *http
 	}
 
 	// 条件パーツの取得と評価
-	part, err := s.stateFacade.GetConditionPart(condIDInt, partIDInt)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get condition part: %v", err), http.StatusInternalServerError)
-		return
+	var part *entity.ConditionPart
+	if input != nil {
+		part, err = facade.EvaluatePartValue(r.Context(), condIDInt, partIDInt, input)
+	} else {
+		part, err = facade.EvaluatePart(r.Context(), condIDInt, partIDInt, increment)
 	}
-
-	err = part.Process(r.Context(), request.Increment)
 	if err != nil {
+		if part == nil {
+			http.Error(w, fmt.Sprintf("Failed to get condition part: %v", err), http.StatusInternalServerError)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to evaluate condition: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -177,18 +264,146 @@ func (s *StateServer) handleConditionPartEvaluate(w http.ResponseWriter, r *http
 	}
 }
 
+// handleEntityEvent 外部エンティティからのイベントを対象の条件パーツに振り分ける
+func (s *StateServer) handleEntityEvent(w http.ResponseWriter, r *http.Request) {
+	log := logger.DefaultLogger()
+	vars := mux.Vars(r)
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+	facade := hub.session.Facade
+
+	leaves := facade.GetCurrentLeafPhases()
+	if len(leaves) == 0 {
+		http.Error(w, "no active phase", http.StatusBadRequest)
+		return
+	}
+
+	if allReady(leaves) {
+		http.Error(w, "state is ready", http.StatusBadRequest)
+		return
+	}
+
+	if facade.IsPaused() {
+		http.Error(w, "state is paused", http.StatusBadRequest)
+		return
+	}
+
+	entityType := vars["entity_type"]
+	entityID, err := strconv.ParseInt(vars["entity_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid entity_id", http.StatusBadRequest)
+		return
+	}
+
+	log.Debug("Received entity event",
+		zap.String("entity_type", entityType),
+		zap.Int64("entity_id", entityID))
+	increment, input, err := decodeEvaluateRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	targets, err := facade.DispatchEntityEvent(r.Context(), entityType, entityID, increment, input)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to dispatch entity event: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// レスポンスの作成
+	type affectedPart struct {
+		ConditionID  value.ConditionID     `json:"condition_id"`
+		PartID       value.ConditionPartID `json:"part_id"`
+		CurrentValue interface{}           `json:"current_value"`
+		IsSatisfied  bool                  `json:"is_satisfied"`
+	}
+	response := struct {
+		Parts []affectedPart `json:"parts"`
+	}{
+		Parts: make([]affectedPart, 0, len(targets)),
+	}
+	for _, target := range targets {
+		response.Parts = append(response.Parts, affectedPart{
+			ConditionID:  target.ConditionID,
+			PartID:       target.Part.ID,
+			CurrentValue: target.Part.GetCurrentValue(),
+			IsSatisfied:  target.Part.IsSatisfied(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error("Failed to encode response", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// allReady は最下層のフェーズがすべて開始前かどうかを返します
+func allReady(leaves entity.Phases) bool {
+	for _, leaf := range leaves {
+		if leaf.CurrentState() != value.StateReady {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeEvaluateRequest は評価リクエストのボディを解析します
+// カウンターは{"increment": 1}、小数・文字列条件は{"value": 0.95}や{"value": "open"}で入力します
+func decodeEvaluateRequest(r *http.Request) (int64, interface{}, error) {
+	var request struct {
+		Increment int64           `json:"increment"`
+		Value     json.RawMessage `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return 0, nil, fmt.Errorf("Invalid request body")
+	}
+	input, err := decodeEvaluateValue(request.Value)
+	if err != nil {
+		return 0, nil, err
+	}
+	return request.Increment, input, nil
+}
+
+// decodeEvaluateValue は評価リクエストのvalueを戦略に渡す型に変換します
+// 数値はfloat64、文字列はstringとして扱い、未指定の場合はnilを返します
+func decodeEvaluateValue(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("Invalid value: %v", err)
+	}
+	switch v := decoded.(type) {
+	case float64, string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("Invalid value: must be a number or a string, got %s", string(raw))
+	}
+}
+
 // handleInitialState 初期状態を取得するAPIエンドポイント
 func (s *StateServer) handleInitialState(w http.ResponseWriter, r *http.Request) {
 	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+	facade := hub.session.Facade
 
 	// すべてのフェーズを取得
-	allPhases := s.stateFacade.GetController().GetPhases()
+	allPhases := facade.GetController().GetPhases()
 	phaseDTOs := GetAllPhasesDTO(allPhases)
 
 	// すべてのフェーズの条件を取得
 	var allConditions []ConditionInfo
 	for _, phase := range allPhases {
-		conditions := s.getConditionInfos(phase)
+		conditions := hub.getConditionInfos(phase)
 		allConditions = append(allConditions, conditions...)
 	}
 
@@ -208,10 +423,10 @@ func (s *StateServer) handleInitialState(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 現在のルートフェーズを取得（親ID=0のフェーズ）
-	currentRootPhase := s.stateFacade.GetCurrentPhase(0)
+	currentRootPhase := facade.GetCurrentPhase(0)
 	if currentRootPhase != nil {
 		// 現在のルートフェーズが存在する場合のみ、関連情報を設定
-		stateInfo := s.getGameStateInfo(currentRootPhase)
+		stateInfo := newGameStateInfo(currentRootPhase)
 		response.State = currentRootPhase.CurrentState()
 		response.Info = stateInfo
 		response.Message = fmt.Sprintf("order: %v, message: %v", currentRootPhase.Order, stateInfo.Message)
@@ -234,15 +449,173 @@ func (s *StateServer) handleInitialState(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (s *StateServer) Start(addr string) error {
+// handleSnapshot セッションのゲーム状態のスナップショットを返す
+func (s *StateServer) handleSnapshot(w http.ResponseWriter, r *http.Request) {
 	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hub.session.Facade.Snapshot()); err != nil {
+		log.Error("Failed to encode response", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleRestore スナップショットからセッションのゲーム状態を復元する
+func (s *StateServer) handleRestore(w http.ResponseWriter, r *http.Request) {
+	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var snapshot entity.Snapshot
+	if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := hub.session.Facade.Restore(r.Context(), &snapshot); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to restore snapshot: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Debug("Session restored", zap.String("session_id", string(hub.session.ID)))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleJournal セッションの状態遷移ジャーナルを返す
+// クエリパラメータsinceを指定すると、その連番より後のエントリのみを返す
+func (s *StateServer) handleJournal(w http.ResponseWriter, r *http.Request) {
+	log := logger.DefaultLogger()
+	hub, ok := s.hubFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hub.session.Facade.Journal().Since(since)); err != nil {
+		log.Error("Failed to encode response", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleListSessions セッションの一覧を返す
+func (s *StateServer) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	log := logger.DefaultLogger()
+
+	sessions := s.sessions.List()
+	response := make([]SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		clients := 0
+		if hub, ok := s.getHub(session.ID); ok {
+			clients = hub.clientCount()
+		}
+		response = append(response, ConvertSessionToDTO(session, clients))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error("Failed to encode response", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleCreateSession 新しいセッションを作成する
+// リクエストボディの"id"が空の場合はランダムなIDを割り当てる
+func (s *StateServer) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	log := logger.DefaultLogger()
+
+	var request struct {
+		ID state.SessionID `json:"id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hub, err := s.createSession(request.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create session: %v", err), http.StatusConflict)
+		return
+	}
+	log.Debug("Session created", zap.String("session_id", string(hub.session.ID)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(ConvertSessionToDTO(hub.session, 0)); err != nil {
+		log.Error("Failed to encode response", zap.Error(err))
+		return
+	}
+}
+
+// handleDeleteSession セッションを破棄する
+func (s *StateServer) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	id := sessionIDFromRequest(r)
+	if _, ok := s.getHub(id); !ok {
+		http.Error(w, fmt.Sprintf("session not found: %s", id), http.StatusNotFound)
+		return
+	}
+
+	if err := s.destroySession(r.Context(), id); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to destroy session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Router はHTTPルーティングを構築します
+// /api/sessions/{session_id}/ 以下のルートはセッションごとに、
+// それ以外のルートはデフォルトセッションに対して動作します
+func (s *StateServer) Router() *mux.Router {
 	r := mux.NewRouter()
 
+	r.HandleFunc("/api/sessions", s.handleListSessions).Methods("GET")
+	r.HandleFunc("/api/sessions", s.handleCreateSession).Methods("POST")
+	r.HandleFunc("/api/sessions/{session_id}", s.handleDeleteSession).Methods("DELETE")
+
+	s.registerSessionRoutes(r.PathPrefix("/api/sessions/{session_id}").Subrouter())
+	s.registerSessionRoutes(r.PathPrefix("/api").Subrouter())
 	r.HandleFunc("/ws", s.handleWebSocket)
-	r.HandleFunc("/api/auto-transition", s.handleAutoTransition).Methods("POST")
-	r.HandleFunc("/api/condition/{condition_id}/part/{part_id}/evaluate", s.handleConditionPartEvaluate).Methods("POST")
-	r.HandleFunc("/api/initial-state", s.handleInitialState).Methods("GET")
+
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir("internal/ui/static")))
+	return r
+}
+
+// registerSessionRoutes はセッションに対する操作のルートを登録します
+func (s *StateServer) registerSessionRoutes(r *mux.Router) {
+	r.HandleFunc("/ws", s.handleWebSocket)
+	r.HandleFunc("/auto-transition", s.handleAutoTransition).Methods("POST")
+	r.HandleFunc("/condition/{condition_id}/part/{part_id}/evaluate", s.handleConditionPartEvaluate).Methods("POST")
+	r.HandleFunc("/entity/{entity_type}/{entity_id}/event", s.handleEntityEvent).Methods("POST")
+	r.HandleFunc("/initial-state", s.handleInitialState).Methods("GET")
+	r.HandleFunc("/snapshot", s.handleSnapshot).Methods("GET")
+	r.HandleFunc("/restore", s.handleRestore).Methods("POST")
+	r.HandleFunc("/journal", s.handleJournal).Methods("GET")
+}
+
+func (s *StateServer) Start(addr string) error {
+	log := logger.DefaultLogger()
+	r := s.Router()
 
 	log.Debug("Starting server on", zap.String("addr", addr))
 	return http.ListenAndServe(addr, r)
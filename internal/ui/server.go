@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"state_sample/internal/domain/entity"
@@ -17,15 +18,18 @@ import (
 
 // ConditionInfo は条件の状態情報を表す構造体です
 type ConditionInfo struct {
-	ID          value.ConditionID   `json:"id"`
-	Label       string              `json:"label"`
-	State       string              `json:"state"`
-	Kind        value.ConditionKind `json:"kind"`
-	IsClear     bool                `json:"is_clear"`
-	Description string              `json:"description"`
-	PhaseID     value.PhaseID       `json:"phase_id"`
-	PhaseName   string              `json:"phase_name"`
-	Parts       []ConditionPartInfo `json:"parts"`
+	ID            value.ConditionID     `json:"id"`
+	Label         string                `json:"label"`
+	State         string                `json:"state"`
+	Kind          value.ConditionKind   `json:"kind"`
+	Combination   value.PartCombination `json:"combination"`
+	RequiredParts int                   `json:"required_parts"` // 条件を満たすために必要なパーツ数
+	Ordered       bool                  `json:"ordered"`        // パーツを優先度の順に満たす必要があるか
+	IsClear       bool                  `json:"is_clear"`
+	Description   string                `json:"description"`
+	PhaseID       value.PhaseID         `json:"phase_id"`
+	PhaseName     string                `json:"phase_name"`
+	Parts         []ConditionPartInfo   `json:"parts"`
 }
 
 // ConditionPartInfo は条件パーツの状態情報を表す構造体です
@@ -35,88 +39,98 @@ type ConditionPartInfo struct {
 	State                string                   `json:"state"`
 	ComparisonOperator   value.ComparisonOperator `json:"comparison_operator"`
 	IsClear              bool                     `json:"is_clear"`
+	Paused               bool                     `json:"paused"`
+	Held                 bool                     `json:"held"` // 先行するパーツを待って完了を保留しているか
+	Live                 bool                     `json:"live"` // 値が基準を外れると未達成に戻るか
 	TargetEntityType     string                   `json:"target_entity_type"`
 	TargetEntityID       int64                    `json:"target_entity_id"`
 	ReferenceValueInt    int64                    `json:"reference_value_int"`
 	ReferenceValueFloat  float64                  `json:"reference_value_float"`
 	ReferenceValueString string                   `json:"reference_value_string"`
+	ReferenceValueSet    []int64                  `json:"reference_value_set,omitempty"`
+	ReferenceStringSet   []string                 `json:"reference_string_set,omitempty"`
 	MinValue             int64                    `json:"min_value"`
 	MaxValue             int64                    `json:"max_value"`
+	MinValueFloat        float64                  `json:"min_value_float"`
+	MaxValueFloat        float64                  `json:"max_value_float"`
+	DurationSeconds      int64                    `json:"duration_seconds"`
+	OnMiss               value.SequenceMissPolicy `json:"on_miss"`
 	Priority             int32                    `json:"priority"`
+	DeadlineSeconds      int64                    `json:"deadline_seconds"`      // 満たす必要のある期限の秒数(0の場合は期限なし)
+	DeadlineRemainingMs  int64                    `json:"deadline_remaining_ms"` // 期限までの残り時間(ミリ秒)
 	CurrentValue         interface{}              `json:"current_value"`
 }
 
+// StateServer は複数のゲームセッションをHTTP / WebSocketで公開するサーバーです
 type StateServer struct {
-	stateFacade *state.GameFacade
-	clients     map[*websocket.Conn]bool
-	upgrader    websocket.Upgrader
-	mu          sync.RWMutex
-	updateChan  chan interface{} // 更新メッセージを送信するためのチャネル
-	done        chan struct{}    // サーバー終了を通知するためのチャネル
+	sessions *state.SessionManager
+	hubs     map[state.SessionID]*sessionHub
+	upgrader websocket.Upgrader
+	mu       sync.RWMutex
 }
 
-func NewStateServer(facade *state.GameFacade) *StateServer {
+// NewStateServer は新しいStateServerを作成します
+// SessionManagerに作成済みのセッションはそのまま公開され、
+// デフォルトセッションが存在しない場合はここで作成します
+func NewStateServer(sessions *state.SessionManager) *StateServer {
 	log := logger.DefaultLogger()
 	log.Debug("Creating new state server instance")
 	server := &StateServer{
-		stateFacade: facade,
-		clients:     make(map[*websocket.Conn]bool),
+		sessions: sessions,
+		hubs:     make(map[state.SessionID]*sessionHub),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
-		updateChan: make(chan interface{}, 100), // バッファ付きチャネルを作成
-		done:       make(chan struct{}),
 	}
 
-	// オブザーバーとして登録
-	controller := facade.GetController()
-	log.Debug("Got controller from facade", zap.String("controller", fmt.Sprintf("%p", controller)))
-
-	controller.AddControllerObserver(server)
-	log.Debug("Added StateServer as StateObserver", zap.String("server", fmt.Sprintf("%p", server)))
+	for _, session := range sessions.List() {
+		server.hubs[session.ID] = newSessionHub(session)
+	}
 
-	// 更新メッセージを処理するゴルーチンを起動
-	go server.processUpdates()
+	if _, ok := sessions.Get(state.DefaultSessionID); !ok {
+		if _, err := server.createSession(state.DefaultSessionID); err != nil {
+			log.Error("Failed to create default session", zap.Error(err))
+		}
+	}
 
 	return server
 }
 
-// processUpdates は更新メッセージを処理するゴルーチン
-func (s *StateServer) processUpdates() {
-	log := logger.DefaultLogger()
-	log.Debug("Starting update processor goroutine")
+// createSession は新しいセッションを作成し、配信用のハブを登録します
+func (s *StateServer) createSession(id state.SessionID) (*sessionHub, error) {
+	session, err := s.sessions.Create(id)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		select {
-		case update := <-s.updateChan:
-			// 実際の更新処理を行う
-			s.sendUpdateToClients(update)
-		case <-s.done:
-			log.Debug("Update processor goroutine shutting down")
-			return
-		}
+	hub := newSessionHub(session)
+	s.mu.Lock()
+	s.hubs[session.ID] = hub
+	s.mu.Unlock()
+	return hub, nil
+}
+
+// destroySession はセッションのクライアントを切断し、セッションを破棄します
+func (s *StateServer) destroySession(ctx context.Context, id state.SessionID) error {
+	s.mu.Lock()
+	hub, ok := s.hubs[id]
+	delete(s.hubs, id)
+	s.mu.Unlock()
+
+	if ok {
+		hub.close()
 	}
+	return s.sessions.Destroy(ctx, id)
 }
 
-// sendUpdateToClients は実際にクライアントに更新を送信する
-func (s *StateServer) sendUpdateToClients(update interface{}) {
-	log := logger.DefaultLogger()
+// getHub は指定されたセッションのハブを返します
+func (s *StateServer) getHub(id state.SessionID) (*sessionHub, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-
-	log.Debug("Sending update to clients", zap.Any("update", update))
-	for client := range s.clients {
-		if err := client.WriteJSON(update); err != nil {
-			log.Error("Error sending message to client", zap.Error(err))
-			err := client.Close()
-			if err != nil {
-				log.Error("Error closing client connection", zap.Error(err))
-			}
-			delete(s.clients, client)
-		}
-	}
+	hub, ok := s.hubs[id]
+	return hub, ok
 }
 
 // GameStateInfo は状態情報を表す構造体です
@@ -127,6 +141,11 @@ type GameStateInfo struct {
 
 // getGameStateInfo はGameStateInfoを取得します
 func (s *StateServer) getGameStateInfo(phase *entity.Phase) *GameStateInfo {
+	return newGameStateInfo(phase)
+}
+
+// newGameStateInfo はフェーズからGameStateInfoを作成します
+func newGameStateInfo(phase *entity.Phase) *GameStateInfo {
 	if phase == nil {
 		return &GameStateInfo{
 			CurrentState: value.StateReady,
@@ -185,13 +204,16 @@ func (s *StateServer) EditResponse(stateName string, currentPhase *entity.Phase,
 	conditions := make([]ConditionInfo, 0)
 	for _, condition := range currentPhase.GetConditions() {
 		condInfo := ConditionInfo{
-			ID:          condition.ID,
-			Label:       condition.Label,
-			State:       condition.CurrentState(),
-			Kind:        condition.Kind,
-			IsClear:     condition.IsClear,
-			Description: condition.Description,
-			Parts:       make([]ConditionPartInfo, 0),
+			ID:            condition.ID,
+			Label:         condition.Label,
+			State:         condition.CurrentState(),
+			Kind:          condition.Kind,
+			Combination:   condition.Combination,
+			RequiredParts: condition.RequiredPartCount(),
+			Ordered:       condition.Ordered,
+			IsClear:       condition.IsClear,
+			Description:   condition.Description,
+			Parts:         make([]ConditionPartInfo, 0),
 		}
 		for _, part := range condition.GetParts() {
 			partInfo := ConditionPartInfo{
@@ -200,14 +222,25 @@ func (s *StateServer) EditResponse(stateName string, currentPhase *entity.Phase,
 				State:                part.CurrentState(),
 				ComparisonOperator:   part.ComparisonOperator,
 				IsClear:              part.IsClear,
+				Paused:               part.IsPaused(),
+				Held:                 part.IsHeld(),
+				Live:                 part.Live,
 				TargetEntityType:     part.TargetEntityType,
 				TargetEntityID:       part.TargetEntityID,
 				ReferenceValueInt:    part.ReferenceValueInt,
 				ReferenceValueFloat:  part.ReferenceValueFloat,
 				ReferenceValueString: part.ReferenceValueString,
+				ReferenceValueSet:    part.GetReferenceValueSet(),
+				ReferenceStringSet:   part.GetReferenceStringSet(),
 				MinValue:             part.MinValue,
 				MaxValue:             part.MaxValue,
+				MinValueFloat:        part.MinValueFloat,
+				MaxValueFloat:        part.MaxValueFloat,
+				DurationSeconds:      part.DurationSeconds,
+				OnMiss:               part.OnMiss,
 				Priority:             part.Priority,
+				DeadlineSeconds:      part.DeadlineSeconds,
+				DeadlineRemainingMs:  part.DeadlineRemaining().Milliseconds(),
 				CurrentValue:         part.GetCurrentValue(), // strategy経由で現在値を取得
 			}
 			condInfo.Parts = append(condInfo.Parts, partInfo)
@@ -285,173 +318,17 @@ func (s *StateServer) EditResponse(stateName string, currentPhase *entity.Phase,
 	return update
 }
 
-func (s *StateServer) OnEntityChanged(entityObj interface{}) {
-	log := logger.DefaultLogger()
-	var currentPhase *entity.Phase
-	if entityObj != nil {
-		switch e := entityObj.(type) {
-		case *entity.Phase:
-			log.Debug("StateServer.OnEntityChanged", zap.Any("entity", e))
-		case *entity.Condition:
-			log.Debug("StateServer.OnEntityChanged", zap.Any("entity", e))
-		case *entity.ConditionPart:
-			log.Debug("StateServer.OnEntityChanged", zap.Any("entity", e))
-		default:
-			log.Debug("StateServer.OnEntityChanged", zap.Any("entity", e))
-		}
-		// ルートフェーズを取得（親ID=0のフェーズ）
-		currentPhase = s.stateFacade.GetCurrentPhase(0)
-		// ルートフェーズが存在しない場合は最下層のフェーズを取得
-		if currentPhase == nil {
-			currentPhase = s.stateFacade.GetCurrentLeafPhase()
-		}
-	} else {
-		// nilの場合は終了なので、最後の情報を取得
-		currentPhase = s.stateFacade.GetController().GetPhases()[:1][0]
-	}
-
-	// currentPhaseがnilの場合の対処
-	if currentPhase == nil {
-		log.Debug("OnEntityChanged: No active phase found, using default state")
-		// デフォルトの状態情報を送信
-		defaultUpdate := struct {
-			Type    string `json:"type"`
-			State   string `json:"state"`
-			Message string `json:"message"`
-		}{
-			Type:    "state_info",
-			State:   value.StateReady,
-			Message: "No active phase. System is initializing or in transition.",
-		}
-		s.broadcastUpdate(defaultUpdate)
-		return
-	}
-
-	// DTOアプローチを使用して全てのフェーズを取得
-	allPhases := s.stateFacade.GetController().GetPhases()
-	phaseDTOs := GetAllPhasesDTO(allPhases)
-
-	// 現在のルートフェーズを取得
-	currentRootPhase := s.stateFacade.GetCurrentPhase(0)
-
-	// 現在のフェーズを決定（ルートフェーズを優先）
-	displayPhase := currentPhase
-	if currentRootPhase != nil && currentRootPhase.CurrentState() != value.StateFinish {
-		displayPhase = currentRootPhase
-	}
-
-	// すべてのフェーズの条件を取得
-	var allConditions []ConditionInfo
-	for _, phase := range allPhases {
-		conditions := s.getConditionInfos(phase)
-		allConditions = append(allConditions, conditions...)
-	}
-
-	// レスポンスを構築
-	response := struct {
-		Type         string          `json:"type"`
-		Phases       []PhaseDTO      `json:"phases"`
-		CurrentPhase *PhaseDTO       `json:"current_phase,omitempty"`
-		State        string          `json:"state"`
-		Info         *GameStateInfo  `json:"info,omitempty"`
-		Message      string          `json:"message,omitempty"`
-		Conditions   []ConditionInfo `json:"conditions"`
-	}{
-		Type:       "state_change",
-		Phases:     phaseDTOs,
-		State:      displayPhase.CurrentState(),
-		Info:       s.getGameStateInfo(displayPhase),
-		Message:    fmt.Sprintf("order: %v, message: %v", displayPhase.Order, s.getGameStateInfo(displayPhase).Message),
-		Conditions: allConditions,
-	}
-
-	// 現在のフェーズをDTOに変換
-	currentDTO := ConvertPhaseToDTO(displayPhase)
-	response.CurrentPhase = &currentDTO
-
-	s.broadcastUpdate(response)
-}
-
-// getConditionInfos は条件情報を取得する
-func (s *StateServer) getConditionInfos(phase *entity.Phase) []ConditionInfo {
-	conditions := make([]ConditionInfo, 0)
-	for _, condition := range phase.GetConditions() {
-		condInfo := ConditionInfo{
-			ID:          condition.ID,
-			Label:       condition.Label,
-			State:       condition.CurrentState(),
-			Kind:        condition.Kind,
-			IsClear:     condition.IsClear,
-			Description: condition.Description,
-			PhaseID:     phase.ID,
-			PhaseName:   phase.Name,
-			Parts:       make([]ConditionPartInfo, 0),
-		}
-		for _, part := range condition.GetParts() {
-			partInfo := ConditionPartInfo{
-				ID:                   part.ID,
-				Label:                part.Label,
-				State:                part.CurrentState(),
-				ComparisonOperator:   part.ComparisonOperator,
-				IsClear:              part.IsClear,
-				TargetEntityType:     part.TargetEntityType,
-				TargetEntityID:       part.TargetEntityID,
-				ReferenceValueInt:    part.ReferenceValueInt,
-				ReferenceValueFloat:  part.ReferenceValueFloat,
-				ReferenceValueString: part.ReferenceValueString,
-				MinValue:             part.MinValue,
-				MaxValue:             part.MaxValue,
-				Priority:             part.Priority,
-				CurrentValue:         part.GetCurrentValue(),
-			}
-			condInfo.Parts = append(condInfo.Parts, partInfo)
-		}
-		conditions = append(conditions, condInfo)
-	}
-	return conditions
-}
-
-func (s *StateServer) OnError(err error) {
-	update := struct {
-		Type  string `json:"type"`
-		Error string `json:"error"`
-	}{
-		Type:  "error",
-		Error: err.Error(),
-	}
-	s.broadcastUpdate(update)
-}
-
-func (s *StateServer) broadcastUpdate(update interface{}) {
-	log := logger.DefaultLogger()
-	log.Debug("Queueing update for broadcast", zap.Any("update", update))
-
-	// 更新メッセージをチャネルに送信（非ブロッキング）
-	select {
-	case s.updateChan <- update:
-		// メッセージが正常にキューに入った
-	default:
-		// チャネルがいっぱいの場合
-		log.Warn("Update channel is full, dropping message")
-	}
-}
-
+// Close は全てのセッションのクライアントを切断します
 func (s *StateServer) Close() error {
 	log := logger.DefaultLogger()
 	log.Debug("Closing state server")
 
-	// 更新処理ゴルーチンを終了
-	close(s.done)
-	log.Debug("Sent shutdown signal to update processor")
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for client := range s.clients {
-		if err := client.Close(); err != nil {
-			log.Error("Error closing client", zap.Error(err))
-		}
+	for id, hub := range s.hubs {
+		hub.close()
+		delete(s.hubs, id)
 	}
-	s.clients = nil
 	return nil
 }
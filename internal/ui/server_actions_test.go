@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"state_sample/internal/domain/value"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPauseResumeActions はHTTPからの一時停止・再開をテストします
+func TestPauseResumeActions(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := doRequest(server, http.MethodPost, "/api/auto-transition?action=start", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=pause", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// 二重の一時停止はエラー
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=pause", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var initial struct {
+		State string `json:"state"`
+	}
+	rec = doRequest(server, http.MethodGet, "/api/initial-state", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &initial))
+	assert.Equal(t, value.StatePaused, initial.State)
+
+	// 一時停止中はカウンターへの入力を受け付けない
+	rec = doRequest(server, http.MethodPost, "/api/condition/3/part/3/evaluate", `{"increment":1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=resume", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(server, http.MethodGet, "/api/initial-state", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &initial))
+	assert.Equal(t, value.StateActive, initial.State)
+
+	rec = doRequest(server, http.MethodPost, "/api/condition/3/part/3/evaluate", `{"increment":1}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestOperatorActions はHTTPからのフェーズを飛ばす・移る・戻る操作をテストします
+func TestOperatorActions(t *testing.T) {
+	server := newTestServer(t)
+
+	phaseState := func(id value.PhaseID) string {
+		var initial struct {
+			Phases []struct {
+				ID    value.PhaseID `json:"id"`
+				State string        `json:"state"`
+			} `json:"phases"`
+		}
+		rec := doRequest(server, http.MethodGet, "/api/initial-state", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &initial))
+		for _, phase := range initial.Phases {
+			if phase.ID == id {
+				return phase.State
+			}
+		}
+		return ""
+	}
+
+	rec := doRequest(server, http.MethodPost, "/api/auto-transition?action=start", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, value.StateActive, phaseState(3))
+
+	// 移り先のフェーズIDが無い、または不正な場合はエラー
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=jump", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=jump&phase_id=abc", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=jump&phase_id=99", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// 後ろのルートフェーズに移ると、離れたフェーズは飛ばして終了する
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=jump&phase_id=2", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, value.StateActive, phaseState(2))
+	assert.Equal(t, value.StateFinish, phaseState(1))
+	assert.Equal(t, value.StateFinish, phaseState(3))
+
+	// 1つ前のルートフェーズに戻ると、最初の子フェーズから始まる
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=rewind", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, value.StateReady, phaseState(2))
+	assert.Equal(t, value.StateActive, phaseState(1))
+	assert.Equal(t, value.StateActive, phaseState(3))
+
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=skip", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, value.StateFinish, phaseState(3))
+	assert.Equal(t, value.StateActive, phaseState(4))
+}
+
+// TestWebSocketActionErrors はWebSocketからの操作が失敗しても接続が閉じられないことをテストします
+func TestWebSocketActionErrors(t *testing.T) {
+	server := newTestServer(t)
+	ts := httptest.NewServer(server.Router())
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// 指定した種類のメッセージが届くまで読み進める
+	readUntil := func(msgType string) map[string]interface{} {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		for {
+			var msg map[string]interface{}
+			require.NoError(t, conn.ReadJSON(&msg))
+			if msg["type"] == msgType {
+				return msg
+			}
+		}
+	}
+
+	// 一時停止していない状態での再開はエラーとして返される
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"event": "resume"}))
+	msg := readUntil("error")
+	assert.Contains(t, msg["error"], "not paused")
+
+	// 存在しないフェーズへの移動もエラーとして返される
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"event": "jump", "phase_id": 99}))
+	msg = readUntil("error")
+	assert.Contains(t, msg["error"], "not found")
+
+	// エラーの後も同じ接続で操作を続けられる
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"event": "start"}))
+	readUntil("state_change")
+}
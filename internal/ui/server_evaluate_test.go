@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/usecase/state"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTypedValueTestServer は小数・文字列条件を持つセッションのテスト用サーバーを作成します
+func newTypedValueTestServer(t *testing.T) *StateServer {
+	sessions := state.NewSessionManager(func() (*state.GameFacade, error) {
+		phases, err := state.BuildPhases(
+			state.Phase("MEASURE").ID(1).All(
+				state.Float("accuracy").ID(1).CompareFloat(value.ComparisonOperatorGTE, 0.9),
+				state.Text("door").ID(2).CompareString(value.ComparisonOperatorEQ, "open"),
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return state.NewGameFacade(phases), nil
+	})
+	server := NewStateServer(sessions)
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+// TestEvaluateTypedValues は型付きの評価値の入力をテストします
+func TestEvaluateTypedValues(t *testing.T) {
+	server := newTypedValueTestServer(t)
+	rec := doRequest(server, http.MethodPost, "/api/auto-transition?action=start", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		CurrentValue interface{} `json:"current_value"`
+		IsSatisfied  bool        `json:"is_satisfied"`
+	}
+
+	rec = doRequest(server, http.MethodPost, "/api/condition/1/part/1/evaluate", `{"value":0.95}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.IsSatisfied)
+
+	rec = doRequest(server, http.MethodPost, "/api/condition/2/part/2/evaluate", `{"value":"closed"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "closed", response.CurrentValue)
+	assert.False(t, response.IsSatisfied)
+
+	// 数値・文字列以外の値は受け付けない
+	rec = doRequest(server, http.MethodPost, "/api/condition/2/part/2/evaluate", `{"value":["open"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// 型の合わない値は評価に失敗する
+	rec = doRequest(server, http.MethodPost, "/api/condition/2/part/2/evaluate", `{"value":1}`)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+// TestEntityEvent はエンティティイベントの振り分けをテストします
+func TestEntityEvent(t *testing.T) {
+	sessions := state.NewSessionManager(func() (*state.GameFacade, error) {
+		phases, err := state.BuildPhases(
+			state.Phase("DEVICES").ID(1).All(
+				state.Counter("switch").ID(1).Target("switch", 7).GTE(2),
+				state.Text("door").ID(2).Target("door", 1).CompareString(value.ComparisonOperatorEQ, "open"),
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return state.NewGameFacade(phases), nil
+	})
+	server := NewStateServer(sessions)
+	t.Cleanup(func() { _ = server.Close() })
+
+	// 開始前は受け付けない
+	rec := doRequest(server, http.MethodPost, "/api/entity/switch/7/event", `{"increment":1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(server, http.MethodPost, "/api/auto-transition?action=start", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Parts []struct {
+			ConditionID  int64       `json:"condition_id"`
+			PartID       int64       `json:"part_id"`
+			CurrentValue interface{} `json:"current_value"`
+			IsSatisfied  bool        `json:"is_satisfied"`
+		} `json:"parts"`
+	}
+
+	rec = doRequest(server, http.MethodPost, "/api/entity/switch/7/event", `{"increment":1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Parts, 1)
+	assert.Equal(t, int64(1), response.Parts[0].ConditionID)
+	assert.Equal(t, int64(1), response.Parts[0].PartID)
+	assert.Equal(t, float64(1), response.Parts[0].CurrentValue)
+	assert.False(t, response.Parts[0].IsSatisfied)
+
+	rec = doRequest(server, http.MethodPost, "/api/entity/door/1/event", `{"value":"open"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Parts, 1)
+	assert.True(t, response.Parts[0].IsSatisfied)
+
+	// 対象のパーツがないエンティティは空の結果を返す
+	rec = doRequest(server, http.MethodPost, "/api/entity/door/2/event", `{"value":"open"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Parts)
+
+	rec = doRequest(server, http.MethodPost, "/api/entity/door/x/event", `{"value":"open"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
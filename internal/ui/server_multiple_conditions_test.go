@@ -43,9 +43,7 @@ func TestServerWithMultipleConditions(t *testing.T) {
 	cond1_2.AddConditionObserver(phase1)
 
 	// テスト用のモックサーバーを直接作成
-	server := &StateServer{
-		stateFacade: nil, // テストでは使用しない
-	}
+	server := &StateServer{}
 
 	// EditResponseメソッドを呼び出して、レスポンスを取得
 	stateInfo := server.getGameStateInfo(phase1)
@@ -104,8 +102,8 @@ func (m *mockStateFacade) GetCurrentPhase(parentID value.PhaseID) *entity.Phase
 	return nil
 }
 
-func (m *mockStateFacade) GetCurrentLeafPhase() *entity.Phase {
-	return m.currentPhase
+func (m *mockStateFacade) GetCurrentLeafPhases() entity.Phases {
+	return entity.Phases{m.currentPhase}
 }
 
 func (m *mockStateFacade) GetController() *state.PhaseController {
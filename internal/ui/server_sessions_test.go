@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/usecase/state"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer はテスト用のStateServerを作成します
+func newTestServer(t *testing.T) *StateServer {
+	sessions := state.NewSessionManager(func() (*state.GameFacade, error) {
+		return state.NewStateFacade(), nil
+	})
+	server := NewStateServer(sessions)
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+// doRequest はルーターに対してリクエストを実行します
+func doRequest(server *StateServer, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestSessionLifecycle はセッションの作成・一覧・破棄をテストします
+func TestSessionLifecycle(t *testing.T) {
+	server := newTestServer(t)
+
+	// デフォルトセッションは自動で作成される
+	rec := doRequest(server, http.MethodGet, "/api/sessions", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var sessions []SessionDTO
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, state.DefaultSessionID, sessions[0].ID)
+
+	rec = doRequest(server, http.MethodPost, "/api/sessions", `{"id":"room1"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created SessionDTO
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, state.SessionID("room1"), created.ID)
+
+	// 同じIDは作成できない
+	rec = doRequest(server, http.MethodPost, "/api/sessions", `{"id":"room1"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRequest(server, http.MethodDelete, "/api/sessions/room1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(server, http.MethodDelete, "/api/sessions/room1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestSessionScopedRoutes はセッションごとに独立したゲームが操作されることをテストします
+func TestSessionScopedRoutes(t *testing.T) {
+	server := newTestServer(t)
+	rec := doRequest(server, http.MethodPost, "/api/sessions", `{"id":"room1"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = doRequest(server, http.MethodPost, "/api/sessions/room1/auto-transition?action=start", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	room1, ok := server.sessions.Get("room1")
+	require.True(t, ok)
+	defaultSession, ok := server.sessions.Get(state.DefaultSessionID)
+	require.True(t, ok)
+
+	assert.Equal(t, value.StateActive, room1.Facade.GetCurrentPhase(0).CurrentState())
+	assert.Nil(t, defaultSession.Facade.GetCurrentPhase(0), "default session must not be started")
+
+	// セッション指定なしのルートはデフォルトセッションを返す
+	var initial struct {
+		State string `json:"state"`
+	}
+	rec = doRequest(server, http.MethodGet, "/api/initial-state", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &initial))
+	assert.Equal(t, value.StateReady, initial.State)
+
+	rec = doRequest(server, http.MethodGet, "/api/sessions/room1/initial-state", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &initial))
+	assert.Equal(t, value.StateActive, initial.State)
+
+	// 存在しないセッションは404
+	rec = doRequest(server, http.MethodGet, "/api/sessions/missing/initial-state", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// 破棄するとタイマーも止まる
+	rec = doRequest(server, http.MethodDelete, "/api/sessions/room1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, value.StateReady, room1.Facade.GetCurrentPhase(0).CurrentState())
+}
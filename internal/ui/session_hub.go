@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"state_sample/internal/usecase/state"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// sessionHub は1つのゲームセッションとそのWebSocketクライアントを束ねます
+// セッションのPhaseControllerを監視し、更新はそのセッションのクライアントにのみ配信します
+type sessionHub struct {
+	session    *state.Session
+	clients    map[*websocket.Conn]bool
+	mu         sync.RWMutex
+	updateChan chan interface{} // 更新メッセージを送信するためのチャネル
+	done       chan struct{}    // セッション終了を通知するためのチャネル
+}
+
+// newSessionHub は新しいsessionHubを作成し、セッションのコントローラーに登録します
+func newSessionHub(session *state.Session) *sessionHub {
+	log := logger.DefaultLogger()
+	h := &sessionHub{
+		session:    session,
+		clients:    make(map[*websocket.Conn]bool),
+		updateChan: make(chan interface{}, 100), // バッファ付きチャネルを作成
+		done:       make(chan struct{}),
+	}
+
+	// オブザーバーとして登録
+	controller := session.Facade.GetController()
+	controller.AddControllerObserver(h)
+	log.Debug("Added sessionHub as ControllerObserver",
+		zap.String("session_id", string(session.ID)),
+		zap.String("controller", fmt.Sprintf("%p", controller)))
+
+	// 更新メッセージを処理するゴルーチンを起動
+	go h.processUpdates()
+
+	return h
+}
+
+// addClient はWebSocketクライアントを登録します
+func (h *sessionHub) addClient(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[conn] = true
+}
+
+// removeClient はWebSocketクライアントの登録を解除します
+func (h *sessionHub) removeClient(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, conn)
+}
+
+// clientCount は接続中のクライアント数を返します
+func (h *sessionHub) clientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// close はオブザーバー登録を解除し、クライアントとの接続を閉じます
+func (h *sessionHub) close() {
+	log := logger.DefaultLogger()
+	h.session.Facade.GetController().RemoveControllerObserver(h)
+
+	// 更新処理ゴルーチンを終了
+	close(h.done)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if err := client.Close(); err != nil {
+			log.Error("Error closing client", zap.Error(err))
+		}
+	}
+	h.clients = make(map[*websocket.Conn]bool)
+}
+
+// processUpdates は更新メッセージを処理するゴルーチン
+func (h *sessionHub) processUpdates() {
+	log := logger.DefaultLogger()
+	log.Debug("Starting update processor goroutine")
+
+	for {
+		select {
+		case update := <-h.updateChan:
+			// 実際の更新処理を行う
+			h.sendUpdateToClients(update)
+		case <-h.done:
+			log.Debug("Update processor goroutine shutting down")
+			return
+		}
+	}
+}
+
+// sendUpdateToClients は実際にクライアントに更新を送信する
+func (h *sessionHub) sendUpdateToClients(update interface{}) {
+	log := logger.DefaultLogger()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	log.Debug("Sending update to clients", zap.Any("update", update))
+	for client := range h.clients {
+		if err := client.WriteJSON(update); err != nil {
+			log.Error("Error sending message to client", zap.Error(err))
+			err := client.Close()
+			if err != nil {
+				log.Error("Error closing client connection", zap.Error(err))
+			}
+			delete(h.clients, client)
+		}
+	}
+}
+
+// sendToClient は1つのクライアントにメッセージを送信します
+// 更新の配信と書き込みが重ならないよう、ロックを取得してから送信します
+func (h *sessionHub) sendToClient(conn *websocket.Conn, msg interface{}) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return conn.WriteJSON(msg)
+}
+
+func (h *sessionHub) OnEntityChanged(entityObj interface{}) {
+	log := logger.DefaultLogger()
+	var currentPhase *entity.Phase
+	if entityObj != nil {
+		switch e := entityObj.(type) {
+		case *entity.Phase:
+			log.Debug("sessionHub.OnEntityChanged", zap.Any("entity", e))
+		case *entity.Condition:
+			log.Debug("sessionHub.OnEntityChanged", zap.Any("entity", e))
+		case *entity.ConditionPart:
+			log.Debug("sessionHub.OnEntityChanged", zap.Any("entity", e))
+		default:
+			log.Debug("sessionHub.OnEntityChanged", zap.Any("entity", e))
+		}
+		// ルートフェーズを取得（親ID=0のフェーズ）
+		currentPhase = h.session.Facade.GetCurrentPhase(0)
+		// ルートフェーズが存在しない場合は最下層のフェーズを取得
+		if currentPhase == nil {
+			if leaves := h.session.Facade.GetCurrentLeafPhases(); len(leaves) > 0 {
+				currentPhase = leaves[0]
+			}
+		}
+	} else {
+		// nilの場合は終了なので、最後の情報を取得
+		currentPhase = h.session.Facade.GetController().GetPhases()[:1][0]
+	}
+
+	// currentPhaseがnilの場合の対処
+	if currentPhase == nil {
+		log.Debug("OnEntityChanged: No active phase found, using default state")
+		// デフォルトの状態情報を送信
+		defaultUpdate := struct {
+			Type    string `json:"type"`
+			State   string `json:"state"`
+			Message string `json:"message"`
+		}{
+			Type:    "state_info",
+			State:   value.StateReady,
+			Message: "No active phase. System is initializing or in transition.",
+		}
+		h.broadcastUpdate(defaultUpdate)
+		return
+	}
+
+	// DTOアプローチを使用して全てのフェーズを取得
+	allPhases := h.session.Facade.GetController().GetPhases()
+	phaseDTOs := GetAllPhasesDTO(allPhases)
+
+	// 現在のルートフェーズを取得
+	currentRootPhase := h.session.Facade.GetCurrentPhase(0)
+
+	// 現在のフェーズを決定（ルートフェーズを優先）
+	displayPhase := currentPhase
+	if currentRootPhase != nil && currentRootPhase.CurrentState() != value.StateFinish {
+		displayPhase = currentRootPhase
+	}
+
+	// すべてのフェーズの条件を取得
+	var allConditions []ConditionInfo
+	for _, phase := range allPhases {
+		conditions := h.getConditionInfos(phase)
+		allConditions = append(allConditions, conditions...)
+	}
+
+	// レスポンスを構築
+	response := struct {
+		Type         string          `json:"type"`
+		Phases       []PhaseDTO      `json:"phases"`
+		CurrentPhase *PhaseDTO       `json:"current_phase,omitempty"`
+		State        string          `json:"state"`
+		Info         *GameStateInfo  `json:"info,omitempty"`
+		Message      string          `json:"message,omitempty"`
+		Conditions   []ConditionInfo `json:"conditions"`
+	}{
+		Type:       "state_change",
+		Phases:     phaseDTOs,
+		State:      displayPhase.CurrentState(),
+		Info:       newGameStateInfo(displayPhase),
+		Message:    fmt.Sprintf("order: %v, message: %v", displayPhase.Order, newGameStateInfo(displayPhase).Message),
+		Conditions: allConditions,
+	}
+
+	// 現在のフェーズをDTOに変換
+	currentDTO := ConvertPhaseToDTO(displayPhase)
+	response.CurrentPhase = &currentDTO
+
+	h.broadcastUpdate(response)
+}
+
+// getConditionInfos は条件情報を取得する
+func (h *sessionHub) getConditionInfos(phase *entity.Phase) []ConditionInfo {
+	conditions := make([]ConditionInfo, 0)
+	for _, condition := range phase.GetConditions() {
+		condInfo := ConditionInfo{
+			ID:            condition.ID,
+			Label:         condition.Label,
+			State:         condition.CurrentState(),
+			Kind:          condition.Kind,
+			Combination:   condition.Combination,
+			RequiredParts: condition.RequiredPartCount(),
+			Ordered:       condition.Ordered,
+			IsClear:       condition.IsClear,
+			Description:   condition.Description,
+			PhaseID:       phase.ID,
+			PhaseName:     phase.Name,
+			Parts:         make([]ConditionPartInfo, 0),
+		}
+		for _, part := range condition.GetParts() {
+			partInfo := ConditionPartInfo{
+				ID:                   part.ID,
+				Label:                part.Label,
+				State:                part.CurrentState(),
+				ComparisonOperator:   part.ComparisonOperator,
+				IsClear:              part.IsClear,
+				Paused:               part.IsPaused(),
+				Held:                 part.IsHeld(),
+				Live:                 part.Live,
+				TargetEntityType:     part.TargetEntityType,
+				TargetEntityID:       part.TargetEntityID,
+				ReferenceValueInt:    part.ReferenceValueInt,
+				ReferenceValueFloat:  part.ReferenceValueFloat,
+				ReferenceValueString: part.ReferenceValueString,
+				ReferenceValueSet:    part.GetReferenceValueSet(),
+				ReferenceStringSet:   part.GetReferenceStringSet(),
+				MinValue:             part.MinValue,
+				MaxValue:             part.MaxValue,
+				MinValueFloat:        part.MinValueFloat,
+				MaxValueFloat:        part.MaxValueFloat,
+				DurationSeconds:      part.DurationSeconds,
+				OnMiss:               part.OnMiss,
+				Priority:             part.Priority,
+				DeadlineSeconds:      part.DeadlineSeconds,
+				DeadlineRemainingMs:  part.DeadlineRemaining().Milliseconds(),
+				CurrentValue:         part.GetCurrentValue(),
+			}
+			condInfo.Parts = append(condInfo.Parts, partInfo)
+		}
+		conditions = append(conditions, condInfo)
+	}
+	return conditions
+}
+
+func (h *sessionHub) OnError(err error) {
+	update := struct {
+		Type  string `json:"type"`
+		Error string `json:"error"`
+	}{
+		Type:  "error",
+		Error: err.Error(),
+	}
+	h.broadcastUpdate(update)
+}
+
+func (h *sessionHub) broadcastUpdate(update interface{}) {
+	log := logger.DefaultLogger()
+	log.Debug("Queueing update for broadcast", zap.Any("update", update))
+
+	// 更新メッセージをチャネルに送信（非ブロッキング）
+	select {
+	case h.updateChan <- update:
+		// メッセージが正常にキューに入った
+	default:
+		// チャネルがいっぱいの場合
+		log.Warn("Update channel is full, dropping message")
+	}
+}
@@ -1,6 +1,8 @@
 // 状態管理クラス
 class StateManager {
     constructor() {
+        // ?session=<id> が指定されていればそのセッションを操作する
+        this.sessionId = new URLSearchParams(window.location.search).get('session');
         this.connect();
         this.setupEventListeners();
         this.currentState = 'ready';
@@ -8,10 +10,15 @@ class StateManager {
         this.fetchInitialState(); // 初期状態を取得
     }
 
+    // セッションに対応するAPIのベースパスを返す
+    apiBase() {
+        return this.sessionId ? `/api/sessions/${encodeURIComponent(this.sessionId)}` : '/api';
+    }
+
     // 初期状態を取得するメソッド
     async fetchInitialState() {
         try {
-            const response = await fetch('/api/initial-state');
+            const response = await fetch(`${this.apiBase()}/initial-state`);
             if (!response.ok) {
                 throw new Error(`HTTP error! status: ${response.status}`);
             }
@@ -26,7 +33,7 @@ class StateManager {
 
     connect() {
         const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-        this.ws = new WebSocket(`${protocol}//${window.location.host}/ws`);
+        this.ws = new WebSocket(`${protocol}//${window.location.host}${this.sessionId ? this.apiBase() : ''}/ws`);
 
         this.ws.onopen = () => {
             console.log('WebSocket: 接続確立');
@@ -82,6 +89,11 @@ class StateManager {
             <button id="start-auto" class="control-btn">自動遷移開始</button>
             <button id="stop-auto" class="control-btn" disabled>自動遷移停止</button>
             <button id="reset-btn" class="control-btn">リセット</button>
+            <button id="pause-btn" class="control-btn" disabled>一時停止</button>
+            <button id="resume-btn" class="control-btn" disabled>再開</button>
+            <button id="abort-btn" class="control-btn" disabled>中断</button>
+            <button id="skip-btn" class="control-btn" disabled>スキップ</button>
+            <button id="rewind-btn" class="control-btn" disabled>前のフェーズへ戻る</button>
             <div id="next-transition" class="transition-info"></div>
             <div id="state-message" class="state-message"></div>
         `;
@@ -101,17 +113,63 @@ class StateManager {
             console.log('リセットリクエスト');
             this.controlAutoTransition('reset');
         });
+
+        document.getElementById('pause-btn').addEventListener('click', () => {
+            console.log('一時停止リクエスト');
+            this.controlAutoTransition('pause');
+        });
+
+        document.getElementById('resume-btn').addEventListener('click', () => {
+            console.log('再開リクエスト');
+            this.controlAutoTransition('resume');
+        });
+
+        document.getElementById('abort-btn').addEventListener('click', () => {
+            console.log('中断リクエスト');
+            this.controlAutoTransition('abort');
+        });
+
+        document.getElementById('skip-btn').addEventListener('click', () => {
+            console.log('スキップリクエスト');
+            this.controlAutoTransition('skip');
+        });
+
+        document.getElementById('rewind-btn').addEventListener('click', () => {
+            console.log('巻き戻しリクエスト');
+            this.controlAutoTransition('rewind');
+        });
     }
 
-    async controlAutoTransition(action) {
+    // phaseIdはjumpで移るフェーズのIDで、それ以外の操作では省略します
+    async controlAutoTransition(action, phaseId) {
         console.log(`自動遷移API呼び出し: ${action}`);
+        const query = phaseId ? `action=${action}&phase_id=${phaseId}` : `action=${action}`;
         try {
-            const response = await fetch(`/api/auto-transition?action=${action}`, {
+            const response = await fetch(`${this.apiBase()}/auto-transition?${query}`, {
                 method: 'POST'
             });
 
             if (response.ok) {
                 console.log(`自動遷移${action}成功`);
+                // 一時停止・再開は自動遷移の開始・停止とは別に扱う
+                if (action === 'pause' || action === 'resume') {
+                    this.showStatus(action === 'pause' ? '一時停止しました' : '再開しました', 'success');
+                    return;
+                }
+                if (action === 'abort') {
+                    this.showStatus('現在のフェーズを中断しました', 'success');
+                    return;
+                }
+                // オペレーター操作は自動遷移の状態を変えない
+                const operatorMessages = {
+                    'skip': '現在のフェーズをスキップしました',
+                    'jump': `フェーズ ${phaseId} に移りました`,
+                    'rewind': '前のフェーズに戻りました'
+                };
+                if (operatorMessages[action]) {
+                    this.showStatus(operatorMessages[action], 'success');
+                    return;
+                }
                 this.showStatus(`自動遷移${action === 'start' ? '開始' : '停止'}`, 'success');
                 this.updateAutoTransitionStatus(action === 'start');
             } else {
@@ -127,7 +185,7 @@ class StateManager {
 
     async handleCounterIncrement(conditionId, partId, increment = 1) {
         try {
-            const response = await fetch(`/api/condition/${conditionId}/part/${partId}/evaluate`, {
+            const response = await fetch(`${this.apiBase()}/condition/${conditionId}/part/${partId}/evaluate`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
@@ -149,6 +207,30 @@ class StateManager {
         }
     }
 
+    async handleValueInput(conditionId, partId, value) {
+        try {
+            const response = await fetch(`${this.apiBase()}/condition/${conditionId}/part/${partId}/evaluate`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({ value })
+            });
+
+            if (!response.ok) {
+                throw new Error(`HTTP error! status: ${response.status}`);
+            }
+
+            const result = await response.json();
+            this.showStatus(`入力値更新: ${result.current_value}`, 'success');
+            return result;
+        } catch (error) {
+            console.error('入力値更新エラー:', error);
+            this.showStatus(`入力値更新エラー: ${error.message}`, 'error');
+            throw error;
+        }
+    }
+
     handleStateUpdate(data) {
         console.log('状態更新データ受信:', data);
         if (data.type === 'error') {
@@ -207,15 +289,47 @@ class StateManager {
             const phaseDetails = document.createElement('div');
             phaseDetails.className = 'phase-item-details';
             phaseDetails.textContent = `親ID: ${phase.parent_id}, 順序: ${phase.order}, 子あり: ${phase.has_children}`;
+            // 条件を持つフェーズは達成した条件数と必要な条件数を表示する
+            if (phase.completion) {
+                phaseDetails.textContent += `, 完了条件: ${phase.completion}`;
+            } else if (phase.required_conditions > 0) {
+                phaseDetails.textContent += `, 条件: ${phase.satisfied_conditions}/${phase.required_conditions}`;
+            }
+            if (phase.failed_conditions > 0) {
+                phaseDetails.textContent += `, 失敗: ${phase.failed_conditions}`;
+            }
+            if (phase.outcome) {
+                phaseDetails.textContent += `, 結果: ${this.formatOutcome(phase.outcome)}`;
+            }
+            if (phase.transitions) {
+                phaseDetails.textContent += `, 遷移: ${phase.transitions.join(' / ')}`;
+            }
+            if (phase.iteration && (phase.repeat_count || phase.iteration > 1)) {
+                const limit = phase.repeat_count ? `/${phase.repeat_count}` : '';
+                phaseDetails.textContent += `, 繰り返し: ${phase.iteration}${limit}回目`;
+            }
+            if (phase.parallel) {
+                phaseDetails.textContent += `, 並列: ${phase.parallel === 'any' ? 'いずれかの領域で完了' : '全領域で完了'}`;
+            }
             
             const phaseState = document.createElement('div');
             phaseState.className = `phase-item-state state-${phase.state}`;
             phaseState.textContent = phase.state;
             
+            // 任意のフェーズに移るボタン
+            const jumpButton = document.createElement('button');
+            jumpButton.className = 'control-btn jump-btn';
+            jumpButton.textContent = 'ここへ移る';
+            jumpButton.addEventListener('click', () => {
+                console.log('ジャンプリクエスト:', phase.id);
+                this.controlAutoTransition('jump', phase.id);
+            });
+            
             phaseInfo.appendChild(phaseName);
             phaseInfo.appendChild(phaseDetails);
             phaseElement.appendChild(phaseInfo);
             phaseElement.appendChild(phaseState);
+            phaseElement.appendChild(jumpButton);
             
             allPhasesList.appendChild(phaseElement);
         });
@@ -348,7 +462,7 @@ class StateManager {
             
             const label = document.createElement('div');
             label.className = 'condition-label';
-            label.textContent = `${condition.label} (Kind: ${condition.kind}, Clear: ${condition.is_clear})`;
+            label.textContent = `${condition.label} (Kind: ${condition.kind}, ${this.formatCombination(condition)}${condition.ordered ? ', 順序あり' : ''}, Clear: ${condition.is_clear})`;
             
             const state = document.createElement('div');
             state.className = `condition-state state-${condition.state}`;
@@ -384,36 +498,47 @@ class StateManager {
 
                     // 基本情報の表示
                     partBasic.innerHTML = `
-                        <strong>${part.label}</strong> (Clear: ${part.is_clear})<br>
+                        <strong>${part.label}</strong> (Clear: ${part.is_clear})${part.paused ? ' <span class="paused-badge">一時停止中</span>' : ''}${part.held ? ' <span class="paused-badge">順序待ち</span>' : ''}${part.live ? ' <span class="paused-badge">ライブ</span>' : ''}${this.formatDeadline(part)}<br>
                         State: <span class="state-${part.state}">${part.state}</span><br>
                         Operator: ${part.comparison_operator}
                     `;
 
-                    // カウンター条件の場合、特別なUIを追加
-                    if (condition.kind === 2) { // KindCounter = 2
+                    // カウンター条件・持続条件・時間窓条件の場合、特別なUIを追加
+                    if (condition.kind === 2 || condition.kind === 5 || condition.kind === 7) { // KindCounter = 2, KindSustained = 5, KindWindow = 7
+                        const sustained = condition.kind === 5;
+                        const windowed = condition.kind === 7;
                         const counterControls = document.createElement('div');
                         counterControls.className = 'counter-controls';
                         // サーバーから取得した現在値を表示するように修正
-                        const currentValue = part.current_value !== undefined ? part.current_value : 0;
+                        // 持続条件の現在値は満了までの残り保持時間(ミリ秒)、時間窓条件の現在値は窓内の入力回数
+                        const currentValue = this.formatCounterValue(part.current_value, condition.kind);
+                        // In / NotIn の場合は受け付ける値の集合を目標値として表示する
+                        const targetValue = this.formatTargetValue(part, condition.kind);
                         counterControls.innerHTML = `
                             <div class="counter-value">
-                                現在値: <span class="current-value">${currentValue}</span> /
-                                目標値: <span class="target-value">${part.reference_value_int}</span>
+                                ${sustained ? '残り保持時間' : '現在値'}: <span class="current-value">${currentValue}</span> /
+                                目標値: <span class="target-value">${targetValue}</span>${sustained ? ` (${part.duration_seconds}秒間)` : ''}${windowed ? ` (直近${part.duration_seconds}秒)` : ''}
                             </div>
                             <button class="increment-btn" data-condition-id="${condition.id}" data-part-id="${part.id}">
                                 カウントアップ
                             </button>
+                            ${part.live || sustained ? `<button class="decrement-btn" data-condition-id="${condition.id}" data-part-id="${part.id}">
+                                カウントダウン
+                            </button>` : ''}
                         `;
 
                         // カウントアップボタンのイベントリスナーを追加
                         const incrementBtn = counterControls.querySelector('.increment-btn');
+                        // 一時停止中はカウンターへの入力を受け付けない
+                        incrementBtn.disabled = part.paused || part.state === 'failed';
                         incrementBtn.addEventListener('click', async () => {
                             try {
                                 const result = await this.handleCounterIncrement(condition.id, part.id);
                                 const currentValueSpan = counterControls.querySelector('.current-value');
-                                currentValueSpan.textContent = result.current_value;
+                                currentValueSpan.textContent = this.formatCounterValue(result.current_value, condition.kind);
                                 
-                                if (result.is_satisfied) {
+                                // ライブのパーツは満たされた後も値を変えられる
+                                if (result.is_satisfied && !part.live) {
                                     incrementBtn.disabled = true;
                                     this.showStatus('条件を満たしました！', 'success');
                                 }
@@ -422,7 +547,61 @@ class StateManager {
                             }
                         });
 
+                        // ライブのパーツは値を減らして未達成に戻せる(持続条件は値を減らして保持を中断できる)
+                        const decrementBtn = counterControls.querySelector('.decrement-btn');
+                        if (decrementBtn) {
+                            decrementBtn.disabled = part.paused;
+                            decrementBtn.addEventListener('click', async () => {
+                                try {
+                                    const result = await this.handleCounterIncrement(condition.id, part.id, -1);
+                                    counterControls.querySelector('.current-value').textContent = this.formatCounterValue(result.current_value, condition.kind);
+                                } catch (error) {
+                                    console.error('カウンター更新エラー:', error);
+                                }
+                            });
+                        }
+
                         partBasic.appendChild(counterControls);
+                    } else if (condition.kind === 3 || condition.kind === 4 || condition.kind === 6) { // KindFloat = 3, KindString = 4, KindSequence = 6
+                        const valueControls = document.createElement('div');
+                        valueControls.className = 'counter-controls';
+                        const currentValue = this.formatInputValue(part, part.current_value, condition.kind);
+                        const targetValue = this.formatTargetValue(part, condition.kind);
+                        const inputType = condition.kind === 4 ? 'text' : 'number';
+                        valueControls.innerHTML = `
+                            <div class="counter-value">
+                                現在値: <span class="current-value">${currentValue}</span> /
+                                目標値: <span class="target-value">${targetValue}</span>
+                            </div>
+                            <input class="value-input" type="${inputType}" step="any">
+                            <button class="submit-value-btn">入力</button>
+                        `;
+
+                        const valueInput = valueControls.querySelector('.value-input');
+                        const submitBtn = valueControls.querySelector('.submit-value-btn');
+                        // 一時停止中は入力を受け付けない
+                        submitBtn.disabled = part.paused;
+                        submitBtn.addEventListener('click', async () => {
+                            // 小数条件・順序入力条件は数値として、文字列条件はそのまま送信する
+                            const value = condition.kind === 4 ? valueInput.value : parseFloat(valueInput.value);
+                            if (condition.kind !== 4 && Number.isNaN(value)) {
+                                this.showStatus('数値を入力してください', 'error');
+                                return;
+                            }
+                            try {
+                                const result = await this.handleValueInput(condition.id, part.id, value);
+                                valueControls.querySelector('.current-value').textContent = this.formatInputValue(part, result.current_value, condition.kind);
+
+                                if (result.is_satisfied) {
+                                    submitBtn.disabled = true;
+                                    this.showStatus('条件を満たしました！', 'success');
+                                }
+                            } catch (error) {
+                                console.error('入力値更新エラー:', error);
+                            }
+                        });
+
+                        partBasic.appendChild(valueControls);
                     }
                     
                     const partDetails = document.createElement('div');
@@ -432,7 +611,9 @@ class StateManager {
                         Values: Int=${part.reference_value_int},
                                Float=${part.reference_value_float},
                                String="${part.reference_value_string}"<br>
-                        Range: ${part.min_value} - ${part.max_value}<br>
+                        Range: ${part.min_value} - ${part.max_value} (Float: ${part.min_value_float} - ${part.max_value_float})<br>
+                        Set: ${part.reference_value_set ? part.reference_value_set.join(', ') : '-'}<br>
+                        String Set: ${part.reference_string_set ? part.reference_string_set.join(', ') : '-'}<br>
                         Priority: ${part.priority}
                     `;
                     
@@ -449,6 +630,73 @@ class StateManager {
         });
     }
 
+    // 条件内のパーツの組み合わせ方の表示を返す
+    formatCombination(condition) {
+        const total = condition.parts ? condition.parts.length : 0;
+        switch (condition.combination) {
+            case 1: // PartCombinationAny
+                return 'いずれか';
+            case 2: // PartCombinationAtLeast
+                return `${condition.required_parts}/${total} 以上`;
+            default:
+                return 'すべて';
+        }
+    }
+
+    // 期限のあるパーツの残り時間のバッジを返す
+    formatDeadline(part) {
+        if (!part.deadline_seconds) {
+            return '';
+        }
+        if (part.state === 'failed') {
+            return ' <span class="paused-badge failed-badge">期限切れ</span>';
+        }
+        if (part.state === 'satisfied' || !part.deadline_remaining_ms) {
+            return ` <span class="paused-badge">期限 ${part.deadline_seconds}秒</span>`;
+        }
+        return ` <span class="paused-badge">残り ${(part.deadline_remaining_ms / 1000).toFixed(1)}秒</span>`;
+    }
+
+    // 条件の種類に応じたカウンターの現在値の表示を返す
+    formatCounterValue(currentValue, kind) {
+        const current = currentValue !== undefined ? currentValue : 0;
+        if (kind === 5) { // KindSustained
+            return `${(current / 1000).toFixed(1)}秒`;
+        }
+        return current;
+    }
+
+    // 条件の種類に応じた入力条件の現在値の表示を返す
+    formatInputValue(part, currentValue, kind) {
+        if (kind === 6) { // KindSequence: 正しく入力した手順の数
+            const total = part.reference_value_set ? part.reference_value_set.length : 0;
+            return `${currentValue || 0}/${total}`;
+        }
+        return currentValue !== undefined ? currentValue : '';
+    }
+
+    // 比較演算子と条件の種類に応じた目標値の表示を返す
+    formatTargetValue(part, kind) {
+        if (kind === 6) { // KindSequence: 入力すべき順番
+            return (part.reference_value_set || []).join(' → ');
+        }
+        const values = kind === 4 ? part.reference_string_set : part.reference_value_set;
+        const set = (values || []).join(', ');
+        switch (part.comparison_operator) {
+            case 7: // ComparisonOperatorBetween
+                if (kind === 3) return `${part.min_value_float} - ${part.max_value_float}`; // KindFloat
+                return `${part.min_value} - ${part.max_value}`;
+            case 8: // ComparisonOperatorIn
+                return `{${set}} のいずれか`;
+            case 9: // ComparisonOperatorNotIn
+                return `{${set}} 以外`;
+            default:
+                if (kind === 3) return part.reference_value_float; // KindFloat
+                if (kind === 4) return `"${part.reference_value_string}"`; // KindString
+                return part.reference_value_int;
+        }
+    }
+
     updateAutoTransitionStatus(isRunning) {
         console.log('自動遷移状態更新:', isRunning);
         const startBtn = document.getElementById('start-auto');
@@ -581,8 +829,10 @@ class StateManager {
         console.log('可能な遷移をハイライト:', state);
         const transitionMap = {
             'ready': ['activate'],
-            'active': ['next'],
-            'next': ['activate', 'finish']
+            'active': ['next', 'pause'],
+            'paused': ['resume'],
+            'next': ['activate', 'finish'],
+            'failed': ['reset']
         };
 
         const possibleTransitions = transitionMap[state] || [];
@@ -600,7 +850,12 @@ class StateManager {
         const buttons = {
             'activate-btn': state === 'ready',
             'next-btn': state === 'active',
-            'finish-btn': state === 'next'
+            'finish-btn': state === 'next',
+            'pause-btn': state === 'active' || state === 'next',
+            'resume-btn': state === 'paused',
+            'abort-btn': state === 'active' || state === 'paused',
+            'skip-btn': state === 'active',
+            'rewind-btn': state === 'active' || state === 'next' || state === 'failed'
         };
 
         Object.entries(buttons).forEach(([id, enabled]) => {
@@ -609,6 +864,16 @@ class StateManager {
         });
     }
 
+    formatOutcome(outcome) {
+        const labels = {
+            'succeeded': '完了',
+            'failed': '失敗',
+            'aborted': '中断',
+            'skipped': 'スキップ'
+        };
+        return labels[outcome] || outcome;
+    }
+
     showStatus(message, type) {
         console.log('ステータス表示:', { message, type });
         const statusElement = document.getElementById('status-message');
@@ -0,0 +1,176 @@
+package scenario
+
+import (
+	"fmt"
+	"state_sample/internal/domain/value"
+	"strings"
+)
+
+// シナリオファイル上の名前と値オブジェクトの対応表
+// 空文字は未指定として扱います
+var (
+	conditionTypeNames = map[string]value.ConditionType{
+		"":          value.ConditionTypeUnspecified,
+		"and":       value.ConditionTypeAnd,
+		"or":        value.ConditionTypeOr,
+		"threshold": value.ConditionTypeThreshold,
+	}
+
+	gameRuleNames = map[string]value.GameRule{
+		"":            value.GameRule_Shooting,
+		"shooting":    value.GameRule_Shooting,
+		"push_switch": value.GameRule_PushSwitch,
+		"animation":   value.GameRule_Animation,
+	}
+
+	conditionKindNames = map[string]value.ConditionKind{
+		"":          value.KindUnspecified,
+		"time":      value.KindTime,
+		"counter":   value.KindCounter,
+		"float":     value.KindFloat,
+		"string":    value.KindString,
+		"sustained": value.KindSustained,
+		"sequence":  value.KindSequence,
+		"window":    value.KindWindow,
+	}
+
+	exprOperatorNames = map[string]value.ExprOperator{
+		"":          value.ExprCondition,
+		"condition": value.ExprCondition,
+		"and":       value.ExprAnd,
+		"or":        value.ExprOr,
+		"not":       value.ExprNot,
+		"threshold": value.ExprThreshold,
+	}
+
+	partCombinationNames = map[string]value.PartCombination{
+		"":         value.PartCombinationAll,
+		"all":      value.PartCombinationAll,
+		"any":      value.PartCombinationAny,
+		"at_least": value.PartCombinationAtLeast,
+	}
+
+	sequenceMissPolicyNames = map[string]value.SequenceMissPolicy{
+		"":      value.SequenceMissReset,
+		"reset": value.SequenceMissReset,
+		"stay":  value.SequenceMissStay,
+	}
+
+	failureActionNames = map[string]value.FailureAction{
+		"":      value.FailureEnd,
+		"end":   value.FailureEnd,
+		"retry": value.FailureRetry,
+		"goto":  value.FailureGoto,
+	}
+
+	parallelCompletionNames = map[string]value.ParallelCompletion{
+		"":    value.ParallelAll,
+		"all": value.ParallelAll,
+		"any": value.ParallelAny,
+	}
+
+	phaseOutcomeNames = map[string]value.PhaseOutcome{
+		"":          value.OutcomeNone,
+		"succeeded": value.OutcomeSucceeded,
+		"failed":    value.OutcomeFailed,
+		"aborted":   value.OutcomeAborted,
+		"skipped":   value.OutcomeSkipped,
+	}
+
+	comparisonOperatorNames = map[string]value.ComparisonOperator{
+		"":        value.ComparisonOperatorUnspecified,
+		"eq":      value.ComparisonOperatorEQ,
+		"neq":     value.ComparisonOperatorNEQ,
+		"gt":      value.ComparisonOperatorGT,
+		"gte":     value.ComparisonOperatorGTE,
+		"lt":      value.ComparisonOperatorLT,
+		"lte":     value.ComparisonOperatorLTE,
+		"between": value.ComparisonOperatorBetween,
+		"in":      value.ComparisonOperatorIn,
+		"not_in":  value.ComparisonOperatorNotIn,
+	}
+)
+
+// parseConditionType は文字列をConditionTypeに変換します
+func parseConditionType(name string) (value.ConditionType, error) {
+	if v, ok := conditionTypeNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.ConditionTypeUnspecified, fmt.Errorf("unknown condition type: %q", name)
+}
+
+// parseGameRule は文字列をGameRuleに変換します
+func parseGameRule(name string) (value.GameRule, error) {
+	if v, ok := gameRuleNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.GameRule_Shooting, fmt.Errorf("unknown game rule: %q", name)
+}
+
+// parseConditionKind は文字列をConditionKindに変換します
+func parseConditionKind(name string) (value.ConditionKind, error) {
+	if v, ok := conditionKindNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.KindUnspecified, fmt.Errorf("unknown condition kind: %q", name)
+}
+
+// parseExprOperator は文字列をExprOperatorに変換します
+func parseExprOperator(name string) (value.ExprOperator, error) {
+	if v, ok := exprOperatorNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.ExprCondition, fmt.Errorf("unknown expression operator: %q", name)
+}
+
+// parsePartCombination は文字列をPartCombinationに変換します
+func parsePartCombination(name string) (value.PartCombination, error) {
+	if v, ok := partCombinationNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.PartCombinationAll, fmt.Errorf("unknown part combination: %q", name)
+}
+
+// parseSequenceMissPolicy は文字列をSequenceMissPolicyに変換します
+func parseSequenceMissPolicy(name string) (value.SequenceMissPolicy, error) {
+	if v, ok := sequenceMissPolicyNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.SequenceMissReset, fmt.Errorf("unknown sequence miss policy: %q", name)
+}
+
+// parseFailureAction は文字列をFailureActionに変換します
+func parseFailureAction(name string) (value.FailureAction, error) {
+	if v, ok := failureActionNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.FailureEnd, fmt.Errorf("unknown failure action: %q", name)
+}
+
+// parseParallelCompletion は文字列をParallelCompletionに変換します
+func parseParallelCompletion(name string) (value.ParallelCompletion, error) {
+	if v, ok := parallelCompletionNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.ParallelAll, fmt.Errorf("unknown parallel completion: %q", name)
+}
+
+// parsePhaseOutcome は文字列をPhaseOutcomeに変換します
+func parsePhaseOutcome(name string) (value.PhaseOutcome, error) {
+	if v, ok := phaseOutcomeNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.OutcomeNone, fmt.Errorf("unknown phase outcome: %q", name)
+}
+
+// parseComparisonOperator は文字列をComparisonOperatorに変換します
+func parseComparisonOperator(name string) (value.ComparisonOperator, error) {
+	if v, ok := comparisonOperatorNames[normalize(name)]; ok {
+		return v, nil
+	}
+	return value.ComparisonOperatorUnspecified, fmt.Errorf("unknown comparison operator: %q", name)
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,65 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format はシナリオファイルの形式を表す型です
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// LoadFile はシナリオファイルを読み込みます
+// 形式は拡張子(.yaml / .yml / .json)から判定します
+func LoadFile(path string) (*Scenario, error) {
+	format, err := formatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+
+	return Parse(data, format)
+}
+
+// Parse はバイト列をシナリオ定義に変換します
+func Parse(data []byte, format Format) (*Scenario, error) {
+	var s Scenario
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse yaml scenario: %w", err)
+		}
+	case FormatJSON:
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse json scenario: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scenario format: %q", format)
+	}
+	return &s, nil
+}
+
+// formatFromPath は拡張子からファイル形式を判定します
+func formatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported scenario file extension: %q", filepath.Ext(path))
+	}
+}
@@ -0,0 +1,272 @@
+package scenario
+
+import (
+	"fmt"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+)
+
+// Scenario はフェーズツリー全体を宣言的に記述したシナリオ定義です
+// YAML / JSON いずれの形式でも同じ構造で記述できます
+type Scenario struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description" yaml:"description"`
+	Phases      []PhaseDef `json:"phases" yaml:"phases"`
+}
+
+// PhaseDef はフェーズの定義です
+type PhaseDef struct {
+	ID                             value.PhaseID   `json:"id" yaml:"id"`
+	Name                           string          `json:"name" yaml:"name"`
+	Description                    string          `json:"description" yaml:"description"`
+	Order                          int             `json:"order" yaml:"order"`
+	ParentID                       value.PhaseID   `json:"parent_id" yaml:"parent_id"`
+	ConditionType                  string          `json:"condition_type" yaml:"condition_type"`
+	RequiredConditions             int             `json:"required_conditions" yaml:"required_conditions"` // thresholdで満たす必要のある条件数
+	Completion                     *ExprDef        `json:"completion" yaml:"completion"`                   // 完了条件式(condition_typeより優先)
+	Rule                           string          `json:"rule" yaml:"rule"`
+	AutoProgressOnChildrenComplete bool            `json:"auto_progress_on_children_complete" yaml:"auto_progress_on_children_complete"`
+	RepeatCount                    int             `json:"repeat_count" yaml:"repeat_count"`               // 繰り返す回数の上限(省略時は回数では終えない)
+	RepeatUntil                    *ExprDef        `json:"repeat_until" yaml:"repeat_until"`               // 完了時に真であれば繰り返しを終える式
+	Parallel                       bool            `json:"parallel" yaml:"parallel"`                       // 子フェーズを並列領域として同時に実行するかどうか
+	ParallelCompletion             string          `json:"parallel_completion" yaml:"parallel_completion"` // all / any(省略時はall)
+	OnFailure                      string          `json:"on_failure" yaml:"on_failure"`                   // 失敗した場合の遷移先: end / retry / goto(省略時はend)
+	OnFailurePhaseID               value.PhaseID   `json:"on_failure_phase_id" yaml:"on_failure_phase_id"` // gotoで移る兄弟フェーズのID
+	MaxRetries                     int             `json:"max_retries" yaml:"max_retries"`                 // retryでやり直す回数の上限(省略時は上限なし)
+	Transitions                    []TransitionDef `json:"transitions" yaml:"transitions"`                 // 終わった後に移るフェーズ(省略時はorderの次)
+	Conditions                     []ConditionDef  `json:"conditions" yaml:"conditions"`
+}
+
+// TransitionDef はフェーズの遷移の辺の定義です
+type TransitionDef struct {
+	ConditionID value.ConditionID `json:"condition_id" yaml:"condition_id"` // この条件で完了した場合に従う(省略時はどの条件でもよい)
+	Outcome     string            `json:"outcome" yaml:"outcome"`           // succeeded / failed / aborted / skipped(省略時はsucceeded)
+	To          value.PhaseID     `json:"to" yaml:"to"`                     // 移り先の兄弟フェーズのID(省略時は兄弟フェーズに移らずに終える)
+}
+
+// ExprDef はフェーズの完了条件式のノードの定義です
+type ExprDef struct {
+	Op          string            `json:"op" yaml:"op"` // condition / and / or / not / threshold(省略時はcondition)
+	ConditionID value.ConditionID `json:"condition_id" yaml:"condition_id"`
+	Required    int               `json:"required" yaml:"required"` // thresholdで真である必要のあるオペランドの数
+	Operands    []ExprDef         `json:"operands" yaml:"operands"`
+}
+
+// ConditionDef は条件の定義です
+type ConditionDef struct {
+	ID            value.ConditionID `json:"id" yaml:"id"`
+	Label         string            `json:"label" yaml:"label"`
+	Name          string            `json:"name" yaml:"name"`
+	Description   string            `json:"description" yaml:"description"`
+	Kind          string            `json:"kind" yaml:"kind"`
+	Combination   string            `json:"combination" yaml:"combination"`       // all / any / at_least(省略時はall)
+	RequiredParts int               `json:"required_parts" yaml:"required_parts"` // at_leastで満たす必要のあるパーツ数
+	Ordered       bool              `json:"ordered" yaml:"ordered"`               // trueのときパーツをpriorityの順に満たす
+	Parts         []PartDef         `json:"parts" yaml:"parts"`
+}
+
+// PartDef は条件パーツの定義です
+type PartDef struct {
+	ID                   value.ConditionPartID `json:"id" yaml:"id"`
+	Label                string                `json:"label" yaml:"label"`
+	ComparisonOperator   string                `json:"comparison_operator" yaml:"comparison_operator"`
+	TargetEntityType     string                `json:"target_entity_type" yaml:"target_entity_type"`
+	TargetEntityID       int64                 `json:"target_entity_id" yaml:"target_entity_id"`
+	ReferenceValueInt    int64                 `json:"reference_value_int" yaml:"reference_value_int"`
+	ReferenceValueFloat  float64               `json:"reference_value_float" yaml:"reference_value_float"`
+	ReferenceValueString string                `json:"reference_value_string" yaml:"reference_value_string"`
+	ReferenceValueSet    []int64               `json:"reference_value_set" yaml:"reference_value_set"`
+	ReferenceStringSet   []string              `json:"reference_string_set" yaml:"reference_string_set"`
+	MinValue             int64                 `json:"min_value" yaml:"min_value"`
+	MaxValue             int64                 `json:"max_value" yaml:"max_value"`
+	MinValueFloat        float64               `json:"min_value_float" yaml:"min_value_float"`   // 小数条件のbetweenの下限
+	MaxValueFloat        float64               `json:"max_value_float" yaml:"max_value_float"`   // 小数条件のbetweenの上限
+	DurationSeconds      int64                 `json:"duration_seconds" yaml:"duration_seconds"` // 持続条件で比較が成り立ち続ける秒数、時間窓条件で入力を数える秒数
+	OnMiss               string                `json:"on_miss" yaml:"on_miss"`                   // 順序入力条件で誤った入力があった場合の扱い: reset / stay(省略時はreset)
+	Priority             int32                 `json:"priority" yaml:"priority"`
+	Live                 bool                  `json:"live" yaml:"live"`                         // trueのとき値が基準を外れると未達成に戻る
+	DeadlineSeconds      int64                 `json:"deadline_seconds" yaml:"deadline_seconds"` // 満たす必要のある期限の秒数(省略時は期限なし)
+}
+
+// Build はシナリオ定義からフェーズを生成し、オブザーバーと戦略を接続します
+// 構造上の問題がある場合はentity.ValidationErrorsを返します
+// 返されるPhasesはそのままNewPhaseControllerに渡すことができます
+func (s *Scenario) Build(factory service.StrategyFactory) (entity.Phases, error) {
+	if len(s.Phases) == 0 {
+		return nil, fmt.Errorf("scenario has no phases")
+	}
+
+	phases := make(entity.Phases, 0, len(s.Phases))
+	for _, phaseDef := range s.Phases {
+		phase, err := phaseDef.build(factory)
+		if err != nil {
+			return nil, fmt.Errorf("phase %d (%s): %w", phaseDef.ID, phaseDef.Name, err)
+		}
+		phases = append(phases, phase)
+	}
+
+	if err := entity.ValidatePhases(phases); err != nil {
+		return nil, err
+	}
+
+	return phases, nil
+}
+
+// build はフェーズ定義からPhaseを生成します
+func (d PhaseDef) build(factory service.StrategyFactory) (*entity.Phase, error) {
+	conditionType, err := parseConditionType(d.ConditionType)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := parseGameRule(d.Rule)
+	if err != nil {
+		return nil, err
+	}
+	onFailure, err := parseFailureAction(d.OnFailure)
+	if err != nil {
+		return nil, err
+	}
+	parallelCompletion, err := parseParallelCompletion(d.ParallelCompletion)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]*entity.Condition, 0, len(d.Conditions))
+	for _, condDef := range d.Conditions {
+		cond, err := condDef.build(factory)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d (%s): %w", condDef.ID, condDef.Label, err)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	phase := entity.NewPhase(d.ID, d.Name, d.Order, conditions, conditionType, rule, d.ParentID, d.AutoProgressOnChildrenComplete)
+	phase.Description = d.Description
+	phase.RequiredConditions = d.RequiredConditions
+	phase.Parallel = d.Parallel
+	phase.ParallelCompletion = parallelCompletion
+	phase.OnFailure = onFailure
+	phase.OnFailurePhaseID = d.OnFailurePhaseID
+	phase.MaxRetries = d.MaxRetries
+	for _, transitionDef := range d.Transitions {
+		outcome, err := parsePhaseOutcome(transitionDef.Outcome)
+		if err != nil {
+			return nil, fmt.Errorf("transition: %w", err)
+		}
+		phase.Transitions = append(phase.Transitions, entity.PhaseTransition{
+			ConditionID: transitionDef.ConditionID,
+			Outcome:     outcome,
+			To:          transitionDef.To,
+		})
+	}
+	if d.Completion != nil {
+		expr, err := d.Completion.build()
+		if err != nil {
+			return nil, fmt.Errorf("completion: %w", err)
+		}
+		phase.Completion = expr
+	}
+	phase.RepeatCount = d.RepeatCount
+	if d.RepeatUntil != nil {
+		expr, err := d.RepeatUntil.build()
+		if err != nil {
+			return nil, fmt.Errorf("repeat_until: %w", err)
+		}
+		phase.RepeatUntil = expr
+	}
+
+	// 条件の変更をフェーズに通知する
+	for _, cond := range conditions {
+		cond.AddConditionObserver(phase)
+	}
+
+	return phase, nil
+}
+
+// build は完了式の定義からCompletionExprを生成します
+func (d ExprDef) build() (*entity.CompletionExpr, error) {
+	op, err := parseExprOperator(d.Op)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := &entity.CompletionExpr{Op: op, ConditionID: d.ConditionID, Required: d.Required}
+	for _, operand := range d.Operands {
+		child, err := operand.build()
+		if err != nil {
+			return nil, err
+		}
+		expr.Children = append(expr.Children, child)
+	}
+	return expr, nil
+}
+
+// build は条件定義からConditionを生成します
+func (d ConditionDef) build(factory service.StrategyFactory) (*entity.Condition, error) {
+	kind, err := parseConditionKind(d.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	combination, err := parsePartCombination(d.Combination)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := entity.NewCondition(d.ID, d.Label, kind)
+	cond.Name = d.Name
+	cond.Description = d.Description
+	cond.Combination = combination
+	cond.RequiredParts = d.RequiredParts
+	cond.Ordered = d.Ordered
+
+	for _, partDef := range d.Parts {
+		part, err := partDef.build()
+		if err != nil {
+			return nil, fmt.Errorf("part %d (%s): %w", partDef.ID, partDef.Label, err)
+		}
+		// AddPartでパーツの変更が条件に通知されるようになる
+		cond.AddPart(part)
+	}
+
+	if err := cond.InitializePartStrategies(factory); err != nil {
+		return nil, err
+	}
+
+	return cond, nil
+}
+
+// build はパーツ定義からConditionPartを生成します
+func (d PartDef) build() (*entity.ConditionPart, error) {
+	operator, err := parseComparisonOperator(d.ComparisonOperator)
+	if err != nil {
+		return nil, err
+	}
+
+	onMiss, err := parseSequenceMissPolicy(d.OnMiss)
+	if err != nil {
+		return nil, err
+	}
+
+	part := entity.NewConditionPart(d.ID, d.Label)
+	part.ComparisonOperator = operator
+	part.TargetEntityType = d.TargetEntityType
+	part.TargetEntityID = d.TargetEntityID
+	part.ReferenceValueInt = d.ReferenceValueInt
+	part.ReferenceValueFloat = d.ReferenceValueFloat
+	part.ReferenceValueString = d.ReferenceValueString
+	part.ReferenceValueSet = d.ReferenceValueSet
+	part.ReferenceStringSet = d.ReferenceStringSet
+	part.MinValue = d.MinValue
+	part.MaxValue = d.MaxValue
+	part.MinValueFloat = d.MinValueFloat
+	part.MaxValueFloat = d.MaxValueFloat
+	part.DurationSeconds = d.DurationSeconds
+	part.OnMiss = onMiss
+	part.Priority = d.Priority
+	part.Live = d.Live
+	part.DeadlineSeconds = d.DeadlineSeconds
+
+	return part, nil
+}
@@ -0,0 +1,560 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/usecase/strategy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testYAML = `
+name: test
+phases:
+  - id: 1
+    name: ROOT
+    order: 1
+    condition_type: and
+    rule: push_switch
+    conditions:
+      - id: 1
+        label: Counter_Condition
+        kind: counter
+        parts:
+          - id: 1
+            label: Counter_Part
+            comparison_operator: gte
+            reference_value_int: 2
+  - id: 2
+    name: CHILD
+    order: 1
+    parent_id: 1
+    condition_type: or
+    conditions:
+      - id: 2
+        label: Time_Condition
+        kind: time
+        parts:
+          - id: 2
+            label: Time_Part
+            reference_value_int: 5
+`
+
+const testJSON = `{
+  "name": "test",
+  "phases": [
+    {
+      "id": 1,
+      "name": "ROOT",
+      "order": 1,
+      "condition_type": "threshold",
+      "required_conditions": 2,
+      "conditions": [
+        {
+          "id": 1,
+          "label": "Counter_Condition",
+          "kind": "counter",
+          "ordered": true,
+          "parts": [
+            {"id": 1, "label": "Counter_Part", "priority": 2, "comparison_operator": "eq", "reference_value_int": 3},
+            {"id": 2, "label": "Set_Part", "priority": 1, "comparison_operator": "in", "reference_value_set": [1, 3]}
+          ]
+        },
+        {
+          "id": 2,
+          "label": "Float_Condition",
+          "kind": "float",
+          "combination": "any",
+          "parts": [
+            {"id": 3, "label": "Float_Part", "comparison_operator": "gte", "reference_value_float": 0.9, "live": true},
+            {"id": 5, "label": "Range_Part", "comparison_operator": "between", "min_value_float": 0.5, "max_value_float": 1.5}
+          ]
+        },
+        {
+          "id": 3,
+          "label": "String_Condition",
+          "kind": "string",
+          "parts": [
+            {"id": 4, "label": "String_Part", "comparison_operator": "in", "reference_string_set": ["open", "ajar"]}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		s, err := Parse([]byte(testYAML), FormatYAML)
+		require.NoError(t, err)
+		assert.Equal(t, "test", s.Name)
+		require.Len(t, s.Phases, 2)
+		assert.Equal(t, value.PhaseID(1), s.Phases[1].ParentID)
+		assert.Equal(t, "gte", s.Phases[0].Conditions[0].Parts[0].ComparisonOperator)
+		assert.Equal(t, int64(2), s.Phases[0].Conditions[0].Parts[0].ReferenceValueInt)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		s, err := Parse([]byte(testJSON), FormatJSON)
+		require.NoError(t, err)
+		require.Len(t, s.Phases, 1)
+		assert.Equal(t, "eq", s.Phases[0].Conditions[0].Parts[0].ComparisonOperator)
+		assert.Equal(t, []int64{1, 3}, s.Phases[0].Conditions[0].Parts[1].ReferenceValueSet)
+
+		phases, err := s.Build(strategy.NewStrategyFactory())
+		require.NoError(t, err)
+		assert.Equal(t, value.ConditionTypeThreshold, phases[0].ConditionType)
+		assert.Equal(t, 2, phases[0].RequiredConditions)
+		part := phases[0].GetConditions()[1].Parts[2]
+		assert.Equal(t, value.ComparisonOperatorIn, part.ComparisonOperator)
+		assert.Equal(t, []int64{1, 3}, part.GetReferenceValueSet())
+		assert.True(t, phases[0].GetConditions()[1].Ordered)
+		assert.Equal(t, "Set_Part", phases[0].GetConditions()[1].GetParts()[0].Label)
+
+		floatCond := phases[0].GetConditions()[2]
+		assert.Equal(t, value.KindFloat, floatCond.Kind)
+		assert.Equal(t, value.PartCombinationAny, floatCond.Combination)
+		assert.Equal(t, 0.9, floatCond.Parts[3].GetReferenceValueFloat())
+		assert.True(t, floatCond.Parts[3].Live)
+		assert.Equal(t, 0.5, floatCond.Parts[5].GetMinValueFloat())
+		assert.Equal(t, 1.5, floatCond.Parts[5].GetMaxValueFloat())
+
+		stringCond := phases[0].GetConditions()[3]
+		assert.Equal(t, value.KindString, stringCond.Kind)
+		assert.Equal(t, []string{"open", "ajar"}, stringCond.Parts[4].GetReferenceStringSet())
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		_, err := Parse([]byte(testYAML), Format("toml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	// リポジトリ同梱のデフォルトシナリオを読み込めることを確認
+	s, err := LoadFile("../../../scenarios/default.yaml")
+	require.NoError(t, err)
+	assert.Len(t, s.Phases, 4)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	assert.Len(t, phases, 4)
+
+	_, err = LoadFile("scenario.txt")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported scenario file extension")
+}
+
+func TestBuild(t *testing.T) {
+	s, err := Parse([]byte(testYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	require.Len(t, phases, 2)
+
+	root := phases[0]
+	assert.Equal(t, "ROOT", root.Name)
+	assert.Equal(t, value.ConditionTypeAnd, root.ConditionType)
+	assert.Equal(t, value.GameRule_PushSwitch, root.Rule)
+
+	cond := root.GetConditions()[1]
+	require.NotNil(t, cond)
+	assert.Equal(t, value.KindCounter, cond.Kind)
+	part := cond.GetParts()[0]
+	assert.Equal(t, value.ComparisonOperatorGTE, part.ComparisonOperator)
+
+	child := phases[1]
+	assert.Equal(t, value.PhaseID(1), child.ParentID)
+	assert.Equal(t, value.ConditionTypeOr, child.ConditionType)
+}
+
+func TestBuildWiresObservers(t *testing.T) {
+	s, err := Parse([]byte(testYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	root := phases[0]
+	require.NoError(t, root.Activate(ctx))
+
+	// パーツ -> 条件 -> フェーズ の通知チェーンが繋がっていればフェーズはnextに進む
+	part := root.GetConditions()[1].GetParts()[0]
+	require.NoError(t, part.Process(ctx, 1))
+	assert.Equal(t, value.StateActive, root.CurrentState())
+	require.NoError(t, part.Process(ctx, 1))
+
+	assert.True(t, part.IsSatisfied())
+	assert.Equal(t, value.StateSatisfied, root.GetConditions()[1].CurrentState())
+	assert.Equal(t, value.StateNext, root.CurrentState())
+}
+
+func TestBuildCompletion(t *testing.T) {
+	const completionYAML = `
+phases:
+  - id: 1
+    name: ROOM
+    order: 1
+    completion:
+      op: or
+      operands:
+        - op: and
+          operands: [{condition_id: 1}, {condition_id: 2}]
+        - op: and
+          operands:
+            - condition_id: 3
+            - op: not
+              operands: [{condition_id: 4}]
+    conditions:
+      - {id: 1, kind: counter, parts: [{id: 1, comparison_operator: gte, reference_value_int: 1}]}
+      - {id: 2, kind: counter, parts: [{id: 2, comparison_operator: gte, reference_value_int: 1}]}
+      - {id: 3, kind: counter, parts: [{id: 3, comparison_operator: gte, reference_value_int: 1}]}
+      - {id: 4, kind: counter, parts: [{id: 4, comparison_operator: gte, reference_value_int: 1}]}
+`
+	s, err := Parse([]byte(completionYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	require.NotNil(t, phases[0].Completion)
+	assert.Equal(t, "(1 and 2) or (3 and not 4)", phases[0].Completion.String())
+
+	// 式が存在しない条件を参照している場合は検証で弾かれる
+	s.Phases[0].Completion.Operands[0].Operands[0].ConditionID = 9
+	_, err = s.Build(strategy.NewStrategyFactory())
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationInvalidCompletion))
+}
+
+func TestBuildSustained(t *testing.T) {
+	const sustainedYAML = `
+phases:
+  - id: 1
+    name: PLATE
+    order: 1
+    conditions:
+      - id: 1
+        kind: sustained
+        parts: [{id: 1, comparison_operator: gte, reference_value_int: 1, duration_seconds: 3, deadline_seconds: 10}]
+`
+	s, err := Parse([]byte(sustainedYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	cond := phases[0].GetConditions()[1]
+	assert.Equal(t, value.KindSustained, cond.Kind)
+	assert.Equal(t, int64(3), cond.Parts[1].GetDurationSeconds())
+	assert.Equal(t, int64(10), cond.Parts[1].DeadlineSeconds)
+
+	// 秒数がない持続条件は検証で弾かれる
+	s.Phases[0].Conditions[0].Parts[0].DurationSeconds = 0
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+}
+
+func TestBuildFailure(t *testing.T) {
+	const failureYAML = `
+phases:
+  - id: 1
+    name: CHALLENGE
+    order: 1
+    on_failure: goto
+    on_failure_phase_id: 2
+    conditions:
+      - id: 1
+        kind: counter
+        parts: [{id: 1, comparison_operator: gte, reference_value_int: 3, deadline_seconds: 10}]
+  - id: 2
+    name: CONSOLATION
+    order: 2
+    on_failure: retry
+    max_retries: 2
+    conditions:
+      - id: 2
+        kind: counter
+        parts: [{id: 2, comparison_operator: gte, reference_value_int: 1}]
+`
+	s, err := Parse([]byte(failureYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	assert.Equal(t, value.FailureGoto, phases[0].OnFailure)
+	assert.Equal(t, value.PhaseID(2), phases[0].OnFailurePhaseID)
+	assert.Equal(t, value.FailureRetry, phases[1].OnFailure)
+	assert.Equal(t, 2, phases[1].MaxRetries)
+
+	// 存在しないフェーズへの移動は検証で弾かれる
+	s.Phases[0].OnFailurePhaseID = 9
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+
+	s.Phases[0].OnFailure = "giveup"
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown failure action")
+}
+
+func TestBuildTransitions(t *testing.T) {
+	const transitionYAML = `
+phases:
+  - id: 1
+    name: CROSSROADS
+    order: 1
+    condition_type: or
+    transitions:
+      - {condition_id: 2, to: 3}
+      - {outcome: failed, to: 0}
+    conditions:
+      - id: 1
+        kind: counter
+        parts: [{id: 1, comparison_operator: gte, reference_value_int: 1}]
+      - id: 2
+        kind: counter
+        parts: [{id: 2, comparison_operator: gte, reference_value_int: 1}]
+  - id: 2
+    name: LEFT
+    order: 2
+    conditions:
+      - id: 3
+        kind: counter
+        parts: [{id: 3, comparison_operator: gte, reference_value_int: 1}]
+  - id: 3
+    name: RIGHT
+    order: 3
+    transitions: [{to: 1}]
+    conditions:
+      - id: 4
+        kind: counter
+        parts: [{id: 4, comparison_operator: gte, reference_value_int: 1}]
+`
+	s, err := Parse([]byte(transitionYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	assert.Equal(t, []entity.PhaseTransition{
+		{ConditionID: 2, To: 3},
+		{Outcome: value.OutcomeFailed},
+	}, phases[0].Transitions)
+	assert.Equal(t, []entity.PhaseTransition{{To: 1}}, phases[2].Transitions)
+
+	// 他のフェーズの条件は検証で弾かれる
+	s.Phases[0].Transitions[0].ConditionID = 4
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+
+	s.Phases[0].Transitions[0].ConditionID = 2
+	s.Phases[0].Transitions[1].Outcome = "timedout"
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown phase outcome")
+}
+
+func TestBuildRepeat(t *testing.T) {
+	const repeatYAML = `
+phases:
+  - id: 1
+    name: ROUND
+    order: 1
+    condition_type: or
+    repeat_count: 5
+    repeat_until: {condition_id: 2}
+    conditions:
+      - {id: 1, kind: counter, parts: [{id: 1, comparison_operator: gte, reference_value_int: 1}]}
+      - {id: 2, kind: counter, parts: [{id: 2, comparison_operator: gte, reference_value_int: 10}]}
+`
+	s, err := Parse([]byte(repeatYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	assert.Equal(t, 5, phases[0].RepeatCount)
+	assert.Equal(t, "2", phases[0].RepeatUntil.String())
+
+	s.Phases[0].RepeatUntil = &ExprDef{Op: "xor"}
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repeat_until")
+}
+
+func TestBuildParallel(t *testing.T) {
+	const parallelYAML = `
+phases:
+  - id: 1
+    name: HEIST
+    order: 1
+    parallel: true
+    parallel_completion: any
+  - id: 2
+    name: VAULT
+    order: 1
+    parent_id: 1
+    conditions:
+      - {id: 1, kind: counter, parts: [{id: 1, comparison_operator: gte, reference_value_int: 1}]}
+  - id: 3
+    name: GUARDS
+    order: 2
+    parent_id: 1
+    conditions:
+      - {id: 2, kind: counter, parts: [{id: 2, comparison_operator: gte, reference_value_int: 1}]}
+`
+	s, err := Parse([]byte(parallelYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	assert.True(t, phases[0].Parallel)
+	assert.Equal(t, value.ParallelAny, phases[0].ParallelCompletion)
+	assert.False(t, phases[1].Parallel)
+
+	s.Phases[0].ParallelCompletion = "some"
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown parallel completion")
+}
+
+func TestBuildWindow(t *testing.T) {
+	const windowYAML = `
+phases:
+  - id: 1
+    name: RAPID
+    order: 1
+    conditions:
+      - id: 1
+        kind: window
+        parts: [{id: 1, comparison_operator: gte, reference_value_int: 5, duration_seconds: 3}]
+`
+	s, err := Parse([]byte(windowYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	cond := phases[0].GetConditions()[1]
+	assert.Equal(t, value.KindWindow, cond.Kind)
+	assert.Equal(t, int64(3), cond.Parts[1].GetDurationSeconds())
+
+	// 秒数がない時間窓条件は作成できない
+	s.Phases[0].Conditions[0].Parts[0].DurationSeconds = 0
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid window duration")
+}
+
+func TestBuildSequence(t *testing.T) {
+	const sequenceYAML = `
+phases:
+  - id: 1
+    name: BUTTONS
+    order: 1
+    conditions:
+      - id: 1
+        kind: sequence
+        parts: [{id: 1, reference_value_set: [3, 1, 4, 2], on_miss: stay}]
+`
+	s, err := Parse([]byte(sequenceYAML), FormatYAML)
+	require.NoError(t, err)
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	require.NoError(t, err)
+	part := phases[0].GetConditions()[1].Parts[1]
+	assert.Equal(t, []int64{3, 1, 4, 2}, part.GetSequence())
+	assert.Equal(t, value.SequenceMissStay, part.OnMiss)
+
+	s.Phases[0].Conditions[0].Parts[0].OnMiss = "ignore"
+	_, err = s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown sequence miss policy")
+}
+
+func TestBuildErrors(t *testing.T) {
+	factory := strategy.NewStrategyFactory()
+
+	testCases := []struct {
+		name     string
+		scenario Scenario
+		errMsg   string
+	}{
+		{
+			name:     "NoPhases",
+			scenario: Scenario{},
+			errMsg:   "scenario has no phases",
+		},
+		{
+			name: "UnknownConditionType",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", ConditionType: "xor"},
+			}},
+			errMsg: "unknown condition type",
+		},
+		{
+			name: "UnknownKind",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", Conditions: []ConditionDef{{ID: 1, Kind: "magic"}}},
+			}},
+			errMsg: "unknown condition kind",
+		},
+		{
+			name: "UnknownCombination",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", Conditions: []ConditionDef{{ID: 1, Kind: "counter", Combination: "most"}}},
+			}},
+			errMsg: "unknown part combination",
+		},
+		{
+			name: "UnknownExpressionOperator",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", Completion: &ExprDef{Op: "xor"}},
+			}},
+			errMsg: "unknown expression operator",
+		},
+		{
+			name: "UnknownOperator",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", Conditions: []ConditionDef{{ID: 1, Kind: "counter", Parts: []PartDef{
+					{ID: 1, ComparisonOperator: "approx"},
+				}}}},
+			}},
+			errMsg: "unknown comparison operator",
+		},
+		{
+			name: "InvalidTimePart",
+			scenario: Scenario{Phases: []PhaseDef{
+				{ID: 1, Name: "P", Conditions: []ConditionDef{{ID: 1, Kind: "time", Parts: []PartDef{
+					{ID: 1, ReferenceValueInt: 0},
+				}}}},
+			}},
+			errMsg: "invalid time interval",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.scenario.Build(factory)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
+
+func TestBuildValidatesStructure(t *testing.T) {
+	s := Scenario{Phases: []PhaseDef{
+		{ID: 1, Name: "ROOT", Order: 1},
+		{ID: 2, Name: "ORPHAN", Order: 1, ParentID: 42},
+	}}
+
+	_, err := s.Build(strategy.NewStrategyFactory())
+	require.Error(t, err)
+
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationOrphanParent))
+}
@@ -2,111 +2,111 @@ package state
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"state_sample/internal/domain/entity"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
+	"state_sample/internal/usecase/scenario"
 	"state_sample/internal/usecase/strategy"
+	"state_sample/scenarios"
 
 	"go.uber.org/zap"
 )
 
+// オペレーター操作の定義
+const (
+	ActionStart  = "start"
+	ActionReset  = "reset"
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionAbort  = "abort"
+	ActionSkip   = "skip"
+	ActionJump   = "jump"
+	ActionRewind = "rewind"
+)
+
 type GameFacade struct {
 	controller *PhaseController
+	journal    *Journal
 }
 
-// NewStateFacade は新しいStateFacadeを作成します
+// NewStateFacade は同梱のデフォルトシナリオ(scenarios/default.yaml)から新しいStateFacadeを作成します
 func NewStateFacade() *GameFacade {
 	log := logger.DefaultLogger()
-	factory := strategy.NewStrategyFactory()
-
-	// ルートフェーズ1
-	RootParentPhaseID := value.PhaseID(0)
-	part1 := entity.NewConditionPart(1, "Time_Part")
-	part1.ReferenceValueInt = 5
-	cond1 := entity.NewCondition(1, "Time_Condition", value.KindTime)
-	cond1.AddPart(part1)
-	if err := cond1.InitializePartStrategies(factory); err != nil {
-		panic(err)
-	}
-	rootPhaseID_1 := value.PhaseID(1)
-	rootPhase1 := entity.NewPhase(rootPhaseID_1, "ROOT_PHASE", 1, []*entity.Condition{cond1}, value.ConditionTypeAnd, value.GameRule_Animation, RootParentPhaseID, false)
-	part1.AddConditionPartObserver(cond1)
-	cond1.AddConditionObserver(rootPhase1)
-	log.Debug("GameFacade initialized", zap.Any("rootPhase1", rootPhase1))
-
-	// ルートフェーズ2
-	part2 := entity.NewConditionPart(2, "Time_Part")
-	part2.ReferenceValueInt = 5
-	cond2 := entity.NewCondition(2, "Time_Condition", value.KindTime)
-	cond2.AddPart(part2)                                            // cond1ではなくcond2に追加
-	if err := cond2.InitializePartStrategies(factory); err != nil { // cond1ではなくcond2を初期化
+
+	s, err := scenario.Parse(scenarios.Default, scenario.FormatYAML)
+	if err != nil {
 		panic(err)
 	}
-	rootPhaseID_2 := value.PhaseID(2)                                                                                                                                       // 一意のID（2）を割り当て
-	rootPhase2 := entity.NewPhase(rootPhaseID_2, "ROOT_PHASE_2", 2, []*entity.Condition{cond2}, value.ConditionTypeAnd, value.GameRule_Animation, RootParentPhaseID, false) // 名前も変更し、cond2を使用
-	part2.AddConditionPartObserver(cond2)
-	cond2.AddConditionObserver(rootPhase2)
-	log.Debug("GameFacade initialized", zap.Any("rootPhase2", rootPhase2)) // ログメッセージも修正
-
-	// 子フェーズ1: CHILD_PHASE1（親=ROOT_PHASE）
-	childPart1 := entity.NewConditionPart(3, "Child1_Part")
-	childPart1.ReferenceValueInt = 2
-	childPart1.ComparisonOperator = value.ComparisonOperatorGTE
-	childCond1 := entity.NewCondition(3, "Child1_Condition", value.KindCounter)
-	childCond1.AddPart(childPart1)
-	if err := childCond1.InitializePartStrategies(factory); err != nil {
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	if err != nil {
 		panic(err)
 	}
+	log.Debug("GameFacade initialized", zap.String("scenario", s.Name), zap.Int("phases", len(phases)))
 
-	childPhaseID_1 := value.PhaseID(4) // 一意のID（4）を割り当て（2から変更）
-	childPhase1 := entity.NewPhase(childPhaseID_1, "CHILD_PHASE1", 1, []*entity.Condition{childCond1}, value.ConditionTypeOr, value.GameRule_Animation, rootPhaseID_1, false)
-	childPart1.AddConditionPartObserver(childCond1)
-	childCond1.AddConditionObserver(childPhase1)
-	log.Debug("GameFacade initialized", zap.Any("childPhase1", childPhase1))
+	return NewGameFacade(phases)
+}
 
-	// 子フェーズ2: CHILD_PHASE2（親=ROOT_PHASE）
-	childPart2 := entity.NewConditionPart(4, "Child2_Part")
-	childPart2.ReferenceValueInt = 3
-	childPart2.ComparisonOperator = value.ComparisonOperatorGTE
-	childCond2 := entity.NewCondition(4, "Child2_Condition", value.KindCounter)
-	childCond2.AddPart(childPart2)
-	if err := childCond2.InitializePartStrategies(factory); err != nil {
-		panic(err)
-	}
-	childPhaseID_2 := value.PhaseID(3)
-	childPhase2 := entity.NewPhase(childPhaseID_2, "CHILD_PHASE2", 2, []*entity.Condition{childCond2}, value.ConditionTypeOr, value.GameRule_Animation, rootPhaseID_1, true)
-	childPart2.AddConditionPartObserver(childCond2)
-	childCond2.AddConditionObserver(childPhase2)
-	log.Debug("GameFacade initialized", zap.Any("childPhase2", childPhase2))
-
-	//// 孫フェーズ: GRANDCHILD_PHASE（親=CHILD_PHASE2）
-	//grandchildPart := entity.NewConditionPart(5, "Grandchild_Part")
-	//grandchildPart.ReferenceValueInt = 2
-	//grandchildCond := entity.NewCondition(5, "Grandchild_Condition", value.KindTime)
-	//grandchildCond.AddPart(grandchildPart)
-	//if err := grandchildCond.InitializePartStrategies(factory); err != nil {
-	//	panic(err)
-	//}
-	//grandchildPhase := entity.NewPhase("GRANDCHILD_PHASE", 1, []*entity.Condition{grandchildCond}, value.ConditionTypeOr, value.GameRule_Animation, 3, false)
-	//grandchildPhase.ID = 4 // IDを明示的に設定
-	//grandchildPart.AddConditionPartObserver(grandchildCond)
-	//grandchildCond.AddConditionObserver(grandchildPhase)
-	//log.Debug("GameFacade initialized", zap.Any("grandchildPhase", grandchildPhase))
-
-	// 全フェーズをスライスに追加
-	phases := []*entity.Phase{rootPhase1, rootPhase2, childPhase1, childPhase2}
+// NewGameFacade は構築済みのフェーズから実時間で動く新しいGameFacadeを作成します
+func NewGameFacade(phases entity.Phases) *GameFacade {
+	return NewGameFacadeWithClock(phases, clock.Real())
+}
 
+// NewGameFacadeWithClock は構築済みのフェーズから指定したClockで動く新しいGameFacadeを作成します
+// タイマーも同じClockで動かす場合は、フェーズをNewStrategyFactoryWithClockで構築してください
+// 実時間以外のClockでは遷移の待ち時間を0にします。clock.FakeのSleepはAdvanceされるまで戻らないため、
+// 待ち時間を設定する場合(SetTransitionDelay)は、遷移のたびに別のゴルーチンから時計を進める必要があります
+func NewGameFacadeWithClock(phases entity.Phases, c clock.Clock) *GameFacade {
 	// PhaseControllerを作成
 	controller := NewPhaseController(phases)
+	controller.SetClock(c)
+	if c != clock.Real() {
+		controller.SetTransitionDelay(0)
+	}
+
+	// 全エンティティの状態遷移をジャーナルに記録する
+	journal := NewJournal()
+	journal.clock = c
+	journal.Attach(phases)
 
 	return &GameFacade{
 		controller: controller,
+		journal:    journal,
+	}
+}
+
+// NewStateFacadeFromScenario はシナリオファイルから新しいGameFacadeを作成します
+func NewStateFacadeFromScenario(path string) (*GameFacade, error) {
+	s, err := scenario.LoadFile(path)
+	if err != nil {
+		return nil, err
 	}
+
+	phases, err := s.Build(strategy.NewStrategyFactory())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scenario %s: %w", path, err)
+	}
+
+	return NewGameFacade(phases), nil
+}
+
+// Validate はフェーズツリーの構造を検証します
+func (sf *GameFacade) Validate() error {
+	return entity.ValidatePhases(sf.controller.GetPhases())
 }
 
 // Start はフェーズシーケンスを開始します
+// フェーズツリーに構造上の問題がある場合は開始しません
 func (sf *GameFacade) Start(ctx context.Context) error {
+	// 構造上の問題で開始しなかった場合は操作として記録しない
+	if err := sf.Validate(); err != nil {
+		return err
+	}
+	sf.journal.recordAction(ActionStart)
+
 	// 最初のルートフェーズを取得
 	rootPhases := sf.controller.phaseFacade.GetPhasesByParentID(0)
 	if len(rootPhases) <= 0 {
@@ -121,17 +121,174 @@ func (sf *GameFacade) Start(ctx context.Context) error {
 
 // Reset は全てのフェーズをリセットします
 func (sf *GameFacade) Reset(ctx context.Context) error {
+	sf.journal.recordAction(ActionReset)
 	return sf.controller.Reset(ctx)
 }
 
+// Pause はゲーム全体を一時停止します
+// タイマーは残り時間を保持して止まり、カウンターは再開するまで評価値の入力を受け付けません
+func (sf *GameFacade) Pause(ctx context.Context) error {
+	sf.journal.recordAction(ActionPause)
+	return sf.controller.Pause(ctx)
+}
+
+// Resume は一時停止したゲームを再開します
+func (sf *GameFacade) Resume(ctx context.Context) error {
+	sf.journal.recordAction(ActionResume)
+	return sf.controller.Resume(ctx)
+}
+
+// Abort は現在の最下層のフェーズを中断します
+// 中断したフェーズは失敗として扱われ、OnFailureに従って遷移します
+func (sf *GameFacade) Abort(ctx context.Context) error {
+	sf.journal.recordAction(ActionAbort)
+	return sf.controller.Abort(ctx)
+}
+
+// Skip は現在の最下層のフェーズを飛ばしたものとして、次のフェーズに進めます
+// 機器の故障などでフェーズを完了できない場合に使います
+func (sf *GameFacade) Skip(ctx context.Context) error {
+	sf.journal.recordAction(ActionSkip)
+	return sf.controller.Skip(ctx)
+}
+
+// JumpTo は指定したIDのフェーズに移ります
+// 移り先より前で動いていたフェーズは飛ばして終了し、後ろのフェーズはリセットされます
+func (sf *GameFacade) JumpTo(ctx context.Context, id value.PhaseID) error {
+	sf.journal.recordJump(id)
+	return sf.controller.JumpTo(ctx, id)
+}
+
+// Rewind は現在のフェーズの1つ前のフェーズに戻ります
+func (sf *GameFacade) Rewind(ctx context.Context) error {
+	sf.journal.recordAction(ActionRewind)
+	return sf.controller.Rewind(ctx)
+}
+
+// IsPaused はゲームが一時停止中かどうかを返します
+func (sf *GameFacade) IsPaused() bool {
+	return sf.controller.IsPaused()
+}
+
+// Snapshot はゲーム全体の状態を保存します
+func (sf *GameFacade) Snapshot() *entity.Snapshot {
+	return sf.controller.phaseFacade.Snapshot()
+}
+
+// Restore はスナップショットからゲーム全体の状態を復元します
+// カウンターの値は引き継ぎ、タイマーは保存時の残り時間から再開します
+// 次フェーズへの遷移待ちで保存されたフェーズは、遷移を終えてから戻ります
+func (sf *GameFacade) Restore(ctx context.Context, snapshot *entity.Snapshot) error {
+	return sf.controller.Restore(ctx, snapshot)
+}
+
 // GetCurrentPhase は指定された親IDに対する現在のフェーズを取得します
 func (sf *GameFacade) GetCurrentPhase(parentID value.PhaseID) *entity.Phase {
 	return sf.controller.phaseFacade.GetCurrentPhase(parentID)
 }
 
-// GetCurrentLeafPhase は現在アクティブな最下層のフェーズを取得します
-func (sf *GameFacade) GetCurrentLeafPhase() *entity.Phase {
-	return sf.controller.phaseFacade.GetCurrentLeafPhase()
+// GetCurrentLeafPhases は現在アクティブな最下層のフェーズの集合を取得します
+// 並列領域を持つフェーズの下では、動いている領域の数だけフェーズを返します
+func (sf *GameFacade) GetCurrentLeafPhases() entity.Phases {
+	return sf.controller.phaseFacade.GetCurrentLeafPhases()
+}
+
+// EvaluatePart は指定された条件パーツに評価値を入力します
+func (sf *GameFacade) EvaluatePart(ctx context.Context, conditionID, partID int64, increment int64) (*entity.ConditionPart, error) {
+	part, err := sf.GetConditionPart(conditionID, partID)
+	if err != nil {
+		return nil, err
+	}
+
+	sf.journal.recordEvaluate(value.ConditionID(conditionID), value.ConditionPartID(partID), increment)
+	return part, part.Process(ctx, increment)
+}
+
+// EvaluatePartValue は指定された条件パーツに戦略に応じた型の評価値を入力します
+// 小数条件にはfloat64の測定値、文字列条件にはstringの入力値を渡します
+func (sf *GameFacade) EvaluatePartValue(ctx context.Context, conditionID, partID int64, input interface{}) (*entity.ConditionPart, error) {
+	part, err := sf.GetConditionPart(conditionID, partID)
+	if err != nil {
+		return nil, err
+	}
+
+	sf.journal.recordEvaluateValue(value.ConditionID(conditionID), value.ConditionPartID(partID), input)
+	return part, part.ProcessValue(ctx, input)
+}
+
+// EntityEventTarget は外部エンティティのイベントを受け取った条件パーツです
+type EntityEventTarget struct {
+	ConditionID value.ConditionID
+	Part        *entity.ConditionPart
+}
+
+// DispatchEntityEvent は外部エンティティのイベントを、そのエンティティを対象とする
+// 現在の最下層フェーズ(並列領域では全ての領域の最下層フェーズ)の評価中の条件パーツすべてに入力し、入力したパーツを返します
+// inputがnilの場合はincrementをカウンターの増分として、それ以外は型付きの評価値として入力します
+// 一部のパーツで評価に失敗しても残りのパーツへの入力は続け、エラーはまとめて返します
+func (sf *GameFacade) DispatchEntityEvent(ctx context.Context, entityType string, entityID int64, increment int64, input interface{}) ([]EntityEventTarget, error) {
+	if sf.IsPaused() {
+		return nil, fmt.Errorf("state is paused")
+	}
+
+	leaves := sf.GetCurrentLeafPhases()
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("no active phase")
+	}
+
+	// 入力によってフェーズが進んでも振り分け先が変わらないよう、先に対象を確定する
+	candidates := make([]EntityEventTarget, 0)
+	for _, leaf := range leaves {
+		for _, condition := range leaf.GetConditions() {
+			for _, part := range condition.GetParts() {
+				if part.TargetEntityType == entityType && part.TargetEntityID == entityID {
+					candidates = append(candidates, EntityEventTarget{ConditionID: condition.ID, Part: part})
+				}
+			}
+		}
+	}
+	// 条件はマップで保持されているため、入力する順番を条件IDで固定する
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].ConditionID < candidates[j].ConditionID
+	})
+
+	targets := make([]EntityEventTarget, 0, len(candidates))
+	var errs []error
+	for _, target := range candidates {
+		// 満たし済みのパーツや、先の入力でフェーズが進んでリセットされたパーツには入力しない
+		if !isEvaluating(target.Part) {
+			continue
+		}
+
+		var err error
+		if input != nil {
+			_, err = sf.EvaluatePartValue(ctx, int64(target.ConditionID), int64(target.Part.ID), input)
+		} else {
+			_, err = sf.EvaluatePart(ctx, int64(target.ConditionID), int64(target.Part.ID), increment)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("part %d: %w", target.Part.ID, err))
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, errors.Join(errs...)
+}
+
+// isEvaluating は条件パーツが評価値の入力を受け付ける状態かどうかを返します
+func isEvaluating(part *entity.ConditionPart) bool {
+	switch part.CurrentState() {
+	case value.StateUnsatisfied, value.StateProcessing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Journal は状態遷移のジャーナルを取得します
+func (sf *GameFacade) Journal() *Journal {
+	return sf.journal
 }
 
 // GetController はPhaseControllerを取得します
@@ -0,0 +1,926 @@
+package state
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
+	"state_sample/internal/usecase/strategy"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeClock はテスト用のFakeクロックを作成します
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// newFacadeWithFakeClock はタイマーとタイムスタンプがFakeクロックで動くGameFacadeを作成します
+func newFacadeWithFakeClock(t *testing.T, fake *clock.Fake, roots ...*PhaseBuilder) *GameFacade {
+	phases, err := BuildPhasesWithFactory(strategy.NewStrategyFactoryWithClock(fake), roots...)
+	require.NoError(t, err)
+	return NewGameFacadeWithClock(phases, fake)
+}
+
+// currentLeafNames は現在の最下層のフェーズの名前を返します
+func currentLeafNames(facade *GameFacade) []string {
+	names := make([]string, 0)
+	for _, leaf := range facade.GetCurrentLeafPhases() {
+		names = append(names, leaf.Name)
+	}
+	return names
+}
+
+// currentLeafName は現在の最下層のフェーズがただ1つの場合にその名前を返し、それ以外は空文字列を返します
+func currentLeafName(facade *GameFacade) string {
+	names := currentLeafNames(facade)
+	if len(names) != 1 {
+		return ""
+	}
+	return names[0]
+}
+
+// singleLeaf は現在の最下層のフェーズがただ1つであることを確認して返します
+func singleLeaf(t *testing.T, facade *GameFacade) *entity.Phase {
+	leaves := facade.GetCurrentLeafPhases()
+	require.Len(t, leaves, 1)
+	return leaves[0]
+}
+
+func TestNewStateFacadeUsesDefaultScenario(t *testing.T) {
+	// 埋め込んだscenarios/default.yamlからフェーズツリーを構築する
+	facade := NewStateFacade()
+	require.NoError(t, facade.Validate())
+
+	phases := facade.GetController().GetPhases()
+	require.Len(t, phases, 4)
+
+	root := findPhase(phases, "ROOT_PHASE")
+	child1 := findPhase(phases, "CHILD_PHASE1")
+	child2 := findPhase(phases, "CHILD_PHASE2")
+	assert.Equal(t, value.PhaseID(3), child1.ID)
+	assert.Equal(t, value.PhaseID(4), child2.ID)
+	assert.Equal(t, root.ID, child1.ParentID)
+	assert.Equal(t, root.ID, child2.ParentID)
+	assert.True(t, child2.AutoProgressOnChildrenComplete)
+	assert.Equal(t, value.PhaseID(2), findPhase(phases, "ROOT_PHASE_2").ID)
+}
+
+func TestGameFacadeWithClockTransitionDelay(t *testing.T) {
+	ctx := context.Background()
+	build := func(c clock.Clock) *GameFacade {
+		phases, err := BuildPhases(
+			Phase("FIRST").ID(1).All(Counter("hit").ID(1).GTE(1)),
+			Phase("SECOND").ID(2).All(Counter("push").ID(2).GTE(1)),
+		)
+		require.NoError(t, err)
+		return NewGameFacadeWithClock(phases, c)
+	}
+
+	// 実時間では既定の待ち時間を使う
+	assert.Equal(t, DefaultTransitionDelay, build(clock.Real()).GetController().transitionDelay)
+
+	// Fakeでは時計を進めなくても次のフェーズへ遷移する
+	fake := newFakeClock()
+	facade := build(fake)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "SECOND"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, newFakeClock().Now(), fake.Now())
+}
+
+func TestGameFacadeWithFakeClock(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	start := fake.Now()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("WAIT").ID(1).All(Timer("wait", 5).ID(1)),
+		Phase("AFTER").ID(2).All(Timer("wait", 5).ID(2)),
+	)
+	// コントローラーの遷移の待ち時間もFakeクロックで進める
+	facade.GetController().SetTransitionDelay(DefaultTransitionDelay)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	first := facade.GetCurrentPhase(0)
+	require.NotNil(t, first)
+	assert.Equal(t, start, *first.StartTime)
+
+	// 5秒経過する直前ではまだ進まない
+	fake.Advance(4 * time.Second)
+	part, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.False(t, part.IsSatisfied())
+
+	// 5秒経過でタイマーが発火するが、遷移の待ち時間が過ぎるまでは次のフェーズへ進まない
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return first.CurrentState() == value.StateNext
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "WAIT", facade.GetCurrentPhase(0).Name)
+
+	// 待ち時間の分だけ進めると、実時間を使わずに次のフェーズへ進む
+	fake.Advance(DefaultTransitionDelay)
+	assert.Eventually(t, func() bool {
+		current := facade.GetCurrentPhase(0)
+		return current != nil && current.Name == "AFTER"
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, value.StateFinish, first.CurrentState())
+	assert.Equal(t, start.Add(5*time.Second), *part.FinishTime)
+	assert.Equal(t, start.Add(6*time.Second), *first.FinishTime)
+
+	entries := facade.Journal().Entries()
+	assert.Equal(t, start, entries[0].Timestamp)
+}
+
+func TestGameFacadePauseResume(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	start := fake.Now()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("ROOT").ID(1).Children(
+			Phase("CHILD1").ID(2).All(
+				Timer("wait", 5).ID(1),
+				Counter("hit").ID(2).GTE(1),
+			),
+			Phase("CHILD2").ID(3).Any(Counter("push").ID(3).GTE(1)),
+		),
+	)
+
+	// 開始前は一時停止できない
+	assert.Error(t, facade.Pause(ctx))
+
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	fake.Advance(2 * time.Second)
+	require.NoError(t, facade.Pause(ctx))
+	assert.True(t, facade.IsPaused())
+	assert.Error(t, facade.Pause(ctx))
+
+	root := facade.GetCurrentPhase(0)
+	leaf := singleLeaf(t, facade)
+	assert.Equal(t, value.StatePaused, root.CurrentState())
+	assert.Equal(t, value.StatePaused, leaf.CurrentState())
+
+	// 一時停止中はカウンターへの入力を拒否し、タイマーも発火しない
+	_, err := facade.EvaluatePart(ctx, 2, 2, 1)
+	assert.Error(t, err)
+	fake.Advance(time.Minute)
+	timer, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.True(t, timer.IsPaused())
+	assert.False(t, timer.IsSatisfied())
+
+	resumed := findPartSnapshot(facade.Snapshot(), 1)
+	require.NotNil(t, resumed)
+	assert.True(t, resumed.Paused)
+	assert.Equal(t, int64(3000), resumed.Strategy.RemainingMs)
+
+	// 再開後は開始時刻を保持したまま、残り時間から続ける
+	require.NoError(t, facade.Resume(ctx))
+	assert.False(t, facade.IsPaused())
+	assert.Error(t, facade.Resume(ctx))
+	assert.Equal(t, value.StateActive, leaf.CurrentState())
+	assert.Equal(t, start, *leaf.StartTime)
+
+	_, err = facade.EvaluatePart(ctx, 2, 2, 1)
+	require.NoError(t, err)
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "CHILD2"
+	}, time.Second, time.Millisecond)
+
+	actions := make([]string, 0)
+	for _, entry := range facade.Journal().Entries() {
+		if entry.Kind == JournalAction {
+			actions = append(actions, entry.Action)
+		}
+	}
+	assert.Equal(t, []string{ActionPause, ActionStart, ActionPause, ActionPause, ActionResume, ActionResume}, actions)
+}
+
+func TestGameFacadeDispatchEntityEvent(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("SWITCHES").ID(1).All(
+			Counter("hold").ID(1).Target("switch", 7).GTE(2),
+			Counter("press").ID(2).Target("switch", 7).GTE(1),
+			Counter("other").ID(3).Target("switch", 8).GTE(1),
+		),
+		Phase("AFTER").ID(2).All(Counter("next").ID(4).Target("switch", 7).GTE(2)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 同じエンティティを対象とするパーツすべてに入力される
+	targets, err := facade.DispatchEntityEvent(ctx, "switch", 7, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.Equal(t, value.ConditionPartID(1), targets[0].Part.ID)
+	assert.Equal(t, value.ConditionPartID(2), targets[1].Part.ID)
+	assert.True(t, targets[1].Part.IsSatisfied())
+
+	// 満たし済みのパーツには入力しない
+	targets, err = facade.DispatchEntityEvent(ctx, "switch", 7, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, value.ConditionID(1), targets[0].ConditionID)
+	assert.True(t, targets[0].Part.IsSatisfied())
+
+	// 対象のパーツがなければ何もしない
+	targets, err = facade.DispatchEntityEvent(ctx, "door", 7, 1, nil)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	// 次のフェーズへ進むと、そのフェーズのパーツに振り分けられる
+	targets, err = facade.DispatchEntityEvent(ctx, "switch", 8, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "AFTER", currentLeafName(facade))
+
+	targets, err = facade.DispatchEntityEvent(ctx, "switch", 7, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, value.ConditionPartID(4), targets[0].Part.ID)
+
+	// 入力はパーツごとの評価としてジャーナルに記録される
+	evaluates := 0
+	for _, entry := range facade.Journal().Entries() {
+		if entry.Kind == JournalEvaluate {
+			evaluates++
+		}
+	}
+	assert.Equal(t, 5, evaluates)
+
+	require.NoError(t, facade.Pause(ctx))
+	_, err = facade.DispatchEntityEvent(ctx, "switch", 7, 1, nil)
+	assert.Error(t, err)
+}
+
+func TestGameFacadeDeadlineCondition(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("RACE").ID(1).Any(
+			Counter("hit").ID(1).GTE(3).Deadline(10),
+			Counter("bonus").ID(2).GTE(1).Deadline(5),
+		),
+		Phase("AFTER").ID(2).All(Counter("next").ID(3).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	_, err := facade.EvaluatePart(ctx, 1, 1, 2)
+	require.NoError(t, err)
+
+	// 期限切れの条件は成功として扱われず、フェーズは残りの条件で完了できる
+	fake.Advance(5 * time.Second)
+	bonus, err := facade.GetConditionPart(2, 2)
+	require.NoError(t, err)
+	assert.Eventually(t, bonus.IsFailed, time.Second, time.Millisecond)
+	leaf := singleLeaf(t, facade)
+	assert.Equal(t, "RACE", leaf.Name)
+	assert.Equal(t, map[value.ConditionID]bool{2: true}, leaf.FailedConditions())
+	assert.True(t, leaf.CanComplete())
+	_, err = facade.EvaluatePart(ctx, 2, 2, 1)
+	assert.Error(t, err)
+
+	// すべての条件が期限切れになると、フェーズはもう完了できずに失敗する
+	fake.Advance(5 * time.Second)
+	hit, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, hit.IsFailed, time.Second, time.Millisecond)
+	assert.False(t, leaf.CanComplete())
+	assert.Eventually(t, leaf.IsFailed, time.Second, time.Millisecond)
+	assert.Equal(t, value.OutcomeFailed, leaf.Outcome)
+	assert.False(t, leaf.IsClear)
+
+	// 失敗時の遷移先が指定されていないルートフェーズはゲームを終了し、次のフェーズには進まない
+	assert.Equal(t, value.StateReady, facade.GetController().GetPhases().GetByID(2).CurrentState())
+}
+
+func TestGameFacadeFailureRetry(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("ROUND").ID(1).RetryOnFailure(1).All(Counter("hit").ID(1).GTE(3).Deadline(5)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	phases := facade.GetController().GetPhases()
+	round := phases.GetByID(1)
+	part, err := facade.EvaluatePart(ctx, 1, 1, 2)
+	require.NoError(t, err)
+
+	// 失敗したフェーズは条件をリセットして最初からやり直す
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool {
+		return round.CurrentState() == value.StateActive && part.GetCurrentValue() == int64(0)
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, value.OutcomeNone, round.Outcome)
+	assert.Equal(t, "ROUND", currentLeafName(facade))
+
+	// やり直しの上限に達すると、失敗したままゲームを終了する
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, round.IsFailed, time.Second, time.Millisecond)
+	assert.Equal(t, value.OutcomeFailed, round.Outcome)
+	assert.Equal(t, value.StateReady, phases.GetByID(2).CurrentState())
+}
+
+func TestGameFacadeFailureGoto(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("CHALLENGE").ID(1).GotoOnFailure(3).All(Counter("hit").ID(1).GTE(3).Deadline(5)),
+		Phase("BONUS").ID(2).All(Counter("bonus").ID(2).GTE(1)),
+		Phase("CONSOLATION").ID(3).All(Counter("consolation").ID(3).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 失敗すると順序の次ではなく、指定したフェーズに移る
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "CONSOLATION"
+	}, time.Second, time.Millisecond)
+
+	phases := facade.GetController().GetPhases()
+	challenge := phases.GetByID(1)
+	assert.True(t, challenge.IsFailed())
+	assert.Equal(t, value.OutcomeFailed, challenge.Outcome)
+	assert.Equal(t, value.StateReady, phases.GetByID(2).CurrentState())
+	assert.Equal(t, value.StateActive, phases.GetByID(3).CurrentState())
+}
+
+func TestGameFacadeTransitionByCondition(t *testing.T) {
+	ctx := context.Background()
+	newFacade := func() *GameFacade {
+		facade := newFacadeWithFakeClock(t, newFakeClock(),
+			Phase("CROSSROADS").ID(1).GotoOn(1, 3).GotoOn(2, 0).Any(
+				Counter("right").ID(1).GTE(1),
+				Counter("stop").ID(2).GTE(1),
+			),
+			Phase("LEFT").ID(2).All(Counter("left").ID(3).GTE(1)),
+			Phase("RIGHT").ID(3).GotoOn(0, 1).All(Counter("goal").ID(4).GTE(1)),
+		)
+		require.NoError(t, facade.Start(ctx))
+		t.Cleanup(func() { _ = facade.Reset(ctx) })
+		return facade
+	}
+
+	// 満たされた条件に応じて、順序の次ではなく辺の移り先に進む
+	facade := newFacade()
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "RIGHT"
+	}, time.Second, time.Millisecond)
+	phases := facade.GetController().GetPhases()
+	assert.Equal(t, value.ConditionID(1), phases.GetByID(1).CompletedBy)
+	assert.Equal(t, value.StateReady, phases.GetByID(2).CurrentState())
+
+	// 前のフェーズに戻る辺では、戻り先のフェーズを最初からやり直す
+	_, err = facade.EvaluatePart(ctx, 4, 4, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "CROSSROADS"
+	}, time.Second, time.Millisecond)
+	crossroads := phases.GetByID(1)
+	assert.Equal(t, value.StateActive, crossroads.CurrentState())
+	assert.Equal(t, value.ConditionID(0), crossroads.CompletedBy)
+	assert.Equal(t, value.StateFinish, phases.GetByID(3).CurrentState())
+
+	// 移り先が0の辺では、兄弟フェーズに移らずに終える
+	facade = newFacade()
+	_, err = facade.EvaluatePart(ctx, 2, 2, 1)
+	require.NoError(t, err)
+	phases = facade.GetController().GetPhases()
+	assert.Eventually(t, func() bool {
+		return phases.GetByID(1).CurrentState() == value.StateFinish
+	}, time.Second, time.Millisecond)
+	assert.Never(t, func() bool {
+		return phases.GetByID(2).CurrentState() != value.StateReady
+	}, 50*time.Millisecond, time.Millisecond)
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+}
+
+func TestGameFacadeTransitionByOutcome(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("CHALLENGE").ID(1).GotoOnOutcome(value.OutcomeFailed, 3).RetryOnFailure(1).
+			All(Counter("hit").ID(1).GTE(3).Deadline(5)),
+		Phase("BONUS").ID(2).All(Counter("bonus").ID(2).GTE(1)),
+		Phase("CONSOLATION").ID(3).All(Counter("consolation").ID(3).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 結果に一致する辺は、失敗時のリトライより優先される
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "CONSOLATION"
+	}, time.Second, time.Millisecond)
+	phases := facade.GetController().GetPhases()
+	assert.True(t, phases.GetByID(1).IsFailed())
+	assert.Equal(t, value.StateReady, phases.GetByID(2).CurrentState())
+}
+
+func TestGameFacadeAbort(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("STAGE").ID(1).AutoProgress().RetryOnFailure(0).Children(
+			Phase("STEP1").ID(2).All(Counter("a").ID(1).GTE(1)),
+			Phase("STEP2").ID(3).All(Counter("b").ID(2).GTE(1)),
+		),
+	)
+	assert.Error(t, facade.Abort(ctx))
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "STEP2"
+	}, time.Second, time.Millisecond)
+
+	// 中断した子フェーズの失敗は親フェーズに引き継がれ、親フェーズが最初からやり直す
+	require.NoError(t, facade.Abort(ctx))
+	phases := facade.GetController().GetPhases()
+	assert.Equal(t, value.StateActive, phases.GetByID(1).CurrentState())
+	assert.Equal(t, value.StateActive, phases.GetByID(2).CurrentState())
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+	assert.Equal(t, "STEP1", currentLeafName(facade))
+
+	var aborted []value.PhaseID
+	for _, entry := range facade.Journal().Entries() {
+		if entry.Kind == JournalPhaseTransition && entry.To == value.StateFailed {
+			aborted = append(aborted, entry.PhaseID)
+		}
+	}
+	assert.Equal(t, []value.PhaseID{3, 1}, aborted)
+}
+
+// newParallelFacade は2つの並列領域を持つフェーズと、その次のフェーズからなるGameFacadeを作成します
+func newParallelFacade(t *testing.T, fake *clock.Fake, completion value.ParallelCompletion) *GameFacade {
+	return newFacadeWithFakeClock(t, fake,
+		Phase("HEIST").ID(1).Parallel(completion).Children(
+			Phase("VAULT").ID(2).All(Counter("vault").ID(1).GTE(1).Deadline(5)),
+			Phase("GUARDS").ID(3).All(Counter("guards").ID(2).GTE(1).Target("guard", 1)),
+		),
+		Phase("ESCAPE").ID(4).All(Counter("escape").ID(3).GTE(1)),
+	)
+}
+
+func TestGameFacadeParallelAll(t *testing.T) {
+	ctx := context.Background()
+	facade := newParallelFacade(t, newFakeClock(), value.ParallelAll)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 全ての領域が同時にアクティブになる
+	assert.Equal(t, []string{"VAULT", "GUARDS"}, currentLeafNames(facade))
+	targets, err := facade.DispatchEntityEvent(ctx, "guard", 1, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	// 残りの領域が終わるまで親フェーズは完了しない
+	phases := facade.GetController().GetPhases()
+	assert.Eventually(t, func() bool {
+		return phases.GetByID(3).CurrentState() == value.StateFinish
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"VAULT"}, currentLeafNames(facade))
+	assert.Equal(t, value.StateActive, phases.GetByID(1).CurrentState())
+
+	_, err = facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "ESCAPE"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, value.StateFinish, phases.GetByID(1).CurrentState())
+}
+
+func TestGameFacadeParallelAny(t *testing.T) {
+	ctx := context.Background()
+	facade := newParallelFacade(t, newFakeClock(), value.ParallelAny)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// いずれかの領域が完了すると、残りの領域を打ち切って親フェーズが完了する
+	_, err := facade.EvaluatePart(ctx, 2, 2, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "ESCAPE"
+	}, time.Second, time.Millisecond)
+
+	phases := facade.GetController().GetPhases()
+	assert.Equal(t, value.StateFinish, phases.GetByID(1).CurrentState())
+	assert.Equal(t, value.StateReady, phases.GetByID(2).CurrentState())
+	assert.Equal(t, value.StateFinish, phases.GetByID(3).CurrentState())
+}
+
+func TestGameFacadeParallelFailure(t *testing.T) {
+	ctx := context.Background()
+
+	// 全ての領域の完了が必要な場合、1つの領域の失敗で残りの領域を打ち切って親フェーズも失敗する
+	fake := newFakeClock()
+	facade := newParallelFacade(t, fake, value.ParallelAll)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	fake.Advance(5 * time.Second)
+	phases := facade.GetController().GetPhases()
+	assert.Eventually(t, phases.GetByID(1).IsFailed, time.Second, time.Millisecond)
+	assert.True(t, phases.GetByID(2).IsFailed())
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+	assert.Equal(t, value.StateReady, phases.GetByID(4).CurrentState())
+
+	// いずれかの領域で完了する場合、動いている領域が残っていれば親フェーズは失敗しない
+	fake = newFakeClock()
+	facade = newParallelFacade(t, fake, value.ParallelAny)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	fake.Advance(5 * time.Second)
+	phases = facade.GetController().GetPhases()
+	assert.Eventually(t, phases.GetByID(2).IsFailed, time.Second, time.Millisecond)
+	assert.Equal(t, value.StateActive, phases.GetByID(1).CurrentState())
+	assert.Equal(t, []string{"GUARDS"}, currentLeafNames(facade))
+
+	// 最後の領域を中断すると親フェーズが中断として失敗する
+	require.NoError(t, facade.Abort(ctx))
+	heist := phases.GetByID(1)
+	assert.True(t, heist.IsFailed())
+	assert.Equal(t, value.OutcomeAborted, heist.Outcome)
+	assert.Equal(t, value.StateReady, phases.GetByID(4).CurrentState())
+}
+
+func TestGameFacadeRepeatCount(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("ROUND").ID(1).Repeat(3).All(Counter("hit").ID(1).GTE(2)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	round := facade.GetController().GetPhases().GetByID(1)
+	assert.Equal(t, 1, round.Iteration)
+
+	// 完了するたびに条件とパーツをリセットして、同じフェーズをもう一度アクティブ化する
+	for iteration := 1; iteration <= 3; iteration++ {
+		assert.Equal(t, "ROUND", currentLeafName(facade))
+		part, err := facade.EvaluatePart(ctx, 1, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), part.GetCurrentValue())
+		_, err = facade.EvaluatePart(ctx, 1, 1, 1)
+		require.NoError(t, err)
+
+		if iteration < 3 {
+			next := iteration + 1
+			assert.Eventually(t, func() bool {
+				return round.Iteration == next && round.CurrentState() == value.StateActive
+			}, time.Second, time.Millisecond)
+		}
+	}
+
+	// 上限の回数に達すると次のフェーズに進む
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 3, round.Iteration)
+	assert.Equal(t, value.StateFinish, round.CurrentState())
+
+	snapshot := facade.Snapshot()
+	assert.Equal(t, 3, snapshot.Phases[0].Iteration)
+}
+
+func TestGameFacadeRepeatUntil(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("ROUND").ID(1).RepeatUntil(entity.ExprRef(2), 0).Any(
+			Counter("miss").ID(1).GTE(1),
+			Counter("goal").ID(2).GTE(1),
+		),
+		Phase("AFTER").ID(2).All(Counter("next").ID(3).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	round := facade.GetController().GetPhases().GetByID(1)
+	for iteration := 2; iteration <= 3; iteration++ {
+		_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return round.Iteration == iteration && round.CurrentState() == value.StateActive
+		}, time.Second, time.Millisecond)
+	}
+
+	// 式が真になった時点で繰り返しを終える
+	_, err := facade.EvaluatePart(ctx, 2, 2, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 3, round.Iteration)
+
+	// リセットすると繰り返し回数も最初に戻る
+	require.NoError(t, facade.Reset(ctx))
+	assert.Equal(t, 0, round.Iteration)
+}
+
+func TestGameFacadeSkip(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("BROKEN").ID(1).GotoOnOutcome(value.OutcomeSucceeded, 3).GotoOnOutcome(value.OutcomeSkipped, 4).
+			All(Counter("device").ID(1).GTE(1)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+		Phase("BONUS").ID(3).All(Counter("bonus").ID(3).GTE(1)),
+		Phase("REPAIR").ID(4).All(Counter("repair").ID(4).GTE(1)),
+	)
+	assert.Error(t, facade.Skip(ctx))
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 一時停止中は飛ばせない
+	require.NoError(t, facade.Pause(ctx))
+	assert.Error(t, facade.Skip(ctx))
+	require.NoError(t, facade.Resume(ctx))
+
+	// 条件を満たさなくても次のフェーズに進み、結果はskippedとして記録される
+	// 遷移の辺はskippedの結果で照合され、完了時の辺には従わない
+	start := fake.Now()
+	require.NoError(t, facade.Skip(ctx))
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "REPAIR"
+	}, time.Second, time.Millisecond)
+	phases := facade.GetController().GetPhases()
+	broken := phases.GetByID(1)
+	assert.Equal(t, value.StateFinish, broken.CurrentState())
+	assert.Equal(t, value.OutcomeSkipped, broken.Outcome)
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+
+	// 飛ばしたフェーズは遷移を待たない
+	assert.Equal(t, start, fake.Now())
+}
+
+func TestGameFacadeSkipStopsTimers(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("WAIT").ID(1).All(Timer("wait", 5).ID(1)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	fake.Advance(2 * time.Second)
+	require.NoError(t, facade.Skip(ctx))
+	require.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+
+	// 飛ばしたフェーズのタイマーは止まっており、時間が経っても発火しない
+	entries := len(facade.Journal().Entries())
+	fake.Advance(10 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	part, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.False(t, part.IsSatisfied())
+	assert.Len(t, facade.Journal().Entries(), entries)
+	assert.Equal(t, "AFTER", currentLeafName(facade))
+}
+
+func TestGameFacadeJumpTo(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("INTRO").ID(1).All(Counter("intro").ID(1).GTE(1)),
+		Phase("STAGE").ID(2).AutoProgress().Children(
+			Phase("STEP1").ID(3).All(Counter("step1").ID(2).GTE(1)),
+			Phase("STEP2").ID(4).All(Counter("step2").ID(3).GTE(1)),
+		),
+		Phase("OUTRO").ID(5).All(Counter("outro").ID(4).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	assert.Error(t, facade.JumpTo(ctx, 99))
+
+	// 前に進むと、離れたフェーズは飛ばしたものとして終わり、移り先の祖先がアクティブになる
+	require.NoError(t, facade.JumpTo(ctx, 4))
+	phases := facade.GetController().GetPhases()
+	assert.Equal(t, "STEP2", currentLeafName(facade))
+	assert.Equal(t, value.StateFinish, phases.GetByID(1).CurrentState())
+	assert.Equal(t, value.OutcomeSkipped, phases.GetByID(1).Outcome)
+	assert.Equal(t, value.StateActive, phases.GetByID(2).CurrentState())
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+	assert.Equal(t, value.StateActive, phases.GetByID(4).CurrentState())
+
+	// 移り先からは通常どおり進む
+	_, err := facade.EvaluatePart(ctx, 3, 3, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "OUTRO"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, value.StateFinish, phases.GetByID(2).CurrentState())
+
+	// 後ろに戻ると、移り先より後ろのフェーズはリセットされる
+	require.NoError(t, facade.JumpTo(ctx, 1))
+	assert.Equal(t, "INTRO", currentLeafName(facade))
+	assert.Equal(t, value.StateActive, phases.GetByID(1).CurrentState())
+	assert.Equal(t, value.OutcomeNone, phases.GetByID(1).Outcome)
+	for _, id := range []value.PhaseID{2, 3, 4, 5} {
+		assert.Equal(t, value.StateReady, phases.GetByID(id).CurrentState(), "phase %d", id)
+	}
+
+	var jumps []value.PhaseID
+	for _, entry := range facade.Journal().Entries() {
+		if entry.Kind == JournalAction && entry.Action == ActionJump {
+			jumps = append(jumps, entry.PhaseID)
+		}
+	}
+	assert.Equal(t, []value.PhaseID{99, 4, 1}, jumps)
+}
+
+func TestGameFacadeJumpToParallelRegion(t *testing.T) {
+	ctx := context.Background()
+	facade := newParallelFacade(t, newFakeClock(), value.ParallelAll)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 後ろのフェーズから並列領域に戻ると、移り先を含まない領域も開始される
+	require.NoError(t, facade.JumpTo(ctx, 4))
+	assert.Equal(t, "ESCAPE", currentLeafName(facade))
+	require.NoError(t, facade.JumpTo(ctx, 3))
+	assert.Equal(t, []string{"VAULT", "GUARDS"}, currentLeafNames(facade))
+	assert.Equal(t, value.StateReady, facade.GetController().GetPhases().GetByID(4).CurrentState())
+}
+
+func TestGameFacadeRewind(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("INTRO").ID(1).All(Counter("intro").ID(1).GTE(1)),
+		Phase("STAGE").ID(2).Children(
+			Phase("STEP1").ID(3).All(Counter("step1").ID(2).GTE(1)),
+			Phase("STEP2").ID(4).All(Counter("step2").ID(3).GTE(1)),
+		),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 最初のフェーズより前には戻れない
+	assert.Error(t, facade.Rewind(ctx))
+
+	require.NoError(t, facade.JumpTo(ctx, 4))
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, "STEP1", currentLeafName(facade))
+
+	// 最初の子フェーズからは親フェーズの1つ前のフェーズに戻る
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, "INTRO", currentLeafName(facade))
+	assert.Equal(t, value.StateReady, facade.GetController().GetPhases().GetByID(2).CurrentState())
+}
+
+func TestGameFacadeRewindBranching(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("LOBBY").ID(1).GotoOnOutcome(value.OutcomeSucceeded, 4).All(Counter("lobby").ID(1).GTE(1)),
+		Phase("SIDE").ID(2).All(Counter("side").ID(2).GTE(1)),
+		Phase("TRAP").ID(3).All(Counter("trap").ID(3).GTE(1)),
+		Phase("BOSS").ID(4).GotoOnFailure(2).All(Counter("boss").ID(4).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 辺で LOBBY -> BOSS、失敗時の移り先で BOSS -> SIDE と進む
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return currentLeafName(facade) == "BOSS"
+	}, time.Second, time.Millisecond)
+	require.NoError(t, facade.Abort(ctx))
+	require.Eventually(t, func() bool {
+		return currentLeafName(facade) == "SIDE"
+	}, time.Second, time.Millisecond)
+
+	// Orderではなく実際に通った経路を戻る
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, "BOSS", currentLeafName(facade))
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, "LOBBY", currentLeafName(facade))
+	assert.Error(t, facade.Rewind(ctx))
+
+	phases := facade.GetController().GetPhases()
+	assert.Equal(t, value.StateReady, phases.GetByID(3).CurrentState())
+}
+
+func TestGameFacadeRewindParallel(t *testing.T) {
+	ctx := context.Background()
+	facade := newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("INTRO").ID(1).All(Counter("intro").ID(1).GTE(1)),
+		Phase("HEIST").ID(2).Parallel(value.ParallelAll).Children(
+			Phase("VAULT").ID(3).Children(
+				Phase("DRILL").ID(4).All(Counter("drill").ID(2).GTE(1)),
+				Phase("OPEN").ID(5).All(Counter("open").ID(3).GTE(1)),
+			),
+			Phase("GUARDS").ID(6).All(Counter("guards").ID(4).GTE(1)),
+		),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	require.NoError(t, facade.JumpTo(ctx, 5))
+	assert.Equal(t, []string{"OPEN", "GUARDS"}, currentLeafNames(facade))
+
+	// 複数の領域が動いている場合は、1つの領域の中ではなく並列フェーズの1つ前のフェーズに戻る
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, []string{"INTRO"}, currentLeafNames(facade))
+	assert.Equal(t, "INTRO", facade.GetCurrentPhase(0).Name)
+	phases := facade.GetController().GetPhases()
+	for _, id := range []value.PhaseID{2, 3, 4, 5, 6} {
+		assert.Equal(t, value.StateReady, phases.GetByID(id).CurrentState(), "phase %d", id)
+	}
+
+	// 動いている領域が1つだけの場合は、その領域の中で1つ前のフェーズに戻る
+	require.NoError(t, facade.JumpTo(ctx, 5))
+	_, err := facade.EvaluatePart(ctx, 4, 4, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "OPEN"
+	}, time.Second, time.Millisecond)
+	require.NoError(t, facade.Rewind(ctx))
+	assert.Equal(t, []string{"DRILL"}, currentLeafNames(facade))
+	assert.Equal(t, value.StateFinish, phases.GetByID(6).CurrentState())
+}
+
+func TestGameFacadeWindowCondition(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("RAPID").ID(1).All(Window("hit", 3).ID(1).GTE(3)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 間隔が空きすぎると古い入力は数えられない
+	for i := 0; i < 2; i++ {
+		_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+		require.NoError(t, err)
+		fake.Advance(2 * time.Second)
+	}
+	part, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return part.GetCurrentValue() == int64(1) }, time.Second, time.Millisecond)
+	_, err = facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "RAPID", currentLeafName(facade))
+
+	_, err = facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+}
+
+func TestGameFacadeSustainedCondition(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newFacadeWithFakeClock(t, fake,
+		Phase("PLATE").ID(1).All(Sustained("plate", 3).ID(1).GTE(1)),
+		Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+	)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 乗っている時間が足りないうちに降りると、保持時間は数え直しになる
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	fake.Advance(2 * time.Second)
+	_, err = facade.EvaluatePart(ctx, 1, 1, -1)
+	require.NoError(t, err)
+	fake.Advance(time.Minute)
+	assert.Equal(t, "PLATE", currentLeafName(facade))
+
+	part, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+	fake.Advance(2 * time.Second)
+	assert.Equal(t, int64(1000), part.GetCurrentValue())
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+}
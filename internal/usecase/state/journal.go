@@ -0,0 +1,192 @@
+package state
+
+import (
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// JournalEntryKind はジャーナルエントリの種類を表す型です
+type JournalEntryKind string
+
+const (
+	JournalPhaseTransition     JournalEntryKind = "phase_transition"     // フェーズの状態遷移
+	JournalConditionTransition JournalEntryKind = "condition_transition" // 条件の状態遷移
+	JournalPartTransition      JournalEntryKind = "part_transition"      // 条件パーツの状態遷移
+	JournalEvaluate            JournalEntryKind = "evaluate"             // 条件パーツへの評価値の入力
+	JournalAction              JournalEntryKind = "action"               // オペレーター操作(start / reset / pause / resume / abort / skip / jump / rewind)
+)
+
+// JournalEntry はジャーナルに記録される1件の出来事です
+type JournalEntry struct {
+	Seq         uint64                `json:"seq"`
+	Timestamp   time.Time             `json:"timestamp"`
+	Kind        JournalEntryKind      `json:"kind"`
+	PhaseID     value.PhaseID         `json:"phase_id,omitempty"`
+	ConditionID value.ConditionID     `json:"condition_id,omitempty"`
+	PartID      value.ConditionPartID `json:"part_id,omitempty"`
+	Event       string                `json:"event,omitempty"`
+	From        string                `json:"from,omitempty"`
+	To          string                `json:"to,omitempty"`
+	Action      string                `json:"action,omitempty"`
+	Increment   int64                 `json:"increment,omitempty"`
+	Value       interface{}           `json:"value,omitempty"` // 小数・文字列条件への入力値
+}
+
+// Journal は状態遷移と入力を発生順に記録する追記専用のログです
+type Journal struct {
+	entries        []JournalEntry
+	seq            uint64
+	conditionPhase map[value.ConditionID]value.PhaseID
+	partCondition  map[value.ConditionPartID]value.ConditionID
+	clock          clock.Clock
+	mu             sync.RWMutex
+	log            *zap.Logger
+}
+
+// NewJournal は空のJournalを作成します
+func NewJournal() *Journal {
+	return &Journal{
+		entries:        make([]JournalEntry, 0),
+		conditionPhase: make(map[value.ConditionID]value.PhaseID),
+		partCondition:  make(map[value.ConditionPartID]value.ConditionID),
+		clock:          clock.Real(),
+		log:            logger.DefaultLogger(),
+	}
+}
+
+// Attach はフェーズツリーの全エンティティの状態遷移を記録するように登録します
+func (j *Journal) Attach(phases entity.Phases) {
+	j.mu.Lock()
+	for _, phase := range phases {
+		for _, cond := range phase.GetConditions() {
+			j.conditionPhase[cond.ID] = phase.ID
+			for _, part := range cond.GetParts() {
+				j.partCondition[part.ID] = cond.ID
+			}
+		}
+	}
+	j.mu.Unlock()
+
+	for _, phase := range phases {
+		phase.AddTransitionObserver(j)
+		for _, cond := range phase.GetConditions() {
+			cond.AddTransitionObserver(j)
+			for _, part := range cond.GetParts() {
+				part.AddTransitionObserver(j)
+			}
+		}
+	}
+}
+
+// Append はエントリに連番とタイムスタンプを付けて追記します
+func (j *Journal) Append(entry JournalEntry) JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.seq++
+	entry.Seq = j.seq
+	entry.Timestamp = j.clock.Now()
+	j.entries = append(j.entries, entry)
+
+	j.log.Debug("Journal.Append",
+		zap.Uint64("seq", entry.Seq),
+		zap.String("kind", string(entry.Kind)),
+		zap.String("event", entry.Event),
+		zap.String("from", entry.From),
+		zap.String("to", entry.To))
+	return entry
+}
+
+// Entries は記録された全エントリのコピーを返します
+func (j *Journal) Entries() []JournalEntry {
+	return j.Since(0)
+}
+
+// Since は指定した連番より後のエントリのコピーを返します
+func (j *Journal) Since(seq uint64) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	entries := make([]JournalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		if entry.Seq > seq {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// OnTransition はエンティティの状態遷移をジャーナルに記録します
+func (j *Journal) OnTransition(entityObj interface{}, event, from, to string) {
+	entry := JournalEntry{Event: event, From: from, To: to}
+
+	switch e := entityObj.(type) {
+	case *entity.Phase:
+		entry.Kind = JournalPhaseTransition
+		entry.PhaseID = e.ID
+	case *entity.Condition:
+		entry.Kind = JournalConditionTransition
+		entry.ConditionID = e.ID
+		entry.PhaseID = j.phaseOf(e.ID)
+	case *entity.ConditionPart:
+		entry.Kind = JournalPartTransition
+		entry.PartID = e.ID
+		entry.ConditionID = j.conditionOf(e.ID)
+		entry.PhaseID = j.phaseOf(entry.ConditionID)
+	default:
+		j.log.Error("Journal.OnTransition: unknown entity type", zap.Any("entity", entityObj))
+		return
+	}
+
+	j.Append(entry)
+}
+
+// recordAction はオペレーター操作を記録します
+func (j *Journal) recordAction(action string) {
+	j.Append(JournalEntry{Kind: JournalAction, Action: action})
+}
+
+// recordJump は指定したフェーズへ移るオペレーター操作を記録します
+func (j *Journal) recordJump(id value.PhaseID) {
+	j.Append(JournalEntry{Kind: JournalAction, Action: ActionJump, PhaseID: id})
+}
+
+// recordEvaluate は条件パーツへの評価値の入力を記録します
+func (j *Journal) recordEvaluate(conditionID value.ConditionID, partID value.ConditionPartID, increment int64) {
+	j.Append(JournalEntry{
+		Kind:        JournalEvaluate,
+		PhaseID:     j.phaseOf(conditionID),
+		ConditionID: conditionID,
+		PartID:      partID,
+		Increment:   increment,
+	})
+}
+
+// recordEvaluateValue は条件パーツへの型付きの評価値の入力を記録します
+func (j *Journal) recordEvaluateValue(conditionID value.ConditionID, partID value.ConditionPartID, input interface{}) {
+	j.Append(JournalEntry{
+		Kind:        JournalEvaluate,
+		PhaseID:     j.phaseOf(conditionID),
+		ConditionID: conditionID,
+		PartID:      partID,
+		Value:       input,
+	})
+}
+
+func (j *Journal) phaseOf(conditionID value.ConditionID) value.PhaseID {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.conditionPhase[conditionID]
+}
+
+func (j *Journal) conditionOf(partID value.ConditionPartID) value.ConditionID {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.partCondition[partID]
+}
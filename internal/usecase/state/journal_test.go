@@ -0,0 +1,415 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJournalTestFacade はジャーナルのテスト用のGameFacadeを作成します
+func newJournalTestFacade(t *testing.T) *GameFacade {
+	return newFacadeWithFakeClock(t, newFakeClock(),
+		Phase("FIRST").ID(1).Any(Counter("hit").ID(1).GTE(2)),
+		Phase("SECOND").ID(2).Any(
+			Counter("push").ID(2).GTE(3),
+			Timer("wait", 60).ID(3),
+		),
+	)
+}
+
+// transitionKey は比較用にエントリから連番とタイムスタンプを除いたものです
+type transitionKey struct {
+	Kind   JournalEntryKind
+	ID     int64
+	Event  string
+	From   string
+	To     string
+	Action string
+}
+
+// transitionKeys はジャーナルのエントリを比較用に変換します
+func transitionKeys(entries []JournalEntry) []transitionKey {
+	keys := make([]transitionKey, 0, len(entries))
+	for _, e := range entries {
+		id := int64(e.PhaseID)
+		switch e.Kind {
+		case JournalConditionTransition:
+			id = int64(e.ConditionID)
+		case JournalPartTransition, JournalEvaluate:
+			id = int64(e.PartID)
+		}
+		keys = append(keys, transitionKey{Kind: e.Kind, ID: id, Event: e.Event, From: e.From, To: e.To, Action: e.Action})
+	}
+	return keys
+}
+
+func TestJournalRecordsTransitions(t *testing.T) {
+	ctx := context.Background()
+	facade := newJournalTestFacade(t)
+	require.NoError(t, facade.Start(ctx))
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+
+	entries := facade.Journal().Entries()
+	require.NotEmpty(t, entries)
+
+	// 連番は1から隙間なく振られる
+	for i, entry := range entries {
+		assert.Equal(t, uint64(i+1), entry.Seq)
+		assert.False(t, entry.Timestamp.IsZero())
+	}
+
+	assert.Equal(t, JournalAction, entries[0].Kind)
+	assert.Equal(t, ActionStart, entries[0].Action)
+
+	assert.Equal(t, JournalPhaseTransition, entries[1].Kind)
+	assert.Equal(t, value.PhaseID(1), entries[1].PhaseID)
+	assert.Equal(t, value.StateReady, entries[1].From)
+	assert.Equal(t, value.StateActive, entries[1].To)
+
+	// 評価値の入力と、それによるパーツの遷移が記録される
+	var evaluate, processing *JournalEntry
+	for i := range entries {
+		switch {
+		case entries[i].Kind == JournalEvaluate:
+			evaluate = &entries[i]
+		case entries[i].Kind == JournalPartTransition && entries[i].To == value.StateProcessing:
+			processing = &entries[i]
+		}
+	}
+	require.NotNil(t, evaluate)
+	assert.Equal(t, value.PhaseID(1), evaluate.PhaseID)
+	assert.Equal(t, value.ConditionID(1), evaluate.ConditionID)
+	assert.Equal(t, value.ConditionPartID(1), evaluate.PartID)
+	assert.Equal(t, int64(1), evaluate.Increment)
+
+	require.NotNil(t, processing)
+	assert.Greater(t, processing.Seq, evaluate.Seq)
+	assert.Equal(t, value.PhaseID(1), processing.PhaseID)
+	assert.Equal(t, value.ConditionID(1), processing.ConditionID)
+
+	assert.Len(t, facade.Journal().Since(processing.Seq), len(entries)-int(processing.Seq))
+}
+
+func TestJournalSkipsRejectedStart(t *testing.T) {
+	ctx := context.Background()
+	facade := newJournalTestFacade(t)
+
+	// 構造上の問題で開始しなかった場合は記録せず、再生でも開始しない
+	part, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	part.ComparisonOperator = value.ComparisonOperatorUnspecified
+	require.Error(t, facade.Start(ctx))
+	assert.Empty(t, facade.Journal().Entries())
+
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	entries := facade.Journal().Entries()
+	require.NotEmpty(t, entries)
+	assert.Equal(t, ActionStart, entries[0].Action)
+}
+
+func TestReplayRebuildsState(t *testing.T) {
+	ctx := context.Background()
+	facade := newJournalTestFacade(t)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// FIRSTを完了させてSECONDに進め、SECONDのカウンターを途中まで進める
+	for i := 0; i < 2; i++ {
+		_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+		require.NoError(t, err)
+	}
+	_, err := facade.EvaluatePart(ctx, 2, 2, 2)
+	require.NoError(t, err)
+
+	// 存在しないパーツへの入力は記録されない
+	_, err = facade.EvaluatePart(ctx, 9, 9, 1)
+	require.Error(t, err)
+
+	replayed := newJournalTestFacade(t)
+	require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	assert.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()))
+
+	current := replayed.GetCurrentPhase(0)
+	require.NotNil(t, current)
+	assert.Equal(t, "SECOND", current.Name)
+	assert.Equal(t, value.StateActive, current.CurrentState())
+
+	part, err := replayed.GetConditionPart(2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), part.GetCurrentValue())
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	// 複数の条件を持つフェーズのリセットを含むジャーナルが、何度再生しても同じ順序で記録される
+	for i := 0; i < 50; i++ {
+		facade := newJournalTestFacade(t)
+		require.NoError(t, facade.Start(ctx))
+		_, err := facade.EvaluatePart(ctx, 1, 1, 2)
+		require.NoError(t, err)
+		_, err = facade.EvaluatePart(ctx, 2, 2, 1)
+		require.NoError(t, err)
+		require.NoError(t, facade.Reset(ctx))
+
+		replayed := newJournalTestFacade(t)
+		require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+
+		require.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()), "run %d", i)
+		_ = replayed.Reset(ctx)
+	}
+}
+
+func TestReplayOperatorControls(t *testing.T) {
+	ctx := context.Background()
+	facade := newJournalTestFacade(t)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 飛ばす・移る・戻るの操作と移り先のフェーズが記録され、同じ順で再生される
+	require.NoError(t, facade.Skip(ctx))
+	require.NoError(t, facade.Rewind(ctx))
+	require.NoError(t, facade.JumpTo(ctx, 2))
+
+	var actions []JournalEntry
+	for _, entry := range facade.Journal().Entries() {
+		if entry.Kind == JournalAction {
+			actions = append(actions, entry)
+		}
+	}
+	require.Len(t, actions, 4)
+	assert.Equal(t, ActionSkip, actions[1].Action)
+	assert.Equal(t, ActionRewind, actions[2].Action)
+	assert.Equal(t, ActionJump, actions[3].Action)
+	assert.Equal(t, value.PhaseID(2), actions[3].PhaseID)
+
+	replayed := newJournalTestFacade(t)
+	require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	assert.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()))
+	assert.Equal(t, "SECOND", replayed.GetCurrentPhase(0).Name)
+	assert.Equal(t, value.OutcomeSkipped, replayed.GetController().GetPhases().GetByID(1).Outcome)
+}
+
+func TestReplayTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// タイマーの発火はパーツのtimeout遷移として記録されている
+	entries := []JournalEntry{
+		{Seq: 1, Kind: JournalAction, Action: ActionStart},
+		{Seq: 2, Kind: JournalEvaluate, ConditionID: 1, PartID: 1, Increment: 2},
+		{Seq: 3, Kind: JournalPartTransition, PhaseID: 2, ConditionID: 3, PartID: 3,
+			Event: value.EventTimeout, From: value.StateUnsatisfied, To: value.StateSatisfied},
+	}
+
+	replayed := newJournalTestFacade(t)
+	require.NoError(t, Replay(ctx, replayed, entries))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	part, err := replayed.GetConditionPart(3, 3)
+	require.NoError(t, err)
+	assert.True(t, part.IsSatisfied())
+	// 最後のフェーズなのでコントローラーによって終了まで進む
+	assert.Equal(t, value.StateFinish, replayed.GetCurrentPhase(0).CurrentState())
+}
+
+func TestReplayDeadlineFailure(t *testing.T) {
+	ctx := context.Background()
+	build := func(fake *clock.Fake) *GameFacade {
+		return newFacadeWithFakeClock(t, fake,
+			Phase("CHALLENGE").ID(1).GotoOnFailure(3).All(Counter("hit").ID(1).GTE(3).Deadline(5)),
+			Phase("BONUS").ID(2).All(Counter("bonus").ID(2).GTE(1)),
+			Phase("CONSOLATION").ID(3).All(Counter("consolation").ID(3).GTE(1)),
+		)
+	}
+
+	fake := newFakeClock()
+	facade := build(fake)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+
+	// 期限切れによるパーツの失敗は、パーツのfail遷移として記録される
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "CONSOLATION"
+	}, time.Second, time.Millisecond)
+
+	// 再生側の時計は進めなくても、記録された期限切れで失敗時の遷移先に移る
+	replayed := build(newFakeClock())
+	require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	assert.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()))
+	assert.Equal(t, "CONSOLATION", currentLeafName(replayed))
+	challenge := replayed.GetController().GetPhases().GetByID(1)
+	assert.True(t, challenge.IsFailed())
+	assert.Equal(t, value.OutcomeFailed, challenge.Outcome)
+}
+
+func TestReplaySustainedHold(t *testing.T) {
+	ctx := context.Background()
+	build := func(fake *clock.Fake) *GameFacade {
+		return newFacadeWithFakeClock(t, fake,
+			Phase("PLATE").ID(1).All(Sustained("plate", 3).ID(1).GTE(1)),
+			Phase("AFTER").ID(2).All(Counter("next").ID(2).GTE(1)),
+		)
+	}
+
+	fake := newFakeClock()
+	facade := build(fake)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	_, err := facade.EvaluatePart(ctx, 1, 1, 1)
+	require.NoError(t, err)
+
+	// 保持時間の満了はパーツのtimeout遷移として記録される
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool {
+		return currentLeafName(facade) == "AFTER"
+	}, time.Second, time.Millisecond)
+
+	// 再生側の時計を進めなくても、記録された満了で次のフェーズに進む
+	replayed := build(newFakeClock())
+	require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	assert.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()))
+	assert.Equal(t, "AFTER", currentLeafName(replayed))
+}
+
+func TestReplayWindowExpiry(t *testing.T) {
+	ctx := context.Background()
+	build := func(fake *clock.Fake) *GameFacade {
+		return newFacadeWithFakeClock(t, fake,
+			Phase("RAPID").ID(1).All(
+				Window("hit", 3).ID(1).Live().GTE(2),
+				Counter("door").ID(2).GTE(1),
+			),
+			Phase("AFTER").ID(2).All(Counter("next").ID(3).GTE(1)),
+		)
+	}
+
+	fake := newFakeClock()
+	facade := build(fake)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	_, err := facade.EvaluatePart(ctx, 1, 1, 2)
+	require.NoError(t, err)
+	part, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	require.True(t, part.IsSatisfied())
+
+	// 入力が窓から外れてライブのパーツが未達成に戻ると、パーツのexpire遷移として記録される
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return !part.IsSatisfied() }, time.Second, time.Millisecond)
+	_, err = facade.EvaluatePart(ctx, 2, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "RAPID", currentLeafName(facade))
+
+	// 再生側の時計を進めなくても、記録された期限切れでパーツが未達成に戻り、フェーズは完了しない
+	replayed := build(newFakeClock())
+	require.NoError(t, Replay(ctx, replayed, facade.Journal().Entries()))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	assert.Equal(t, transitionKeys(facade.Journal().Entries()), transitionKeys(replayed.Journal().Entries()))
+	assert.Equal(t, "RAPID", currentLeafName(replayed))
+	replayedPart, err := replayed.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.False(t, replayedPart.IsSatisfied())
+}
+
+func TestReplayRequiresFakeClock(t *testing.T) {
+	ctx := context.Background()
+	entries := []JournalEntry{{Seq: 1, Kind: JournalAction, Action: ActionStart}}
+	build := func(c clock.Clock) *GameFacade {
+		phases, err := BuildPhases(Phase("ONLY").ID(1).All(Counter("hit").ID(1).GTE(1)))
+		require.NoError(t, err)
+		return NewGameFacadeWithClock(phases, c)
+	}
+
+	// 実時間のfacadeは動いているタイマーが発火するため再生しない
+	live := build(clock.Real())
+	assert.Error(t, Replay(ctx, live, entries))
+	assert.Equal(t, value.StateReady, live.GetController().GetPhases().GetByID(1).CurrentState())
+
+	// 遷移の待ち時間が残っているとFakeのSleepで止まるため再生しない
+	delayed := build(newFakeClock())
+	delayed.GetController().SetTransitionDelay(DefaultTransitionDelay)
+	assert.Error(t, Replay(ctx, delayed, entries))
+
+	replayed := newJournalTestFacade(t)
+	require.NoError(t, Replay(ctx, replayed, entries))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+}
+
+func TestReplayReportsFailedEntries(t *testing.T) {
+	ctx := context.Background()
+	entries := []JournalEntry{
+		{Seq: 1, Kind: JournalEvaluate, ConditionID: 9, PartID: 9, Increment: 1},
+		{Seq: 2, Kind: JournalAction, Action: "jump"},
+		{Seq: 3, Kind: JournalAction, Action: ActionStart},
+	}
+
+	replayed := newJournalTestFacade(t)
+	err := Replay(ctx, replayed, entries)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seq 1")
+	assert.Contains(t, err.Error(), "seq 2")
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	// 失敗した入力があっても後続の入力は再生される
+	assert.Equal(t, value.StateActive, replayed.GetCurrentPhase(0).CurrentState())
+}
+
+func TestReplayTypedValues(t *testing.T) {
+	ctx := context.Background()
+	build := func() *GameFacade {
+		return newFacadeWithFakeClock(t, newFakeClock(),
+			Phase("MEASURE").ID(1).All(
+				Float("accuracy").ID(1).CompareFloat(value.ComparisonOperatorGTE, 0.9),
+				Text("door").ID(2).CompareString(value.ComparisonOperatorEQ, "open"),
+			),
+			Phase("DONE").ID(2).Any(Counter("hit").ID(3).GTE(1)),
+		)
+	}
+
+	facade := build()
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+	_, err := facade.EvaluatePartValue(ctx, 1, 1, 0.95)
+	require.NoError(t, err)
+	_, err = facade.EvaluatePartValue(ctx, 2, 2, "closed")
+	require.NoError(t, err)
+
+	// JSONを経由しても入力値の型が保たれる
+	data, err := json.Marshal(facade.Journal().Entries())
+	require.NoError(t, err)
+	var entries []JournalEntry
+	require.NoError(t, json.Unmarshal(data, &entries))
+
+	replayed := build()
+	require.NoError(t, Replay(ctx, replayed, entries))
+	t.Cleanup(func() { _ = replayed.Reset(ctx) })
+
+	accuracy, err := replayed.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.True(t, accuracy.IsSatisfied())
+	door, err := replayed.GetConditionPart(2, 2)
+	require.NoError(t, err)
+	assert.False(t, door.IsSatisfied())
+	assert.Equal(t, "closed", door.GetCurrentValue())
+}
@@ -0,0 +1,716 @@
+package state
+
+import (
+	"fmt"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/usecase/strategy"
+)
+
+// フェーズ階層を組み立てるためのビルダーです
+//
+//	phases, err := state.BuildPhases(
+//		state.Phase("ROOT").All(state.Timer("wait", 5)).Children(
+//			state.Phase("CHILD1").Any(state.Counter("hit").GTE(3)),
+//			state.Phase("CHILD2").Any(state.Counter("push").EQ(1)),
+//		),
+//	)
+//
+// パーツ -> 条件 -> フェーズ のオブザーバー接続と戦略の初期化はBuildPhasesが行うため、
+// 接続漏れでフェーズが進まないという状態は起こりません
+// IDとOrderは省略時に自動採番されます(Orderは兄弟内での記述順)
+
+// PhaseBuilder はフェーズの定義を組み立てるビルダーです
+type PhaseBuilder struct {
+	id            value.PhaseID
+	name          string
+	description   string
+	order         int
+	rule          value.GameRule
+	conditionType value.ConditionType
+	required      int
+	completion    *entity.CompletionExpr
+	autoProgress  bool
+	parallel      bool
+	parallelMode  value.ParallelCompletion
+	repeatCount   int
+	repeatUntil   *entity.CompletionExpr
+	onFailure     value.FailureAction
+	failureTarget value.PhaseID
+	maxRetries    int
+	transitions   []entity.PhaseTransition
+	conditions    []*ConditionBuilder
+	children      []*PhaseBuilder
+}
+
+// Phase は新しいPhaseBuilderを作成します
+func Phase(name string) *PhaseBuilder {
+	return &PhaseBuilder{name: name}
+}
+
+// ID はフェーズIDを明示的に指定します
+func (b *PhaseBuilder) ID(id value.PhaseID) *PhaseBuilder {
+	b.id = id
+	return b
+}
+
+// Order は兄弟フェーズ内での順序を明示的に指定します
+func (b *PhaseBuilder) Order(order int) *PhaseBuilder {
+	b.order = order
+	return b
+}
+
+// Description はフェーズの説明を設定します
+func (b *PhaseBuilder) Description(description string) *PhaseBuilder {
+	b.description = description
+	return b
+}
+
+// Rule はゲームルールを設定します
+func (b *PhaseBuilder) Rule(rule value.GameRule) *PhaseBuilder {
+	b.rule = rule
+	return b
+}
+
+// AutoProgress は子フェーズ完了時に自動的に進捗するようにします
+func (b *PhaseBuilder) AutoProgress() *PhaseBuilder {
+	b.autoProgress = true
+	return b
+}
+
+// Parallel は子フェーズを並列領域として同時に実行するようにします
+// completionは親フェーズが完了する条件で、全ての領域の完了を待つか、いずれかの領域の完了で完了するかを指定します
+func (b *PhaseBuilder) Parallel(completion value.ParallelCompletion) *PhaseBuilder {
+	b.parallel = true
+	b.parallelMode = completion
+	return b
+}
+
+// Repeat は完了したフェーズを次のフェーズに進めず、指定した回数だけ繰り返すようにします
+func (b *PhaseBuilder) Repeat(count int) *PhaseBuilder {
+	b.repeatCount = count
+	return b
+}
+
+// RepeatUntil は完了したフェーズを、完了時に式が真になるまで繰り返すようにします
+// 式は条件IDを参照するため、条件のIDは明示的に指定してください。maxIterationsは繰り返し回数の上限で、0の場合は上限なしです
+func (b *PhaseBuilder) RepeatUntil(expr *entity.CompletionExpr, maxIterations int) *PhaseBuilder {
+	b.repeatUntil = expr
+	b.repeatCount = maxIterations
+	return b
+}
+
+// RetryOnFailure は失敗した場合にフェーズを最初からやり直すようにします
+// maxRetriesはやり直し回数の上限で、0の場合は上限なしです
+func (b *PhaseBuilder) RetryOnFailure(maxRetries int) *PhaseBuilder {
+	b.onFailure = value.FailureRetry
+	b.maxRetries = maxRetries
+	return b
+}
+
+// GotoOnFailure は失敗した場合に指定した兄弟フェーズに移るようにします
+func (b *PhaseBuilder) GotoOnFailure(id value.PhaseID) *PhaseBuilder {
+	b.onFailure = value.FailureGoto
+	b.failureTarget = id
+	return b
+}
+
+// GotoOn は指定した条件が満たされてフェーズが完了した場合に、指定した兄弟フェーズに移るようにします
+// conditionIDが0の場合はどの条件で完了しても移ります。toが0の場合は兄弟フェーズに移らずに終えます
+func (b *PhaseBuilder) GotoOn(conditionID value.ConditionID, to value.PhaseID) *PhaseBuilder {
+	b.transitions = append(b.transitions, entity.PhaseTransition{ConditionID: conditionID, To: to})
+	return b
+}
+
+// GotoOnOutcome はフェーズが指定した結果で終わった場合に、指定した兄弟フェーズに移るようにします
+func (b *PhaseBuilder) GotoOnOutcome(outcome value.PhaseOutcome, to value.PhaseID) *PhaseBuilder {
+	b.transitions = append(b.transitions, entity.PhaseTransition{Outcome: outcome, To: to})
+	return b
+}
+
+// All はすべての条件を満たす必要があるフェーズにします
+func (b *PhaseBuilder) All(conditions ...*ConditionBuilder) *PhaseBuilder {
+	b.conditionType = value.ConditionTypeAnd
+	b.conditions = append(b.conditions, conditions...)
+	return b
+}
+
+// Any はいずれかの条件を満たせばよいフェーズにします
+func (b *PhaseBuilder) Any(conditions ...*ConditionBuilder) *PhaseBuilder {
+	b.conditionType = value.ConditionTypeOr
+	b.conditions = append(b.conditions, conditions...)
+	return b
+}
+
+// AtLeast はk個以上の条件を満たせばよいフェーズにします
+func (b *PhaseBuilder) AtLeast(k int, conditions ...*ConditionBuilder) *PhaseBuilder {
+	b.conditionType = value.ConditionTypeThreshold
+	b.required = k
+	b.conditions = append(b.conditions, conditions...)
+	return b
+}
+
+// When は完了条件式を満たした時に完了するフェーズにします
+// 式は条件IDを参照するため、条件のIDは明示的に指定してください
+func (b *PhaseBuilder) When(expr *entity.CompletionExpr, conditions ...*ConditionBuilder) *PhaseBuilder {
+	b.completion = expr
+	b.conditions = append(b.conditions, conditions...)
+	return b
+}
+
+// Children は子フェーズを追加します
+func (b *PhaseBuilder) Children(children ...*PhaseBuilder) *PhaseBuilder {
+	b.children = append(b.children, children...)
+	return b
+}
+
+// ConditionBuilder は条件の定義を組み立てるビルダーです
+type ConditionBuilder struct {
+	id            value.ConditionID
+	label         string
+	description   string
+	kind          value.ConditionKind
+	combination   value.PartCombination
+	requiredParts int
+	ordered       bool
+	parts         []*PartBuilder
+}
+
+// Condition は指定された種類とパーツを持つConditionBuilderを作成します
+func Condition(label string, kind value.ConditionKind, parts ...*PartBuilder) *ConditionBuilder {
+	return &ConditionBuilder{label: label, kind: kind, parts: parts}
+}
+
+// Counter はパーツを1つ持つカウンター条件を作成します
+func Counter(label string) *ConditionBuilder {
+	return Condition(label, value.KindCounter, Part(label))
+}
+
+// Float はパーツを1つ持つ小数の測定値の条件を作成します
+func Float(label string) *ConditionBuilder {
+	return Condition(label, value.KindFloat, Part(label))
+}
+
+// Text はパーツを1つ持つ文字列の入力値の条件を作成します
+func Text(label string) *ConditionBuilder {
+	return Condition(label, value.KindString, Part(label))
+}
+
+// Sustained はカウンターの比較が指定秒数成り立ち続けると満たされる持続条件を作成します
+func Sustained(label string, seconds int64) *ConditionBuilder {
+	return Condition(label, value.KindSustained, Part(label).Duration(seconds))
+}
+
+// Window は指定秒数内の入力回数を比較する時間窓条件を作成します
+func Window(label string, seconds int64) *ConditionBuilder {
+	return Condition(label, value.KindWindow, Part(label).Duration(seconds))
+}
+
+// Sequence は指定した順番で値が入力されると満たされる順序入力条件を作成します
+func Sequence(label string, steps ...int64) *ConditionBuilder {
+	return Condition(label, value.KindSequence, Part(label).Steps(steps...))
+}
+
+// Timer は指定秒数の経過で満たされる時間条件を作成します
+func Timer(label string, seconds int64) *ConditionBuilder {
+	return Condition(label, value.KindTime, Part(label).Value(seconds))
+}
+
+// ID は条件IDを明示的に指定します
+func (b *ConditionBuilder) ID(id value.ConditionID) *ConditionBuilder {
+	b.id = id
+	return b
+}
+
+// Description は条件の説明を設定します
+func (b *ConditionBuilder) Description(description string) *ConditionBuilder {
+	b.description = description
+	return b
+}
+
+// AnyPart はいずれかのパーツを満たせば条件を満たすようにします
+func (b *ConditionBuilder) AnyPart() *ConditionBuilder {
+	b.combination = value.PartCombinationAny
+	return b
+}
+
+// AtLeastParts はn個以上のパーツを満たせば条件を満たすようにします
+func (b *ConditionBuilder) AtLeastParts(n int) *ConditionBuilder {
+	b.combination = value.PartCombinationAtLeast
+	b.requiredParts = n
+	return b
+}
+
+// Ordered はパーツを優先度の順にしか満たせないようにします
+func (b *ConditionBuilder) Ordered() *ConditionBuilder {
+	b.ordered = true
+	return b
+}
+
+// StayOnMiss は条件内のすべてのパーツを、誤った入力があっても進み具合を保つようにします
+func (b *ConditionBuilder) StayOnMiss() *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.StayOnMiss() })
+}
+
+// Live は条件内のすべてのパーツを、値が基準を外れると未達成に戻るようにします
+func (b *ConditionBuilder) Live() *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.Live() })
+}
+
+// Deadline は条件内のすべてのパーツに、満たす必要のある期限の秒数を設定します
+func (b *ConditionBuilder) Deadline(seconds int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.Deadline(seconds) })
+}
+
+// 以下の比較メソッドは条件内のすべてのパーツに適用されます
+// Counterのようにパーツが1つの条件で使うことを想定しています
+
+// EQ は値が基準値と等しいことを条件にします
+func (b *ConditionBuilder) EQ(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.EQ(v) })
+}
+
+// NEQ は値が基準値と異なることを条件にします
+func (b *ConditionBuilder) NEQ(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.NEQ(v) })
+}
+
+// GT は値が基準値より大きいことを条件にします
+func (b *ConditionBuilder) GT(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.GT(v) })
+}
+
+// GTE は値が基準値以上であることを条件にします
+func (b *ConditionBuilder) GTE(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.GTE(v) })
+}
+
+// LT は値が基準値より小さいことを条件にします
+func (b *ConditionBuilder) LT(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.LT(v) })
+}
+
+// LTE は値が基準値以下であることを条件にします
+func (b *ConditionBuilder) LTE(v int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.LTE(v) })
+}
+
+// Between は値がmin以上max以下であることを条件にします
+func (b *ConditionBuilder) Between(min, max int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.Between(min, max) })
+}
+
+// In は値が指定した値のいずれかと等しいことを条件にします
+func (b *ConditionBuilder) In(values ...int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.In(values...) })
+}
+
+// NotIn は値が指定した値のいずれとも異なることを条件にします
+func (b *ConditionBuilder) NotIn(values ...int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.NotIn(values...) })
+}
+
+// CompareFloat は小数の測定値を基準値と比較することを条件にします
+func (b *ConditionBuilder) CompareFloat(operator value.ComparisonOperator, v float64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.CompareFloat(operator, v) })
+}
+
+// BetweenFloat は小数の測定値がmin以上max以下であることを条件にします
+func (b *ConditionBuilder) BetweenFloat(min, max float64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.BetweenFloat(min, max) })
+}
+
+// CompareString は文字列の入力値を基準値と比較することを条件にします
+func (b *ConditionBuilder) CompareString(operator value.ComparisonOperator, v string) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.CompareString(operator, v) })
+}
+
+// InStrings は入力値が指定した文字列のいずれかと等しいことを条件にします
+func (b *ConditionBuilder) InStrings(values ...string) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.InStrings(values...) })
+}
+
+// NotInStrings は入力値が指定した文字列のいずれとも異なることを条件にします
+func (b *ConditionBuilder) NotInStrings(values ...string) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.NotInStrings(values...) })
+}
+
+// Target は対象エンティティを設定します
+func (b *ConditionBuilder) Target(entityType string, entityID int64) *ConditionBuilder {
+	return b.each(func(p *PartBuilder) { p.Target(entityType, entityID) })
+}
+
+func (b *ConditionBuilder) each(fn func(p *PartBuilder)) *ConditionBuilder {
+	for _, p := range b.parts {
+		fn(p)
+	}
+	return b
+}
+
+// PartBuilder は条件パーツの定義を組み立てるビルダーです
+type PartBuilder struct {
+	id               value.ConditionPartID
+	label            string
+	operator         value.ComparisonOperator
+	referenceValue   int64
+	referenceSet     []int64
+	referenceFloat   float64
+	referenceString  string
+	referenceStrings []string
+	minValue         int64
+	maxValue         int64
+	minValueFloat    float64
+	maxValueFloat    float64
+	durationSeconds  int64
+	onMiss           value.SequenceMissPolicy
+	targetEntityType string
+	targetEntityID   int64
+	priority         int32
+	live             bool
+	deadlineSeconds  int64
+}
+
+// Part は新しいPartBuilderを作成します
+func Part(label string) *PartBuilder {
+	return &PartBuilder{label: label}
+}
+
+// ID はパーツIDを明示的に指定します
+func (b *PartBuilder) ID(id value.ConditionPartID) *PartBuilder {
+	b.id = id
+	return b
+}
+
+// Value は比較演算子を変えずに基準値を設定します(時間条件の秒数など)
+func (b *PartBuilder) Value(v int64) *PartBuilder {
+	b.referenceValue = v
+	return b
+}
+
+// EQ は値が基準値と等しいことを条件にします
+func (b *PartBuilder) EQ(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorEQ, v)
+}
+
+// NEQ は値が基準値と異なることを条件にします
+func (b *PartBuilder) NEQ(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorNEQ, v)
+}
+
+// GT は値が基準値より大きいことを条件にします
+func (b *PartBuilder) GT(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorGT, v)
+}
+
+// GTE は値が基準値以上であることを条件にします
+func (b *PartBuilder) GTE(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorGTE, v)
+}
+
+// LT は値が基準値より小さいことを条件にします
+func (b *PartBuilder) LT(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorLT, v)
+}
+
+// LTE は値が基準値以下であることを条件にします
+func (b *PartBuilder) LTE(v int64) *PartBuilder {
+	return b.compare(value.ComparisonOperatorLTE, v)
+}
+
+// Between は値がmin以上max以下であることを条件にします
+func (b *PartBuilder) Between(min, max int64) *PartBuilder {
+	b.operator = value.ComparisonOperatorBetween
+	b.minValue = min
+	b.maxValue = max
+	return b
+}
+
+// In は値が指定した値のいずれかと等しいことを条件にします
+func (b *PartBuilder) In(values ...int64) *PartBuilder {
+	b.operator = value.ComparisonOperatorIn
+	b.referenceSet = append([]int64(nil), values...)
+	return b
+}
+
+// NotIn は値が指定した値のいずれとも異なることを条件にします
+func (b *PartBuilder) NotIn(values ...int64) *PartBuilder {
+	b.operator = value.ComparisonOperatorNotIn
+	b.referenceSet = append([]int64(nil), values...)
+	return b
+}
+
+// CompareFloat は小数の測定値を基準値と比較することを条件にします
+func (b *PartBuilder) CompareFloat(operator value.ComparisonOperator, v float64) *PartBuilder {
+	b.operator = operator
+	b.referenceFloat = v
+	return b
+}
+
+// BetweenFloat は小数の測定値がmin以上max以下であることを条件にします
+func (b *PartBuilder) BetweenFloat(min, max float64) *PartBuilder {
+	b.operator = value.ComparisonOperatorBetween
+	b.minValueFloat = min
+	b.maxValueFloat = max
+	return b
+}
+
+// CompareString は文字列の入力値を基準値と比較することを条件にします
+func (b *PartBuilder) CompareString(operator value.ComparisonOperator, v string) *PartBuilder {
+	b.operator = operator
+	b.referenceString = v
+	return b
+}
+
+// InStrings は入力値が指定した文字列のいずれかと等しいことを条件にします
+func (b *PartBuilder) InStrings(values ...string) *PartBuilder {
+	b.operator = value.ComparisonOperatorIn
+	b.referenceStrings = append([]string(nil), values...)
+	return b
+}
+
+// NotInStrings は入力値が指定した文字列のいずれとも異なることを条件にします
+func (b *PartBuilder) NotInStrings(values ...string) *PartBuilder {
+	b.operator = value.ComparisonOperatorNotIn
+	b.referenceStrings = append([]string(nil), values...)
+	return b
+}
+
+// Steps は順序入力条件で入力すべき値を順番に設定します
+func (b *PartBuilder) Steps(steps ...int64) *PartBuilder {
+	b.referenceSet = append([]int64(nil), steps...)
+	return b
+}
+
+// StayOnMiss は順序入力条件で誤った入力があっても進み具合を保つようにします
+func (b *PartBuilder) StayOnMiss() *PartBuilder {
+	b.onMiss = value.SequenceMissStay
+	return b
+}
+
+// Duration は時間を伴う条件で使う秒数を設定します
+func (b *PartBuilder) Duration(seconds int64) *PartBuilder {
+	b.durationSeconds = seconds
+	return b
+}
+
+// Target は対象エンティティを設定します
+func (b *PartBuilder) Target(entityType string, entityID int64) *PartBuilder {
+	b.targetEntityType = entityType
+	b.targetEntityID = entityID
+	return b
+}
+
+// Priority は優先度を設定します
+func (b *PartBuilder) Priority(priority int32) *PartBuilder {
+	b.priority = priority
+	return b
+}
+
+// Live は満たされた後も評価を続け、値が基準を外れると未達成に戻るようにします
+func (b *PartBuilder) Live() *PartBuilder {
+	b.live = true
+	return b
+}
+
+// Deadline は満たす必要のある期限の秒数を設定します。期限までに満たさないとパーツは失敗します
+func (b *PartBuilder) Deadline(seconds int64) *PartBuilder {
+	b.deadlineSeconds = seconds
+	return b
+}
+
+func (b *PartBuilder) compare(operator value.ComparisonOperator, v int64) *PartBuilder {
+	b.operator = operator
+	b.referenceValue = v
+	return b
+}
+
+// BuildPhases はビルダーからフェーズを生成します
+// 生成後にentity.ValidatePhasesで構造を検証します
+func BuildPhases(roots ...*PhaseBuilder) (entity.Phases, error) {
+	return BuildPhasesWithFactory(strategy.NewStrategyFactory(), roots...)
+}
+
+// BuildPhasesWithFactory は指定された戦略ファクトリを使ってフェーズを生成します
+func BuildPhasesWithFactory(factory service.StrategyFactory, roots ...*PhaseBuilder) (entity.Phases, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no phases to build")
+	}
+
+	ids := newBuilderIDs(roots)
+	phases := make(entity.Phases, 0)
+	for i, root := range roots {
+		built, err := root.build(factory, ids, 0, i+1)
+		if err != nil {
+			return nil, err
+		}
+		phases = append(phases, built...)
+	}
+
+	if err := entity.ValidatePhases(phases); err != nil {
+		return nil, err
+	}
+
+	return phases, nil
+}
+
+// build はフェーズとその子孫を生成します
+func (b *PhaseBuilder) build(factory service.StrategyFactory, ids *builderIDs, parentID value.PhaseID, position int) (entity.Phases, error) {
+	id := b.id
+	if id == 0 {
+		id = ids.nextPhaseID()
+	}
+	order := b.order
+	if order == 0 {
+		order = position
+	}
+
+	conditions := make([]*entity.Condition, 0, len(b.conditions))
+	for _, cb := range b.conditions {
+		cond, err := cb.build(factory, ids)
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: %w", b.name, err)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	phase := entity.NewPhase(id, b.name, order, conditions, b.conditionType, b.rule, parentID, b.autoProgress)
+	phase.Description = b.description
+	phase.RequiredConditions = b.required
+	phase.Completion = b.completion
+	phase.Parallel = b.parallel
+	phase.RepeatCount = b.repeatCount
+	phase.RepeatUntil = b.repeatUntil
+	phase.ParallelCompletion = b.parallelMode
+	phase.OnFailure = b.onFailure
+	phase.OnFailurePhaseID = b.failureTarget
+	phase.MaxRetries = b.maxRetries
+	phase.Transitions = b.transitions
+	for _, cond := range conditions {
+		cond.AddConditionObserver(phase)
+	}
+
+	phases := entity.Phases{phase}
+	for i, child := range b.children {
+		built, err := child.build(factory, ids, id, i+1)
+		if err != nil {
+			return nil, err
+		}
+		phases = append(phases, built...)
+	}
+
+	return phases, nil
+}
+
+// build は条件とそのパーツを生成し、戦略を初期化します
+func (b *ConditionBuilder) build(factory service.StrategyFactory, ids *builderIDs) (*entity.Condition, error) {
+	id := b.id
+	if id == 0 {
+		id = ids.nextConditionID()
+	}
+
+	cond := entity.NewCondition(id, b.label, b.kind)
+	cond.Description = b.description
+	cond.Combination = b.combination
+	cond.RequiredParts = b.requiredParts
+	cond.Ordered = b.ordered
+	for _, pb := range b.parts {
+		// AddPartでパーツ -> 条件の通知が接続される
+		cond.AddPart(pb.build(ids))
+	}
+
+	if err := cond.InitializePartStrategies(factory); err != nil {
+		return nil, fmt.Errorf("condition %s: %w", b.label, err)
+	}
+
+	return cond, nil
+}
+
+// build はパーツを生成します
+func (b *PartBuilder) build(ids *builderIDs) *entity.ConditionPart {
+	id := b.id
+	if id == 0 {
+		id = ids.nextPartID()
+	}
+
+	part := entity.NewConditionPart(id, b.label)
+	part.ComparisonOperator = b.operator
+	part.ReferenceValueInt = b.referenceValue
+	part.ReferenceValueSet = b.referenceSet
+	part.ReferenceValueFloat = b.referenceFloat
+	part.ReferenceValueString = b.referenceString
+	part.ReferenceStringSet = b.referenceStrings
+	part.MinValue = b.minValue
+	part.MaxValue = b.maxValue
+	part.MinValueFloat = b.minValueFloat
+	part.MaxValueFloat = b.maxValueFloat
+	part.DurationSeconds = b.durationSeconds
+	part.OnMiss = b.onMiss
+	part.TargetEntityType = b.targetEntityType
+	part.TargetEntityID = b.targetEntityID
+	part.Priority = b.priority
+	part.Live = b.live
+	part.DeadlineSeconds = b.deadlineSeconds
+	return part
+}
+
+// builderIDs は明示的に指定されたIDと衝突しないようにIDを採番します
+type builderIDs struct {
+	usedPhases     map[value.PhaseID]bool
+	usedConditions map[value.ConditionID]bool
+	usedParts      map[value.ConditionPartID]bool
+	phase          value.PhaseID
+	condition      value.ConditionID
+	part           value.ConditionPartID
+}
+
+func newBuilderIDs(roots []*PhaseBuilder) *builderIDs {
+	ids := &builderIDs{
+		usedPhases:     make(map[value.PhaseID]bool),
+		usedConditions: make(map[value.ConditionID]bool),
+		usedParts:      make(map[value.ConditionPartID]bool),
+	}
+
+	var collect func(b *PhaseBuilder)
+	collect = func(b *PhaseBuilder) {
+		ids.usedPhases[b.id] = true
+		for _, cb := range b.conditions {
+			ids.usedConditions[cb.id] = true
+			for _, pb := range cb.parts {
+				ids.usedParts[pb.id] = true
+			}
+		}
+		for _, child := range b.children {
+			collect(child)
+		}
+	}
+	for _, root := range roots {
+		collect(root)
+	}
+
+	return ids
+}
+
+func (ids *builderIDs) nextPhaseID() value.PhaseID {
+	ids.phase++
+	for ids.usedPhases[ids.phase] {
+		ids.phase++
+	}
+	return ids.phase
+}
+
+func (ids *builderIDs) nextConditionID() value.ConditionID {
+	ids.condition++
+	for ids.usedConditions[ids.condition] {
+		ids.condition++
+	}
+	return ids.condition
+}
+
+func (ids *builderIDs) nextPartID() value.ConditionPartID {
+	ids.part++
+	for ids.usedParts[ids.part] {
+		ids.part++
+	}
+	return ids.part
+}
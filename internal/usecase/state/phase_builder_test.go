@@ -0,0 +1,391 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findPhase は名前でフェーズを探します
+func findPhase(phases entity.Phases, name string) *entity.Phase {
+	for _, phase := range phases {
+		if phase.Name == name {
+			return phase
+		}
+	}
+	return nil
+}
+
+func TestBuildPhasesAssignsIDsAndOrders(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("ROOT").All(Timer("wait", 5)).Children(
+			Phase("CHILD1").Any(Counter("hit").GTE(3)),
+			Phase("CHILD2").Any(Counter("push").EQ(1)),
+		),
+		Phase("ROOT2").All(Counter("score").Between(1, 10)),
+	)
+	require.NoError(t, err)
+	require.Len(t, phases, 4)
+
+	root := findPhase(phases, "ROOT")
+	child1 := findPhase(phases, "CHILD1")
+	child2 := findPhase(phases, "CHILD2")
+	root2 := findPhase(phases, "ROOT2")
+
+	assert.Equal(t, value.PhaseID(1), root.ID)
+	assert.Equal(t, value.PhaseID(0), root.ParentID)
+	assert.Equal(t, 1, root.Order)
+	assert.Equal(t, 2, root2.Order)
+	assert.Equal(t, root.ID, child1.ParentID)
+	assert.Equal(t, root.ID, child2.ParentID)
+	assert.Equal(t, 1, child1.Order)
+	assert.Equal(t, 2, child2.Order)
+
+	assert.Equal(t, value.ConditionTypeAnd, root.ConditionType)
+	assert.Equal(t, value.ConditionTypeOr, child1.ConditionType)
+
+	// 条件とパーツのIDも一意に採番される
+	seen := make(map[value.ConditionPartID]bool)
+	for _, phase := range phases {
+		for _, cond := range phase.GetConditions() {
+			for _, part := range cond.GetParts() {
+				assert.False(t, seen[part.ID], "part id %d is duplicated", part.ID)
+				seen[part.ID] = true
+				assert.True(t, part.HasStrategy())
+			}
+		}
+	}
+	assert.Len(t, seen, 4)
+
+	timePart := root.GetConditions()[root.ConditionIDs[0]].GetParts()[0]
+	assert.Equal(t, int64(5), timePart.ReferenceValueInt)
+
+	betweenPart := root2.GetConditions()[root2.ConditionIDs[0]].GetParts()[0]
+	assert.Equal(t, value.ComparisonOperatorBetween, betweenPart.ComparisonOperator)
+	assert.Equal(t, int64(1), betweenPart.MinValue)
+	assert.Equal(t, int64(10), betweenPart.MaxValue)
+}
+
+func TestBuildPhasesAvoidsExplicitIDs(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("A").ID(2).Any(Counter("a").ID(1).GTE(1)),
+		Phase("B").Any(Counter("b").GTE(1)),
+	)
+	require.NoError(t, err)
+
+	b := findPhase(phases, "B")
+	assert.Equal(t, value.PhaseID(1), b.ID)
+	assert.Equal(t, value.ConditionID(2), b.ConditionIDs[0])
+}
+
+func TestBuildPhasesWiresObservers(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("ROOT").All(
+			Counter("hit").GTE(2),
+			Condition("pair", value.KindCounter, Part("left").EQ(1), Part("right").EQ(1)),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	root := phases[0]
+	require.NoError(t, root.Activate(ctx))
+
+	for _, cond := range root.GetConditions() {
+		for _, part := range cond.GetParts() {
+			for !part.IsSatisfied() {
+				require.NoError(t, part.Process(ctx, 1))
+			}
+		}
+	}
+
+	// Observerの接続漏れがなければフェーズはnextに進んでいる
+	assert.True(t, root.IsClear)
+	assert.Equal(t, value.StateNext, root.CurrentState())
+}
+
+func TestBuildPhasesValidation(t *testing.T) {
+	_, err := BuildPhases(
+		Phase("A").ID(1).Any(Counter("a").GTE(1)),
+		Phase("B").ID(1).Any(Counter("b").GTE(1)),
+	)
+	require.Error(t, err)
+
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationDuplicatePhaseID))
+
+	_, err = BuildPhases()
+	assert.Error(t, err)
+
+	// 比較演算子を指定していないカウンター条件は検証で弾かれる
+	_, err = BuildPhases(Phase("A").Any(Counter("a")))
+	require.Error(t, err)
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationInvalidPart))
+}
+
+func TestBuildPhasesInNotIn(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("A").ID(1).All(
+			Counter("in").ID(1).In(2, 4),
+			Counter("not_in").ID(2).NotIn(1),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	in := phase.GetConditions()[1].GetParts()[0]
+	assert.Equal(t, value.ComparisonOperatorIn, in.ComparisonOperator)
+	assert.Equal(t, []int64{2, 4}, in.GetReferenceValueSet())
+
+	require.NoError(t, in.Process(ctx, 1))
+	assert.False(t, in.IsSatisfied())
+	require.NoError(t, in.Process(ctx, 1))
+	assert.True(t, in.IsSatisfied())
+
+	notIn := phase.GetConditions()[2].GetParts()[0]
+	require.NoError(t, notIn.Process(ctx, 1))
+	assert.False(t, notIn.IsSatisfied())
+	require.NoError(t, notIn.Process(ctx, 1))
+	assert.True(t, notIn.IsSatisfied())
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+
+	// 値の集合が空のIn条件は検証で弾かれる
+	_, err = BuildPhases(Phase("B").Any(Counter("b").In()))
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationInvalidPart))
+}
+
+func TestBuildPhasesFloatAndString(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("A").ID(1).All(
+			Float("accuracy").ID(1).CompareFloat(value.ComparisonOperatorGTE, 0.9),
+			Text("door").ID(2).InStrings("open", "ajar"),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	accuracy := phase.GetConditions()[1].GetParts()[0]
+	assert.Equal(t, 0.9, accuracy.GetReferenceValueFloat())
+	require.NoError(t, accuracy.ProcessValue(ctx, 0.85))
+	assert.False(t, accuracy.IsSatisfied())
+	require.NoError(t, accuracy.ProcessValue(ctx, 0.92))
+	assert.True(t, accuracy.IsSatisfied())
+
+	door := phase.GetConditions()[2].GetParts()[0]
+	assert.Equal(t, []string{"open", "ajar"}, door.GetReferenceStringSet())
+	assert.Error(t, door.ProcessValue(ctx, int64(1)))
+	require.NoError(t, door.ProcessValue(ctx, "ajar"))
+	assert.True(t, door.IsSatisfied())
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+
+	// 文字列に大小比較は使えない
+	_, err = BuildPhases(Phase("B").Any(Text("b").CompareString(value.ComparisonOperatorGT, "a")))
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationUnsupportedOperator))
+
+	// 小数の範囲は小数の下限・上限で指定する
+	phases, err = BuildPhases(Phase("C").ID(1).All(Float("grip").ID(1).BetweenFloat(0.5, 1.5)))
+	require.NoError(t, err)
+	grip := phases[0].GetConditions()[1].GetParts()[0]
+	require.NoError(t, phases[0].Activate(ctx))
+	require.NoError(t, grip.ProcessValue(ctx, 0.4))
+	assert.False(t, grip.IsSatisfied())
+	require.NoError(t, grip.ProcessValue(ctx, 0.75))
+	assert.True(t, grip.IsSatisfied())
+}
+
+func TestBuildPhasesPartCombination(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("A").ID(1).All(
+			Condition("two_of_three", value.KindCounter,
+				Part("a").ID(1).GTE(1),
+				Part("b").ID(2).GTE(1),
+				Part("c").ID(3).GTE(1),
+			).ID(1).AtLeastParts(2),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	cond := phase.GetConditions()[1]
+	assert.Equal(t, value.PartCombinationAtLeast, cond.Combination)
+	assert.Equal(t, 2, cond.RequiredPartCount())
+
+	parts := cond.GetParts()
+	require.NoError(t, parts[0].Process(ctx, 1))
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+	require.NoError(t, parts[2].Process(ctx, 1))
+	assert.Equal(t, value.StateSatisfied, cond.CurrentState())
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+
+	// パーツ数を超える必要数は検証で弾かれる
+	_, err = BuildPhases(Phase("B").Any(Counter("b").GTE(1).AtLeastParts(2)))
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationInvalidCombination))
+}
+
+func TestBuildPhasesOrderedCondition(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("A").ID(1).All(
+			Condition("steps", value.KindCounter,
+				Part("second").ID(2).Priority(2).GTE(1),
+				Part("first").ID(1).Priority(1).GTE(1),
+			).ID(1).Ordered(),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	cond := phase.GetConditions()[1]
+	assert.True(t, cond.Ordered)
+
+	parts := cond.GetParts()
+	require.Equal(t, "first", parts[0].Label)
+
+	// 先に後のパーツを満たしても保留され、先のパーツを満たすと反映される
+	require.NoError(t, parts[1].Process(ctx, 1))
+	assert.True(t, parts[1].IsHeld())
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+	require.NoError(t, parts[0].Process(ctx, 1))
+	assert.True(t, parts[1].IsSatisfied())
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+}
+
+func TestBuildPhasesLiveParts(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("HOLD").ID(1).All(
+			Counter("left").ID(1).Live().GTE(1),
+			Counter("right").ID(2).Live().GTE(1),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	conditions := phase.GetConditions()
+	left := conditions[1].GetParts()[0]
+	right := conditions[2].GetParts()[0]
+	assert.True(t, left.Live)
+
+	// 押している間だけ満たされ、離すと未達成に戻る
+	require.NoError(t, left.Process(ctx, 1))
+	assert.True(t, left.IsSatisfied())
+	assert.True(t, phase.SatisfiedConditions[1])
+	require.NoError(t, left.Process(ctx, -1))
+	assert.False(t, left.IsSatisfied())
+	assert.Equal(t, int64(0), left.GetCurrentValue())
+	assert.False(t, phase.SatisfiedConditions[1])
+
+	require.NoError(t, right.Process(ctx, 1))
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+	assert.False(t, phase.IsClear)
+
+	// 両方を同時に満たすとフェーズが進む
+	require.NoError(t, left.Process(ctx, 1))
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+}
+
+func TestBuildPhasesSequence(t *testing.T) {
+	// 呼び出し元のスライスを後から書き換えても、設定した順番は変わらない
+	steps := []int64{3, 1, 4, 2}
+	builder := Sequence("buttons", steps...).ID(1).StayOnMiss()
+	steps[0] = 9
+
+	phases, err := BuildPhases(Phase("BUTTONS").ID(1).All(builder))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	part := phase.GetConditions()[1].GetParts()[0]
+	assert.Equal(t, []int64{3, 1, 4, 2}, part.GetSequence())
+	assert.Equal(t, value.SequenceMissStay, part.OnMiss)
+
+	// 誤った入力があっても進み具合は保たれる
+	for _, input := range []int64{3, 1, 9, 4} {
+		require.NoError(t, part.ProcessValue(ctx, input))
+	}
+	assert.Equal(t, int64(3), part.GetCurrentValue())
+	require.NoError(t, part.ProcessValue(ctx, 2.0))
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+}
+
+func TestBuildPhasesThreshold(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("PUZZLE").ID(1).AtLeast(2,
+			Counter("a").ID(1).GTE(1),
+			Counter("b").ID(2).GTE(1),
+			Counter("c").ID(3).GTE(1),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	assert.Equal(t, value.ConditionTypeThreshold, phase.ConditionType)
+	assert.Equal(t, 2, phase.RequiredConditionCount())
+	require.NoError(t, phase.Activate(ctx))
+
+	require.NoError(t, phase.GetConditions()[3].GetParts()[0].Process(ctx, 1))
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+	assert.Equal(t, 1, phase.SatisfiedConditionCount())
+
+	require.NoError(t, phase.GetConditions()[1].GetParts()[0].Process(ctx, 1))
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+
+	// 条件数を超える必要数は検証で弾かれる
+	_, err = BuildPhases(Phase("B").AtLeast(2, Counter("b").GTE(1)))
+	var errs entity.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	assert.True(t, errs.HasKind(entity.ValidationInvalidThreshold))
+}
+
+func TestBuildPhasesCompletionExpr(t *testing.T) {
+	phases, err := BuildPhases(
+		Phase("ROOM").ID(1).When(
+			entity.ExprOrOf(
+				entity.ExprAndOf(entity.ExprRef(1), entity.ExprRef(2)),
+				entity.ExprRef(3),
+			),
+			Counter("a").ID(1).GTE(1),
+			Counter("b").ID(2).GTE(1),
+			Counter("c").ID(3).GTE(1),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	phase := phases[0]
+	require.NoError(t, phase.Activate(ctx))
+
+	require.NoError(t, phase.GetConditions()[1].GetParts()[0].Process(ctx, 1))
+	assert.Equal(t, value.StateActive, phase.CurrentState())
+	require.NoError(t, phase.GetConditions()[2].GetParts()[0].Process(ctx, 1))
+	assert.Equal(t, value.StateNext, phase.CurrentState())
+}
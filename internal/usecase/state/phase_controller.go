@@ -7,18 +7,28 @@ import (
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// DefaultTransitionDelay はnext状態のフェーズが次のフェーズへ遷移するまでの既定の待ち時間です
+const DefaultTransitionDelay = 1 * time.Second
+
 // PhaseController はフェーズの制御を担当するコントローラーです
 type PhaseController struct {
-	phaseFacade *entity.PhaseFacade
-	observers   []service.ControllerObserver
-	mu          sync.RWMutex
-	log         *zap.Logger
+	phaseFacade     *entity.PhaseFacade
+	observers       []service.ControllerObserver
+	clock           clock.Clock
+	transitionDelay time.Duration // next状態から次のフェーズへ遷移するまでの待ち時間
+	paused          bool
+	pending         []*entity.Phase                   // 一時停止中に保留した次フェーズへの遷移
+	retries         map[value.PhaseID]int             // 失敗によるやり直しの回数
+	history         map[value.PhaseID][]value.PhaseID // 親IDごとに実際に入った兄弟フェーズの履歴
+	mu              sync.RWMutex
+	log             *zap.Logger
 }
 
 // NewPhaseController は新しいPhaseControllerを作成します
@@ -32,9 +42,13 @@ func NewPhaseController(phases entity.Phases) *PhaseController {
 	phaseFacade := entity.NewPhaseFacade(phases)
 
 	pc := &PhaseController{
-		phaseFacade: phaseFacade,
-		observers:   make([]service.ControllerObserver, 0),
-		log:         log,
+		phaseFacade:     phaseFacade,
+		observers:       make([]service.ControllerObserver, 0),
+		clock:           clock.Real(),
+		transitionDelay: DefaultTransitionDelay,
+		retries:         make(map[value.PhaseID]int),
+		history:         make(map[value.PhaseID][]value.PhaseID),
+		log:             log,
 	}
 
 	log.Debug("PhaseController initialized",
@@ -73,64 +87,308 @@ func (pc *PhaseController) OnPhaseChanged(phaseEntity interface{}) {
 		zap.Bool("equals", phase.CurrentState() == value.StateNext))
 	pc.NotifyEntityChanged(phase)
 
-	if phase.CurrentState() == value.StateNext {
-		time.Sleep(1 * time.Second)
-		pc.log.Debug("start next phase!!!!!!!!!!")
+	switch phase.CurrentState() {
+	case value.StateNext:
+		// オペレーターが飛ばしたフェーズは待たずに遷移する
+		pc.mu.RLock()
+		c, delay := pc.clock, pc.transitionDelay
+		pc.mu.RUnlock()
+		if phase.Outcome != value.OutcomeSkipped && delay > 0 {
+			c.Sleep(delay)
+		}
+	case value.StateFailed:
+		// 失敗したフェーズは待たずに遷移する
+	default:
+		return
+	}
+
+	// 待機中に一時停止された場合は、再開されるまで遷移を保留する
+	pc.mu.Lock()
+	if pc.paused {
+		pc.pending = append(pc.pending, phase)
+		pc.mu.Unlock()
+		pc.log.Debug("PhaseController.OnPhaseChanged: transition deferred while paused",
+			zap.String("phase", phase.Name))
+		return
+	}
+	pc.mu.Unlock()
+
+	pc.proceed(context.Background(), phase)
+}
 
-		ctx := context.Background()
+// proceed はnext状態のフェーズを次のフェーズへ進め、失敗したフェーズはOnFailureに従って遷移させます
+func (pc *PhaseController) proceed(ctx context.Context, phase *entity.Phase) {
+	// 待っている間にオペレーターの操作で別のフェーズに移った場合は遷移しない
+	if state := phase.CurrentState(); state != value.StateNext && state != value.StateFailed {
+		pc.log.Debug("PhaseController.proceed: phase left while waiting",
+			zap.String("phase", phase.Name),
+			zap.String("state", state))
+		return
+	}
+	if phase.IsFailed() {
+		pc.handleFailure(ctx, phase)
+		return
+	}
+	pc.advance(ctx, phase)
+}
 
-		// フェーズの親IDを取得
-		parentID := phase.ParentID
+// advance はnext状態のフェーズを終了し、次のフェーズをアクティブ化します
+// 次のフェーズは遷移の辺に従って決め、一致する辺がない場合はOrderの次のフェーズにします
+// 繰り返しが残っているフェーズは次のフェーズに進まず、もう一度アクティブ化します
+func (pc *PhaseController) advance(ctx context.Context, phase *entity.Phase) {
+	pc.log.Debug("start next phase!!!!!!!!!!")
 
-		// 同じ親を持つフェーズのグループを取得
-		siblingPhases := pc.phaseFacade.GetPhasesByParentID(parentID)
+	// フェーズの親IDを取得
+	parentID := phase.ParentID
 
-		// 現在のフェーズを終了
-		if err := phase.Finish(ctx); err != nil {
-			pc.log.Error("Failed to finish current phase", zap.Error(err))
-			// エラーが発生しても次のフェーズに進む試みをする
+	// 現在のフェーズを終了
+	if err := phase.Finish(ctx); err != nil {
+		pc.log.Error("Failed to finish current phase", zap.Error(err))
+		// エラーが発生しても次のフェーズに進む試みをする
+	}
+
+	// 繰り返すフェーズは次のフェーズに進まず、もう一度アクティブ化する
+	if phase.ShouldRepeat() {
+		pc.log.Debug("Repeating phase",
+			zap.String("phase", phase.Name),
+			zap.Int("iteration", phase.Iteration))
+		if err := pc.repeatPhase(ctx, phase); err != nil {
+			pc.log.Error("Failed to repeat phase", zap.String("phase", phase.Name), zap.Error(err))
 		}
+		return
+	}
 
-		// 次のフェーズを探す
-		nextPhase := siblingPhases.GetNextByOrder(phase.Order)
+	// 並列領域は兄弟の領域に移らず、親フェーズの完了条件を確認する
+	if phase.Parent != nil && phase.Parent.Parallel {
+		pc.completeRegion(ctx, phase)
+		return
+	}
 
-		if nextPhase != nil {
-			// 次のフェーズが見つかった場合、それをアクティブ化
-			pc.log.Debug("Found next phase",
-				zap.String("next_phase", nextPhase.Name),
-				zap.Int("next_order", nextPhase.Order))
-			_ = pc.ActivatePhaseRecursively(ctx, nextPhase)
-		} else if parentID != 0 {
-			// 次のフェーズがなく、親がルートでない場合、親の次のフェーズを探す
-			pc.log.Debug("No next phase found, checking parent's siblings")
+	// 次のフェーズを探す
+	nextPhase := pc.nextSibling(phase)
+
+	if nextPhase != nil {
+		// 次のフェーズが見つかった場合、それをアクティブ化
+		// 辺で前のフェーズに戻る場合もあるため、リセットしてからアクティブ化する
+		pc.log.Debug("Found next phase",
+			zap.String("next_phase", nextPhase.Name),
+			zap.Int("next_order", nextPhase.Order))
+		if err := pc.restartPhase(ctx, nextPhase); err != nil {
+			pc.log.Error("Failed to activate next phase", zap.String("next_phase", nextPhase.Name), zap.Error(err))
+		}
+	} else if parentID != 0 {
+		// 次のフェーズがなく、親がルートでない場合、親の次のフェーズを探す
+		pc.log.Debug("No next phase found, checking parent's siblings")
 
-			// 親フェーズが子フェーズ完了時に自動的に進捗する設定の場合
-			if phase.Parent != nil && phase.Parent.AutoProgressOnChildrenComplete {
-				pc.log.Debug("Moving parent phase to next state (auto progress enabled)",
-					zap.String("parent_name", phase.Parent.Name))
+		// 親フェーズが子フェーズ完了時に自動的に進捗する設定の場合
+		if phase.Parent != nil && phase.Parent.AutoProgressOnChildrenComplete {
+			pc.log.Debug("Moving parent phase to next state (auto progress enabled)",
+				zap.String("parent_name", phase.Parent.Name))
 
-				if err := phase.Parent.Next(ctx); err != nil {
-					pc.log.Error("Failed to move parent to next state", zap.Error(err))
-				}
+			if err := phase.Parent.Next(ctx); err != nil {
+				pc.log.Error("Failed to move parent to next state", zap.Error(err))
+			}
+		}
+	} else {
+		// 親IDが0（ルートフェーズ）で次のフェーズがない場合、ゲームを終了する
+		pc.log.Debug("No next root phase found, all phases completed")
+		pc.NotifyEntityChanged(nil)
+	}
+}
+
+// completeRegion は完了した並列領域の親フェーズが、ParallelCompletionに従って完了したかを確認します
+// 全ての領域の完了を待つ場合は残りの領域が終わるまで待ち、いずれかの領域で完了する場合は残りの領域を打ち切ります
+func (pc *PhaseController) completeRegion(ctx context.Context, region *entity.Phase) {
+	parent := region.Parent
+
+	switch parent.ParallelCompletion {
+	case value.ParallelAny:
+		pc.stopRegions(ctx, parent, region)
+	default:
+		for _, r := range parent.GetChildren() {
+			if r.CurrentState() != value.StateFinish {
+				pc.log.Debug("Waiting for other regions",
+					zap.String("parent", parent.Name),
+					zap.String("region", r.Name))
+				return
+			}
+		}
+	}
+
+	// 同時に完了した別の領域が先に親フェーズを進めている場合は何もしない
+	if parent.CurrentState() != value.StateActive {
+		return
+	}
+	pc.log.Debug("Parallel regions completed", zap.String("parent", parent.Name))
+	if err := parent.Next(ctx); err != nil {
+		pc.log.Error("Failed to move parallel parent to next state", zap.String("parent", parent.Name), zap.Error(err))
+	}
+}
+
+// failRegion は失敗した並列領域を親フェーズの失敗として扱うかを、ParallelCompletionに従って決めます
+// 全ての領域の完了が必要な場合は残りの領域を打ち切って親フェーズを失敗させ、
+// いずれかの領域で完了する場合は他に動いている領域がなくなったときだけ親フェーズを失敗させます
+func (pc *PhaseController) failRegion(ctx context.Context, region *entity.Phase) bool {
+	parent := region.Parent
+
+	if parent.ParallelCompletion == value.ParallelAny {
+		for _, r := range parent.GetChildren() {
+			if r != region && isRunning(r) {
+				return false
 			}
-		} else {
-			// 親IDが0（ルートフェーズ）で次のフェーズがない場合、次のルートフェーズを探す
-			pc.log.Debug("No next phase found for root phase, looking for next root phase")
-
-			rootPhases := pc.phaseFacade.GetPhasesByParentID(0)
-			nextRootPhase := rootPhases.GetNextByOrder(phase.Order)
-
-			if nextRootPhase != nil {
-				pc.log.Debug("Found next root phase",
-					zap.String("next_root", nextRootPhase.Name),
-					zap.Int("next_order", nextRootPhase.Order))
-				_ = pc.ActivatePhaseRecursively(ctx, nextRootPhase)
-			} else {
-				pc.log.Debug("No next root phase found, all phases completed")
-				pc.NotifyEntityChanged(nil)
+		}
+		return true
+	}
+
+	pc.stopRegions(ctx, parent, region)
+	return true
+}
+
+// stopRegions は指定した領域以外で動いている並列領域を打ち切り、リセットします
+func (pc *PhaseController) stopRegions(ctx context.Context, parent *entity.Phase, except *entity.Phase) {
+	for _, region := range parent.GetChildren() {
+		if region == except || !isRunning(region) {
+			continue
+		}
+		pc.log.Debug("Stopping parallel region", zap.String("region", region.Name))
+		if err := resetRecursively(ctx, region); err != nil {
+			pc.log.Error("Failed to stop parallel region", zap.String("region", region.Name), zap.Error(err))
+		}
+	}
+}
+
+// isRunning はフェーズが開始されてからまだ終わっていないかを返します
+func isRunning(phase *entity.Phase) bool {
+	switch phase.CurrentState() {
+	case value.StateActive, value.StatePaused, value.StateNext:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextSibling は遷移の辺に従って次の兄弟フェーズを返します
+// 一致する辺がない場合はOrderの次のフェーズを、辺が終了を指す場合はnilを返します
+func (pc *PhaseController) nextSibling(phase *entity.Phase) *entity.Phase {
+	siblingPhases := pc.phaseFacade.GetPhasesByParentID(phase.ParentID)
+
+	to, ok := phase.MatchTransition()
+	if !ok {
+		return siblingPhases.GetNextByOrder(phase.Order)
+	}
+	if to == 0 {
+		pc.log.Debug("Transition leads to end", zap.String("phase", phase.Name))
+		return nil
+	}
+
+	next := siblingPhases.GetByID(to)
+	if next == nil {
+		pc.log.Error("Transition target not found",
+			zap.String("phase", phase.Name),
+			zap.Int("target_id", int(to)))
+	}
+	return next
+}
+
+// handleFailure は失敗したフェーズの遷移の辺またはOnFailureに従って、やり直し・別フェーズへの移動・終了のいずれかを行います
+// やり直しの上限に達した場合や移り先がない場合は終了として扱います
+func (pc *PhaseController) handleFailure(ctx context.Context, phase *entity.Phase) {
+	pc.log.Debug("PhaseController.handleFailure",
+		zap.String("phase", phase.Name),
+		zap.String("outcome", string(phase.Outcome)),
+		zap.Int("on_failure", int(phase.OnFailure)))
+
+	_, matched := phase.MatchTransition()
+	switch {
+	case matched:
+		// 結果に一致する遷移の辺はOnFailureより優先する
+		if target := pc.nextSibling(phase); target != nil {
+			pc.restartFailureTarget(ctx, target)
+			return
+		}
+	case phase.OnFailure == value.FailureRetry:
+		if pc.takeRetry(phase) {
+			if err := pc.restartPhase(ctx, phase); err != nil {
+				pc.log.Error("Failed to retry phase", zap.String("phase", phase.Name), zap.Error(err))
 			}
+			return
+		}
+		pc.log.Debug("Retry limit reached", zap.String("phase", phase.Name), zap.Int("max_retries", phase.MaxRetries))
+	case phase.OnFailure == value.FailureGoto:
+		target := pc.phaseFacade.GetPhasesByParentID(phase.ParentID).GetByID(phase.OnFailurePhaseID)
+		if target != nil {
+			pc.restartFailureTarget(ctx, target)
+			return
+		}
+		pc.log.Error("Failure target not found",
+			zap.String("phase", phase.Name),
+			zap.Int("target_id", int(phase.OnFailurePhaseID)))
+	}
+
+	// 子フェーズの失敗は親フェーズに引き継ぎ、親フェーズのOnFailureに従う
+	if phase.Parent != nil {
+		if phase.Parent.Parallel && !pc.failRegion(ctx, phase) {
+			pc.log.Debug("Other regions are still running", zap.String("region", phase.Name))
+			return
+		}
+		if err := phase.Parent.Fail(ctx, phase.Outcome); err != nil {
+			pc.log.Error("Failed to fail parent phase", zap.String("parent", phase.Parent.Name), zap.Error(err))
+		}
+		return
+	}
+
+	pc.log.Debug("Root phase failed, ending game", zap.String("phase", phase.Name))
+	pc.NotifyEntityChanged(nil)
+}
+
+// restartFailureTarget は失敗したフェーズの移り先をアクティブ化します
+func (pc *PhaseController) restartFailureTarget(ctx context.Context, target *entity.Phase) {
+	if err := pc.restartPhase(ctx, target); err != nil {
+		pc.log.Error("Failed to activate failure target", zap.String("target", target.Name), zap.Error(err))
+	}
+}
+
+// takeRetry はやり直しの上限に達していなければ回数を数えてtrueを返します
+func (pc *PhaseController) takeRetry(phase *entity.Phase) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if phase.MaxRetries > 0 && pc.retries[phase.ID] >= phase.MaxRetries {
+		return false
+	}
+	pc.retries[phase.ID]++
+	return true
+}
+
+// restartPhase はフェーズとその子孫をリセットしてから、改めてアクティブ化します
+func (pc *PhaseController) restartPhase(ctx context.Context, phase *entity.Phase) error {
+	if err := resetRecursively(ctx, phase); err != nil {
+		return err
+	}
+	return pc.ActivatePhaseRecursively(ctx, phase)
+}
+
+// repeatPhase はフェーズの子孫をリセットし、繰り返し回数を保ったままフェーズを改めてアクティブ化します
+func (pc *PhaseController) repeatPhase(ctx context.Context, phase *entity.Phase) error {
+	for _, child := range phase.GetChildren() {
+		if err := resetRecursively(ctx, child); err != nil {
+			return err
+		}
+	}
+	if err := phase.Repeat(ctx); err != nil {
+		return err
+	}
+	return pc.ActivatePhaseRecursively(ctx, phase)
+}
+
+// resetRecursively はフェーズとその子孫をリセットします
+func resetRecursively(ctx context.Context, phase *entity.Phase) error {
+	for _, child := range phase.GetChildren() {
+		if err := resetRecursively(ctx, child); err != nil {
+			return err
 		}
 	}
+	return phase.Reset(ctx)
 }
 
 // OnConditionChanged は条件変更通知を受け取るメソッドです
@@ -161,6 +419,23 @@ func (pc *PhaseController) OnConditionPartChanged(part interface{}) {
 	pc.NotifyEntityChanged(part)
 }
 
+// SetClock はコントローラーと全フェーズが使うClockを設定します
+func (pc *PhaseController) SetClock(c clock.Clock) {
+	pc.mu.Lock()
+	pc.clock = c
+	pc.mu.Unlock()
+
+	pc.phaseFacade.SetClock(c)
+}
+
+// SetTransitionDelay はnext状態から次のフェーズへ遷移するまでの待ち時間を設定します
+// 0以下を指定すると待たずに遷移します
+func (pc *PhaseController) SetTransitionDelay(d time.Duration) {
+	pc.mu.Lock()
+	pc.transitionDelay = d
+	pc.mu.Unlock()
+}
+
 // GetPhases は全フェーズを取得します
 func (pc *PhaseController) GetPhases() entity.Phases {
 	return pc.phaseFacade.GetAllPhases()
@@ -180,11 +455,12 @@ func (pc *PhaseController) ActivatePhaseRecursively(ctx context.Context, phase *
 	if err := phase.Activate(ctx); err != nil {
 		return err
 	}
+	pc.enterPhase(phase)
 
 	// 現在のフェーズとして設定
 	pc.phaseFacade.SetCurrentPhase(phase)
 
-	// 子フェーズがある場合は最初の子フェーズをアクティブ化
+	// 子フェーズがある場合は最初の子フェーズ(並列領域の場合は全ての子フェーズ)をアクティブ化
 	if phase.HasChildren() {
 		children := phase.GetChildren()
 		if len(children) == 0 {
@@ -193,6 +469,16 @@ func (pc *PhaseController) ActivatePhaseRecursively(ctx context.Context, phase *
 			return fmt.Errorf("inconsistent phase state: HasChildren() is true but GetChildren() returned empty slice")
 		}
 
+		// 並列領域は全ての子フェーズを同時にアクティブ化する
+		if phase.Parallel {
+			for _, region := range children {
+				if err := pc.ActivatePhaseRecursively(ctx, region); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
 		firstChild := children[0]
 		if firstChild == nil {
 			pc.log.Error("ActivatePhaseRecursively: first child is nil",
@@ -210,6 +496,349 @@ func (pc *PhaseController) ActivatePhaseRecursively(ctx context.Context, phase *
 	return nil
 }
 
+// Pause はアクティブな全フェーズを一時停止します
+// 一時停止中はnext状態になったフェーズの次フェーズへの遷移も保留します
+func (pc *PhaseController) Pause(ctx context.Context) error {
+	running := make(entity.Phases, 0)
+	for _, phase := range pc.GetPhases() {
+		if state := phase.CurrentState(); state == value.StateActive || state == value.StateNext {
+			running = append(running, phase)
+		}
+	}
+	if len(running) == 0 {
+		return fmt.Errorf("no running phase to pause")
+	}
+
+	pc.mu.Lock()
+	if pc.paused {
+		pc.mu.Unlock()
+		return fmt.Errorf("already paused")
+	}
+	pc.paused = true
+	pc.mu.Unlock()
+
+	for _, phase := range running {
+		// next状態のフェーズは遷移の保留のみ行う
+		if phase.CurrentState() != value.StateActive {
+			continue
+		}
+		if err := phase.Pause(ctx); err != nil {
+			pc.log.Error("PhaseController.Pause", zap.String("phase", phase.Name), zap.Error(err))
+			return err
+		}
+	}
+
+	pc.log.Debug("PhaseController.Pause", zap.Int("running_phases", len(running)))
+	return nil
+}
+
+// Resume は一時停止した全フェーズを再開し、保留していた遷移を再開します
+func (pc *PhaseController) Resume(ctx context.Context) error {
+	pc.mu.Lock()
+	if !pc.paused {
+		pc.mu.Unlock()
+		return fmt.Errorf("not paused")
+	}
+	pc.paused = false
+	pending := pc.pending
+	pc.pending = nil
+	pc.mu.Unlock()
+
+	// 親フェーズから順に再開する
+	for _, phase := range pc.GetPhases() {
+		if !phase.IsPaused() {
+			continue
+		}
+		if err := phase.Resume(ctx); err != nil {
+			pc.log.Error("PhaseController.Resume", zap.String("phase", phase.Name), zap.Error(err))
+			return err
+		}
+	}
+
+	for _, phase := range pending {
+		pc.log.Debug("PhaseController.Resume: resuming deferred transition",
+			zap.String("phase", phase.Name))
+		go pc.proceed(context.Background(), phase)
+	}
+	return nil
+}
+
+// Abort は現在の最下層のフェーズを中断し、失敗として扱います
+// 並列領域では動いている全ての領域の最下層のフェーズを中断します
+func (pc *PhaseController) Abort(ctx context.Context) error {
+	aborted := 0
+	for _, phase := range pc.phaseFacade.GetCurrentLeafPhases() {
+		// 先に中断したフェーズの失敗で打ち切られた領域は中断しない
+		if state := phase.CurrentState(); state != value.StateActive && state != value.StatePaused {
+			continue
+		}
+		if err := phase.Fail(ctx, value.OutcomeAborted); err != nil {
+			pc.log.Error("PhaseController.Abort", zap.String("phase", phase.Name), zap.Error(err))
+			return err
+		}
+		aborted++
+	}
+	if aborted == 0 {
+		return fmt.Errorf("no current phase to abort")
+	}
+	return nil
+}
+
+// Skip は現在の最下層のフェーズを飛ばし、通常の完了と同じく次のフェーズに進めます
+// フェーズの結果はskippedとなり、遷移の辺もskippedの結果で照合します
+// 最下層のフェーズがすでに終わっている場合は、アクティブな最も近い祖先フェーズを飛ばします
+func (pc *PhaseController) Skip(ctx context.Context) error {
+	if pc.IsPaused() {
+		return fmt.Errorf("cannot skip while paused")
+	}
+
+	targets := pc.activeLeaves()
+	if len(targets) == 0 {
+		return fmt.Errorf("no active phase to skip")
+	}
+	for _, phase := range targets {
+		// 先に飛ばしたフェーズの遷移で打ち切られた領域は飛ばさない
+		if phase.CurrentState() != value.StateActive {
+			continue
+		}
+		pc.log.Debug("PhaseController.Skip", zap.String("phase", phase.Name))
+		if err := phase.SkipNext(ctx); err != nil {
+			pc.log.Error("PhaseController.Skip", zap.String("phase", phase.Name), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// activeLeaves は現在の最下層のフェーズごとに、自身または祖先のうち最も近いアクティブなフェーズを返します
+func (pc *PhaseController) activeLeaves() entity.Phases {
+	seen := make(map[value.PhaseID]bool)
+	result := make(entity.Phases, 0)
+	for _, leaf := range pc.phaseFacade.GetCurrentLeafPhases() {
+		phase := leaf
+		for phase != nil && phase.CurrentState() != value.StateActive {
+			phase = phase.Parent
+		}
+		if phase != nil && !seen[phase.ID] {
+			seen[phase.ID] = true
+			result = append(result, phase)
+		}
+	}
+	return result
+}
+
+// JumpTo は指定したフェーズに移ります
+// 移り先より前で動いていたフェーズは飛ばして終了させ、移り先より後ろのフェーズはリセットします
+// 移り先の祖先フェーズはアクティブにして、CurrentPhaseMapを祖先から移り先までたどれるようにします
+// 祖先の並列領域のうち、移り先を含まない領域はそのまま動かし続けます
+func (pc *PhaseController) JumpTo(ctx context.Context, id value.PhaseID) error {
+	target := pc.GetPhases().GetByID(id)
+	if target == nil {
+		return fmt.Errorf("phase %d not found", id)
+	}
+	if pc.IsPaused() {
+		return fmt.Errorf("cannot jump while paused")
+	}
+
+	// 祖先をルートから順に並べ、移り先を含まない並列領域を集める
+	ancestors := make(entity.Phases, 0)
+	for parent := target.Parent; parent != nil; parent = parent.Parent {
+		ancestors = append(entity.Phases{parent}, ancestors...)
+	}
+	keep := make(map[value.PhaseID]bool)
+	for _, phase := range append(ancestors, target) {
+		keep[phase.ID] = true
+	}
+	otherRegions := make(map[value.PhaseID]entity.Phases)
+	for i, ancestor := range ancestors {
+		if !ancestor.Parallel {
+			continue
+		}
+		onPath := target
+		if i+1 < len(ancestors) {
+			onPath = ancestors[i+1]
+		}
+		for _, region := range ancestor.GetChildren() {
+			if region != onPath {
+				otherRegions[ancestor.ID] = append(otherRegions[ancestor.ID], region)
+				markSubtree(region, keep)
+			}
+		}
+	}
+	markSubtree(target, keep)
+
+	pc.log.Debug("PhaseController.JumpTo",
+		zap.String("target", target.Name),
+		zap.Int("ancestors", len(ancestors)))
+
+	// 移り先の前で動いていたフェーズは飛ばし、後ろで開始済みのフェーズはリセットする
+	order := pc.treeOrder()
+	for _, phase := range pc.GetPhases() {
+		if keep[phase.ID] {
+			continue
+		}
+		var err error
+		switch {
+		case order[phase.ID] < order[target.ID] && (isRunning(phase) || phase.IsFailed()):
+			err = phase.Skip(ctx)
+		case order[phase.ID] > order[target.ID] && phase.CurrentState() != value.StateReady:
+			err = phase.Reset(ctx)
+		}
+		if err != nil {
+			pc.log.Error("Failed to leave phase", zap.String("phase", phase.Name), zap.Error(err))
+			return err
+		}
+	}
+
+	// 移り先をリセットし、祖先をルートから順にアクティブにする
+	if err := resetRecursively(ctx, target); err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.CurrentState() != value.StateActive {
+			if err := ancestor.Reset(ctx); err != nil {
+				return err
+			}
+			if err := ancestor.Activate(ctx); err != nil {
+				return err
+			}
+			pc.enterPhase(ancestor)
+			// 新しく開始した並列フェーズでは、移り先を含まない領域も開始する
+			for _, region := range otherRegions[ancestor.ID] {
+				if err := pc.restartPhase(ctx, region); err != nil {
+					return err
+				}
+			}
+		}
+		pc.phaseFacade.SetCurrentPhase(ancestor)
+	}
+	return pc.ActivatePhaseRecursively(ctx, target)
+}
+
+// Rewind は現在のフェーズの1つ前のフェーズに戻ります
+// 1つ前のフェーズは兄弟フェーズの中で直前に入っていたフェーズで、遷移の辺や失敗時の移り先で進んだ場合もその経路を戻ります
+// 直前に入ったフェーズの履歴がない場合(移動で直接入った場合や復元した場合)は、Orderが1つ前のフェーズに戻ります
+// 最初の子フェーズや並列領域の場合は親フェーズの1つ前のフェーズに戻ります
+// 複数の並列領域が動いている場合は、それらの最も近い共通の祖先フェーズから1つ前のフェーズを探します
+func (pc *PhaseController) Rewind(ctx context.Context) error {
+	leaves := pc.phaseFacade.GetCurrentLeafPhases()
+	if len(leaves) == 0 {
+		return fmt.Errorf("no current phase to rewind")
+	}
+
+	for phase := commonAncestor(leaves); phase != nil; phase = phase.Parent {
+		if phase.Parent != nil && phase.Parent.Parallel {
+			continue
+		}
+		if prev := pc.popPrevious(phase); prev != nil {
+			return pc.JumpTo(ctx, prev.ID)
+		}
+	}
+	return fmt.Errorf("no previous phase to rewind to")
+}
+
+// enterPhase はフェーズに入ったことを親IDごとの履歴に記録します
+// 同じフェーズに続けて入った場合(やり直しや繰り返し)は記録せず、子孫の履歴は入り直すたびに消します
+func (pc *PhaseController) enterPhase(phase *entity.Phase) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	var forget func(p *entity.Phase)
+	forget = func(p *entity.Phase) {
+		delete(pc.history, p.ID)
+		for _, child := range p.GetChildren() {
+			forget(child)
+		}
+	}
+	forget(phase)
+
+	h := pc.history[phase.ParentID]
+	if len(h) > 0 && h[len(h)-1] == phase.ID {
+		return
+	}
+	pc.history[phase.ParentID] = append(h, phase.ID)
+}
+
+// popPrevious は履歴から現在のフェーズを取り除き、兄弟フェーズの中で1つ前のフェーズを返します
+// 履歴に直前のフェーズがない場合はOrderが1つ前のフェーズを返し、それもない場合はnilを返して履歴を変えません
+func (pc *PhaseController) popPrevious(phase *entity.Phase) *entity.Phase {
+	siblings := pc.phaseFacade.GetPhasesByParentID(phase.ParentID)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	h := pc.history[phase.ParentID]
+	current := len(h) > 0 && h[len(h)-1] == phase.ID
+	if current && len(h) >= 2 {
+		if prev := siblings.GetByID(h[len(h)-2]); prev != nil {
+			pc.history[phase.ParentID] = h[:len(h)-1]
+			return prev
+		}
+	}
+
+	prev := siblings.GetByOrder(phase.Order - 1)
+	if prev != nil && current {
+		pc.history[phase.ParentID] = h[:len(h)-1]
+	}
+	return prev
+}
+
+// commonAncestor はフェーズの集合のうち最も深い共通の祖先(自身を含む)を返します
+// フェーズが1つの場合はそのフェーズを返します
+func commonAncestor(phases entity.Phases) *entity.Phase {
+	common := pathFromRoot(phases[0])
+	for _, phase := range phases[1:] {
+		path := pathFromRoot(phase)
+		n := 0
+		for n < len(common) && n < len(path) && common[n] == path[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(common) == 0 {
+		return nil
+	}
+	return common[len(common)-1]
+}
+
+// pathFromRoot はルートフェーズから指定したフェーズまでの祖先を順に並べて返します
+func pathFromRoot(phase *entity.Phase) entity.Phases {
+	path := make(entity.Phases, 0)
+	for p := phase; p != nil; p = p.Parent {
+		path = append(entity.Phases{p}, path...)
+	}
+	return path
+}
+
+// treeOrder はルートフェーズから深さ優先でたどった時の、各フェーズの順番を返します
+func (pc *PhaseController) treeOrder() map[value.PhaseID]int {
+	order := make(map[value.PhaseID]int)
+	var walk func(parentID value.PhaseID)
+	walk = func(parentID value.PhaseID) {
+		for _, phase := range pc.phaseFacade.GetPhasesByParentID(parentID) {
+			order[phase.ID] = len(order)
+			walk(phase.ID)
+		}
+	}
+	walk(0)
+	return order
+}
+
+// markSubtree はフェーズとその子孫をsetに加えます
+func markSubtree(phase *entity.Phase, set map[value.PhaseID]bool) {
+	set[phase.ID] = true
+	for _, child := range phase.GetChildren() {
+		markSubtree(child, set)
+	}
+}
+
+// IsPaused はゲーム全体が一時停止中かどうかを返します
+func (pc *PhaseController) IsPaused() bool {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	return pc.paused
+}
+
 // Reset は全てのフェーズをリセットします
 func (pc *PhaseController) Reset(ctx context.Context) error {
 	allPhases := pc.GetPhases()
@@ -221,6 +850,13 @@ func (pc *PhaseController) Reset(ctx context.Context) error {
 
 	pc.log.Debug("PhaseController.Reset", zap.String("action", "Resetting all phases"))
 
+	pc.mu.Lock()
+	pc.paused = false
+	pc.pending = nil
+	pc.retries = make(map[value.PhaseID]int)
+	pc.history = make(map[value.PhaseID][]value.PhaseID)
+	pc.mu.Unlock()
+
 	// 全フェーズをリセット
 	for _, phase := range allPhases {
 		if err := phase.Reset(ctx); err != nil {
@@ -242,6 +878,46 @@ func (pc *PhaseController) Reset(ctx context.Context) error {
 	return nil
 }
 
+// Restore はスナップショットから全フェーズの状態を復元します
+func (pc *PhaseController) Restore(ctx context.Context, snapshot *entity.Snapshot) error {
+	if err := pc.phaseFacade.Restore(ctx, snapshot); err != nil {
+		pc.log.Error("PhaseController.Restore", zap.Error(err))
+		return err
+	}
+
+	// 一時停止中のフェーズがあれば、ゲーム全体も一時停止中として復元する
+	paused := false
+	for _, phase := range pc.GetPhases() {
+		if phase.IsPaused() {
+			paused = true
+			break
+		}
+	}
+	pc.mu.Lock()
+	pc.paused = paused
+	pc.pending = nil
+	pc.retries = make(map[value.PhaseID]int)
+	pc.history = make(map[value.PhaseID][]value.PhaseID)
+	pc.mu.Unlock()
+
+	for _, leaf := range pc.phaseFacade.GetCurrentLeafPhases() {
+		pc.NotifyEntityChanged(leaf)
+	}
+
+	// next状態で保存されたフェーズは次フェーズへの遷移待ちだったので、遷移を再開する
+	// 遷移を終えてから戻るので、呼び出し元は戻った時点で遷移後の状態を参照できる
+	// 一時停止中の場合は再開されるまで保留する
+	for _, phase := range pc.GetPhases() {
+		if phase.CurrentState() == value.StateNext {
+			pc.log.Debug("PhaseController.Restore: resuming pending transition",
+				zap.String("phase", phase.Name))
+			pc.OnPhaseChanged(phase)
+		}
+	}
+
+	return nil
+}
+
 func (pc *PhaseController) AddControllerObserver(observer service.ControllerObserver) {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
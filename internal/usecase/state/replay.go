@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
+
+	"go.uber.org/zap"
+)
+
+// Replay はジャーナルに記録された入力を新しいGameFacadeに順番に適用して状態を再構築します
+//
+// 再生するのはオペレーター操作、評価値の入力、タイマーによるタイムアウト(時間条件の発火や保持時間の満了)、
+// 時間窓からの入力の期限切れ、期限切れによる失敗のみです。
+// それ以外の状態遷移はこれらの入力の結果として再現されます。
+// 記録時に失敗した入力は再生時にも失敗するため、エラーがあっても最後まで再生し、まとめて返します。
+//
+// タイマーの発火はジャーナルから再生するため、facadeはclock.Fakeで動かし(フェーズもNewStrategyFactoryWithClockで
+// 同じFakeを使って構築します)、遷移の待ち時間を0にしておく必要があります。
+// 実時間で動くfacadeでは動いているタイマーも発火して入力が二重に適用され、待ち時間が残っているとFakeのSleepで止まるため、
+// どちらかを満たさないfacadeは再生せずにエラーを返します。
+func Replay(ctx context.Context, facade *GameFacade, entries []JournalEntry) error {
+	if err := checkReplayable(facade); err != nil {
+		return err
+	}
+
+	log := logger.DefaultLogger()
+	var errs []error
+
+	for _, entry := range entries {
+		var err error
+		switch {
+		case entry.Kind == JournalAction:
+			err = replayAction(ctx, facade, entry)
+		case entry.Kind == JournalEvaluate && entry.Value != nil:
+			_, err = facade.EvaluatePartValue(ctx, int64(entry.ConditionID), int64(entry.PartID), entry.Value)
+		case entry.Kind == JournalEvaluate:
+			_, err = facade.EvaluatePart(ctx, int64(entry.ConditionID), int64(entry.PartID), entry.Increment)
+		case entry.Kind == JournalPartTransition && (entry.Event == value.EventTimeout || entry.Event == value.EventExpire):
+			part, findErr := facade.GetConditionPart(int64(entry.ConditionID), int64(entry.PartID))
+			if findErr != nil {
+				err = findErr
+				break
+			}
+			// タイマーが発火した時と同じ経路で通知する
+			part.OnUpdated(entry.Event)
+		case entry.Kind == JournalPartTransition && entry.Event == value.EventFail:
+			// パーツの失敗は期限切れによるものだけなので、期限のタイマーが発火した時と同じ経路で失敗させる
+			part, findErr := facade.GetConditionPart(int64(entry.ConditionID), int64(entry.PartID))
+			if findErr != nil {
+				err = findErr
+				break
+			}
+			err = part.ExpireDeadline(ctx)
+		default:
+			continue
+		}
+
+		if err != nil {
+			log.Debug("Replay: entry failed",
+				zap.Uint64("seq", entry.Seq),
+				zap.String("kind", string(entry.Kind)),
+				zap.Error(err))
+			errs = append(errs, fmt.Errorf("seq %d (%s): %w", entry.Seq, entry.Kind, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkReplayable はfacadeがジャーナルを決定的に再生できる設定になっているかを確認します
+func checkReplayable(facade *GameFacade) error {
+	pc := facade.controller
+	pc.mu.RLock()
+	c, delay := pc.clock, pc.transitionDelay
+	pc.mu.RUnlock()
+
+	if _, ok := c.(*clock.Fake); !ok {
+		return fmt.Errorf("replay requires a facade running on clock.Fake")
+	}
+	if delay > 0 {
+		return fmt.Errorf("replay requires a zero transition delay, got %s", delay)
+	}
+	return nil
+}
+
+// replayAction はオペレーター操作を再生します
+func replayAction(ctx context.Context, facade *GameFacade, entry JournalEntry) error {
+	switch entry.Action {
+	case ActionStart:
+		return facade.Start(ctx)
+	case ActionReset:
+		return facade.Reset(ctx)
+	case ActionPause:
+		return facade.Pause(ctx)
+	case ActionResume:
+		return facade.Resume(ctx)
+	case ActionAbort:
+		return facade.Abort(ctx)
+	case ActionSkip:
+		return facade.Skip(ctx)
+	case ActionJump:
+		return facade.JumpTo(ctx, entry.PhaseID)
+	case ActionRewind:
+		return facade.Rewind(ctx)
+	default:
+		return fmt.Errorf("unknown action: %s", entry.Action)
+	}
+}
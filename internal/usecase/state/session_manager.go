@@ -0,0 +1,134 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	logger "state_sample/internal/lib"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SessionID はゲームセッションを識別するIDです
+type SessionID string
+
+// DefaultSessionID はセッションを指定しないリクエストが使うセッションのIDです
+const DefaultSessionID SessionID = "default"
+
+// Session は独立したGameFacadeを持つゲームセッションです
+type Session struct {
+	ID        SessionID
+	Facade    *GameFacade
+	CreatedAt time.Time
+}
+
+// FacadeFactory はセッションごとに新しいGameFacadeを作成する関数です
+type FacadeFactory func() (*GameFacade, error)
+
+// SessionManager は複数のゲームセッションの作成・取得・破棄を管理します
+type SessionManager struct {
+	sessions  map[SessionID]*Session
+	newFacade FacadeFactory
+	mu        sync.RWMutex
+	log       *zap.Logger
+}
+
+// NewSessionManager は新しいSessionManagerを作成します
+func NewSessionManager(newFacade FacadeFactory) *SessionManager {
+	return &SessionManager{
+		sessions:  make(map[SessionID]*Session),
+		newFacade: newFacade,
+		log:       logger.DefaultLogger(),
+	}
+}
+
+// Create は新しいセッションを作成します
+// idが空の場合はランダムなIDを割り当てます
+func (m *SessionManager) Create(id SessionID) (*Session, error) {
+	if id == "" {
+		generated, err := generateSessionID()
+		if err != nil {
+			return nil, err
+		}
+		id = generated
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[id]; exists {
+		return nil, fmt.Errorf("session already exists: %s", id)
+	}
+
+	facade, err := m.newFacade()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create facade for session %s: %w", id, err)
+	}
+
+	session := &Session{
+		ID:        id,
+		Facade:    facade,
+		CreatedAt: time.Now(),
+	}
+	m.sessions[id] = session
+
+	m.log.Debug("SessionManager.Create", zap.String("session_id", string(id)))
+	return session, nil
+}
+
+// Get は指定されたIDのセッションを返します
+func (m *SessionManager) Get(id SessionID) (*Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.sessions[id]
+	return session, ok
+}
+
+// List は全てのセッションを作成順に返します
+func (m *SessionManager) List() []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+// Destroy はセッションをリセットして破棄します
+// リセットによって実行中のタイマーも停止されます
+func (m *SessionManager) Destroy(ctx context.Context, id SessionID) error {
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	if ok {
+		delete(m.sessions, id)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	m.log.Debug("SessionManager.Destroy", zap.String("session_id", string(id)))
+	return session.Facade.Reset(ctx)
+}
+
+// generateSessionID はランダムなセッションIDを生成します
+func generateSessionID() (SessionID, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return SessionID(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSessionManager() *SessionManager {
+	return NewSessionManager(func() (*GameFacade, error) {
+		return NewStateFacade(), nil
+	})
+}
+
+func TestSessionManagerCreate(t *testing.T) {
+	manager := newTestSessionManager()
+
+	room1, err := manager.Create("room1")
+	require.NoError(t, err)
+	assert.Equal(t, SessionID("room1"), room1.ID)
+
+	// IDを省略した場合は自動で割り当てられる
+	generated, err := manager.Create("")
+	require.NoError(t, err)
+	assert.NotEmpty(t, generated.ID)
+
+	// セッションごとに独立したGameFacadeを持つ
+	assert.NotSame(t, room1.Facade, generated.Facade)
+
+	// 同じIDでは作成できない
+	_, err = manager.Create("room1")
+	assert.Error(t, err)
+
+	// ファクトリのエラーはそのまま返す
+	failing := NewSessionManager(func() (*GameFacade, error) {
+		return nil, errors.New("broken scenario")
+	})
+	_, err = failing.Create("room")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken scenario")
+	assert.Empty(t, failing.List())
+}
+
+func TestSessionManagerGetAndList(t *testing.T) {
+	manager := newTestSessionManager()
+	_, err := manager.Create("room1")
+	require.NoError(t, err)
+	_, err = manager.Create("room2")
+	require.NoError(t, err)
+
+	session, ok := manager.Get("room2")
+	assert.True(t, ok)
+	assert.Equal(t, SessionID("room2"), session.ID)
+
+	_, ok = manager.Get("missing")
+	assert.False(t, ok)
+
+	sessions := manager.List()
+	require.Len(t, sessions, 2)
+	assert.Equal(t, SessionID("room1"), sessions[0].ID)
+	assert.Equal(t, SessionID("room2"), sessions[1].ID)
+}
+
+func TestSessionManagerDestroy(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestSessionManager()
+	room1, err := manager.Create("room1")
+	require.NoError(t, err)
+	room2, err := manager.Create("room2")
+	require.NoError(t, err)
+
+	require.NoError(t, room1.Facade.Start(ctx))
+	require.NoError(t, room2.Facade.Start(ctx))
+
+	// 破棄したセッションはリセットされ、他のセッションには影響しない
+	require.NoError(t, manager.Destroy(ctx, "room1"))
+	_, ok := manager.Get("room1")
+	assert.False(t, ok)
+	assert.Equal(t, value.StateReady, room1.Facade.GetCurrentPhase(0).CurrentState())
+	assert.Equal(t, value.StateActive, room2.Facade.GetCurrentPhase(0).CurrentState())
+
+	assert.Error(t, manager.Destroy(ctx, "room1"))
+	require.NoError(t, manager.Destroy(ctx, "room2"))
+}
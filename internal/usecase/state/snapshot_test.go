@@ -0,0 +1,267 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSnapshotTestFacade はスナップショットのテスト用のGameFacadeを作成します
+func newSnapshotTestFacade(t *testing.T, fake *clock.Fake) *GameFacade {
+	return newFacadeWithFakeClock(t, fake,
+		Phase("ROOT").ID(1).Children(
+			Phase("CHILD1").ID(2).All(
+				Counter("hit").ID(1).GTE(5),
+				Timer("wait", 10).ID(2),
+			),
+			Phase("CHILD2").ID(3).Any(Counter("push").ID(3).GTE(1)),
+		),
+	)
+}
+
+// snapshotRoundTrip はスナップショットをJSONに変換して戻します
+func snapshotRoundTrip(t *testing.T, snapshot *entity.Snapshot) *entity.Snapshot {
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	var decoded entity.Snapshot
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return &decoded
+}
+
+// findPartSnapshot はスナップショットから条件パーツの状態を探します
+func findPartSnapshot(snapshot *entity.Snapshot, partID value.ConditionPartID) *entity.PartSnapshot {
+	for i := range snapshot.Phases {
+		for j := range snapshot.Phases[i].Conditions {
+			for k := range snapshot.Phases[i].Conditions[j].Parts {
+				if snapshot.Phases[i].Conditions[j].Parts[k].ID == partID {
+					return &snapshot.Phases[i].Conditions[j].Parts[k]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestGameFacadeSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	start := fake.Now()
+	facade := newSnapshotTestFacade(t, fake)
+	require.NoError(t, facade.Start(ctx))
+
+	counterPart, err := facade.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	require.NoError(t, counterPart.Process(ctx, 3))
+
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+	require.NoError(t, facade.Reset(ctx))
+
+	// 別プロセスを想定して新しいフェーズツリーに復元する
+	restored := newSnapshotTestFacade(t, newFakeClock())
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	root := restored.GetCurrentPhase(0)
+	require.NotNil(t, root)
+	assert.Equal(t, value.PhaseID(1), root.ID)
+	assert.Equal(t, value.StateActive, root.CurrentState())
+	assert.True(t, root.IsActive())
+
+	leaf := singleLeaf(t, restored)
+	require.NotNil(t, leaf)
+	assert.Equal(t, "CHILD1", leaf.Name)
+	assert.Equal(t, value.StateActive, leaf.CurrentState())
+	require.NotNil(t, leaf.StartTime)
+	assert.True(t, start.Equal(*leaf.StartTime))
+
+	restoredCounter, err := restored.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, value.StateProcessing, restoredCounter.CurrentState())
+	assert.Equal(t, int64(3), restoredCounter.GetCurrentValue())
+
+	// 復元後もカウンターは続きから数える
+	require.NoError(t, restoredCounter.Process(ctx, 2))
+	assert.True(t, restoredCounter.IsSatisfied())
+}
+
+func TestGameFacadeRestoreResumesTimer(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newSnapshotTestFacade(t, fake)
+	require.NoError(t, facade.Start(ctx))
+
+	// 10秒のタイマーが4秒進んだところで保存する
+	fake.Advance(4 * time.Second)
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+	require.NoError(t, facade.Reset(ctx))
+
+	timer := findPartSnapshot(snapshot, 2)
+	require.NotNil(t, timer)
+	require.NotNil(t, timer.Strategy)
+	assert.True(t, timer.Strategy.Running)
+	assert.Equal(t, int64(6000), timer.Strategy.RemainingMs)
+
+	restoredClock := newFakeClock()
+	restored := newSnapshotTestFacade(t, restoredClock)
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	// タイマーは最初からではなく残り時間から再開する
+	resumed := findPartSnapshot(restored.Snapshot(), 2)
+	require.NotNil(t, resumed)
+	assert.Equal(t, int64(6000), resumed.Strategy.RemainingMs)
+
+	timerPart, err := restored.GetConditionPart(2, 2)
+	require.NoError(t, err)
+	restoredClock.Advance(6 * time.Second)
+	assert.Eventually(t, timerPart.IsSatisfied, time.Second, time.Millisecond)
+}
+
+func TestGameFacadeRestoreResumesDeadline(t *testing.T) {
+	ctx := context.Background()
+	newFacade := func(fake *clock.Fake) *GameFacade {
+		return newFacadeWithFakeClock(t, fake,
+			Phase("RACE").ID(1).All(Counter("hit").ID(1).GTE(3).Deadline(10)),
+		)
+	}
+	fake := newFakeClock()
+	facade := newFacade(fake)
+	require.NoError(t, facade.Start(ctx))
+
+	fake.Advance(4 * time.Second)
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+	require.NoError(t, facade.Reset(ctx))
+	part := findPartSnapshot(snapshot, 1)
+	require.NotNil(t, part)
+	assert.Equal(t, int64(6000), part.DeadlineRemainingMs)
+
+	// 期限は最初からではなく残り時間から再開する
+	restoredClock := newFakeClock()
+	restored := newFacade(restoredClock)
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	hit, err := restored.GetConditionPart(1, 1)
+	require.NoError(t, err)
+	restoredClock.Advance(5 * time.Second)
+	assert.Never(t, hit.IsFailed, 50*time.Millisecond, time.Millisecond)
+	restoredClock.Advance(time.Second)
+	assert.Eventually(t, hit.IsFailed, time.Second, time.Millisecond)
+}
+
+func TestGameFacadeRestoreFailedPhase(t *testing.T) {
+	ctx := context.Background()
+	facade := newSnapshotTestFacade(t, newFakeClock())
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	// 中断した子フェーズの失敗はルートフェーズまで引き継がれる
+	require.NoError(t, facade.Abort(ctx))
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+
+	restored := newSnapshotTestFacade(t, newFakeClock())
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	phases := restored.GetController().GetPhases()
+	for _, id := range []value.PhaseID{1, 2} {
+		phase := phases.GetByID(id)
+		assert.True(t, phase.IsFailed(), phase.Name)
+		assert.Equal(t, value.OutcomeAborted, phase.Outcome, phase.Name)
+	}
+	assert.Equal(t, value.OutcomeNone, phases.GetByID(3).Outcome)
+}
+
+func TestGameFacadeRestoreRejectsForeignSnapshot(t *testing.T) {
+	ctx := context.Background()
+	facade := newSnapshotTestFacade(t, newFakeClock())
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() { _ = facade.Reset(ctx) })
+
+	other := NewStateFacade()
+	err := other.Restore(ctx, facade.Snapshot())
+	assert.Error(t, err)
+
+	// 別のツリーのスナップショットを渡しても状態は変更されない
+	assert.Nil(t, other.GetCurrentPhase(0))
+
+	snapshot := facade.Snapshot()
+	snapshot.Version = 0
+	assert.Error(t, newSnapshotTestFacade(t, newFakeClock()).Restore(ctx, snapshot))
+}
+
+func TestGameFacadeRestorePaused(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	facade := newSnapshotTestFacade(t, fake)
+	require.NoError(t, facade.Start(ctx))
+
+	fake.Advance(4 * time.Second)
+	require.NoError(t, facade.Pause(ctx))
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+	require.NoError(t, facade.Reset(ctx))
+
+	restoredClock := newFakeClock()
+	restored := newSnapshotTestFacade(t, restoredClock)
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	// 一時停止中のまま復元され、タイマーは再開するまで発火しない
+	assert.True(t, restored.IsPaused())
+	assert.Equal(t, value.StatePaused, singleLeaf(t, restored).CurrentState())
+	_, err := restored.EvaluatePart(ctx, 1, 1, 1)
+	assert.Error(t, err)
+
+	timerPart, err := restored.GetConditionPart(2, 2)
+	require.NoError(t, err)
+	restoredClock.Advance(time.Minute)
+	assert.False(t, timerPart.IsSatisfied())
+
+	require.NoError(t, restored.Resume(ctx))
+	restoredClock.Advance(6 * time.Second)
+	assert.Eventually(t, timerPart.IsSatisfied, time.Second, time.Millisecond)
+}
+
+func TestGameFacadeRestoreNext(t *testing.T) {
+	ctx := context.Background()
+	build := func(fake *clock.Fake) *GameFacade {
+		return newFacadeWithFakeClock(t, fake,
+			Phase("WAIT").ID(1).All(Timer("wait", 5).ID(1)),
+			Phase("AFTER").ID(2).All(Counter("hit").ID(2).GTE(1)),
+		)
+	}
+
+	// 遷移の待ち時間中(next状態)にスナップショットを取る
+	fake := newFakeClock()
+	facade := build(fake)
+	facade.GetController().SetTransitionDelay(DefaultTransitionDelay)
+	require.NoError(t, facade.Start(ctx))
+	t.Cleanup(func() {
+		fake.Advance(DefaultTransitionDelay)
+		_ = facade.Reset(ctx)
+	})
+	fake.Advance(5 * time.Second)
+	first := facade.GetCurrentPhase(0)
+	require.Eventually(t, func() bool {
+		return first.CurrentState() == value.StateNext
+	}, time.Second, time.Millisecond)
+	snapshot := snapshotRoundTrip(t, facade.Snapshot())
+
+	// 復元が戻った時点で、保留されていた遷移が終わっている
+	restored := build(newFakeClock())
+	require.NoError(t, restored.Restore(ctx, snapshot))
+	t.Cleanup(func() { _ = restored.Reset(ctx) })
+
+	current := restored.GetCurrentPhase(0)
+	require.NotNil(t, current)
+	assert.Equal(t, "AFTER", current.Name)
+	assert.Equal(t, value.StateActive, current.CurrentState())
+}
@@ -3,6 +3,7 @@ package strategy
 import (
 	"context"
 	"fmt"
+	"slices"
 	"state_sample/internal/domain/entity"
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
@@ -15,6 +16,7 @@ import (
 // CounterStrategy はカウンターベースの条件評価戦略です
 type CounterStrategy struct {
 	currentValue int64
+	paused       bool
 	observers    []service.StrategyObserver
 	mu           sync.RWMutex
 }
@@ -60,33 +62,25 @@ func (s *CounterStrategy) Evaluate(ctx context.Context, part interface{}, params
 	if !ok {
 		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
 	}
-	increment := params.(int64)
+	increment, ok := params.(int64)
+	if !ok {
+		return fmt.Errorf("invalid params type: expected int64, got %T", params)
+	}
 
 	// カウンター値を更新
 	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("counter is paused")
+	}
 	s.currentValue += increment
 	s.mu.Unlock()
 	log.Debug("currentValue", zap.Int64("currentValue", s.currentValue))
 
 	// ComparisonOperatorを使用して条件を評価
-	satisfied := false
-	switch condPart.GetComparisonOperator() {
-	case value.ComparisonOperatorEQ:
-		satisfied = s.currentValue == condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorNEQ:
-		satisfied = s.currentValue != condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorGT:
-		satisfied = s.currentValue > condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorGTE:
-		satisfied = s.currentValue >= condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorLT:
-		satisfied = s.currentValue < condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorLTE:
-		satisfied = s.currentValue <= condPart.GetReferenceValueInt()
-	case value.ComparisonOperatorBetween:
-		satisfied = s.currentValue >= condPart.GetMinValue() && s.currentValue <= condPart.GetMaxValue()
-	default:
-		return fmt.Errorf("unsupported comparison operator: %v", condPart.GetComparisonOperator())
+	satisfied, err := compareInt(condPart, s.currentValue)
+	if err != nil {
+		return err
 	}
 
 	log.Debug("Counter Evaluate",
@@ -105,12 +99,72 @@ func (s *CounterStrategy) Evaluate(ctx context.Context, part interface{}, params
 	return nil
 }
 
+// compareInt は整数値をパーツの比較演算子と基準値で比較します
+func compareInt(condPart *entity.ConditionPart, current int64) (bool, error) {
+	switch condPart.GetComparisonOperator() {
+	case value.ComparisonOperatorEQ:
+		return current == condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorNEQ:
+		return current != condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorGT:
+		return current > condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorGTE:
+		return current >= condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorLT:
+		return current < condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorLTE:
+		return current <= condPart.GetReferenceValueInt(), nil
+	case value.ComparisonOperatorBetween:
+		return current >= condPart.GetMinValue() && current <= condPart.GetMaxValue(), nil
+	case value.ComparisonOperatorIn:
+		return slices.Contains(condPart.GetReferenceValueSet(), current), nil
+	case value.ComparisonOperatorNotIn:
+		return !slices.Contains(condPart.GetReferenceValueSet(), current), nil
+	default:
+		return false, fmt.Errorf("unsupported comparison operator: %v", condPart.GetComparisonOperator())
+	}
+}
+
+// Pause はカウンターを一時停止し、再開するまで入力を受け付けないようにします
+func (s *CounterStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+// Resume は一時停止したカウンターの入力の受け付けを再開します
+func (s *CounterStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+// Snapshot はカウンターの現在値を保存します
+func (s *CounterStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return service.StrategySnapshot{CurrentValue: s.currentValue, Paused: s.paused}
+}
+
+// Restore はスナップショットからカウンターの現在値を復元します
+// 復元時は評価を行わないため、オブザーバーへの通知も行いません
+func (s *CounterStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentValue = snapshot.CurrentValue
+	s.paused = snapshot.Paused
+	return nil
+}
+
 // Cleanup は戦略のリソースを解放します
 func (s *CounterStrategy) Cleanup() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.currentValue = 0
+	s.paused = false
 	// observersをnilにするのではなく、空のスライスにする
 	s.observers = make([]service.StrategyObserver, 0)
 	return nil
@@ -79,6 +79,7 @@ func TestCounterStrategyEvaluate(t *testing.T) {
 		increment         int64
 		operator          value.ComparisonOperator
 		referenceValue    int64
+		referenceSet      []int64
 		minValue          int64
 		maxValue          int64
 		expectedSatisfied bool
@@ -260,6 +261,42 @@ func TestCounterStrategyEvaluate(t *testing.T) {
 			expectedSatisfied: false,
 			expectedEvent:     value.EventProcess,
 		},
+		{
+			name:              "In_Satisfied",
+			initialValue:      0,
+			increment:         3,
+			operator:          value.ComparisonOperatorIn,
+			referenceSet:      []int64{1, 3, 5},
+			expectedSatisfied: true,
+			expectedEvent:     value.EventComplete,
+		},
+		{
+			name:              "In_Unsatisfied",
+			initialValue:      0,
+			increment:         4,
+			operator:          value.ComparisonOperatorIn,
+			referenceSet:      []int64{1, 3, 5},
+			expectedSatisfied: false,
+			expectedEvent:     value.EventProcess,
+		},
+		{
+			name:              "NotIn_Satisfied",
+			initialValue:      0,
+			increment:         4,
+			operator:          value.ComparisonOperatorNotIn,
+			referenceSet:      []int64{1, 3, 5},
+			expectedSatisfied: true,
+			expectedEvent:     value.EventComplete,
+		},
+		{
+			name:              "NotIn_Unsatisfied",
+			initialValue:      0,
+			increment:         5,
+			operator:          value.ComparisonOperatorNotIn,
+			referenceSet:      []int64{1, 3, 5},
+			expectedSatisfied: false,
+			expectedEvent:     value.EventProcess,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -276,6 +313,7 @@ func TestCounterStrategyEvaluate(t *testing.T) {
 			part := entity.NewConditionPart(1, "Test Part")
 			part.ComparisonOperator = tc.operator
 			part.ReferenceValueInt = tc.referenceValue
+			part.ReferenceValueSet = tc.referenceSet
 			part.MinValue = tc.minValue
 			part.MaxValue = tc.maxValue
 
@@ -306,7 +344,7 @@ func TestCounterStrategyEvaluate(t *testing.T) {
 
 	// 未サポートの比較演算子
 	part := entity.NewConditionPart(1, "Test Part")
-	part.ComparisonOperator = value.ComparisonOperatorIn // 未サポート
+	part.ComparisonOperator = value.ComparisonOperatorUnspecified // 未サポート
 	err = strategy.Evaluate(context.Background(), part, int64(5))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported comparison operator")
@@ -409,3 +447,48 @@ func TestCounterStrategyObserver(t *testing.T) {
 	assert.Len(t, mockObserver2.Events, 1)
 	assert.Equal(t, "another_event", mockObserver2.Events[0])
 }
+
+func TestCounterStrategySnapshotRestore(t *testing.T) {
+	strategy := NewCounterStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	assert.NoError(t, strategy.Initialize(part))
+	strategy.currentValue = 7
+
+	snapshot := strategy.Snapshot()
+	assert.Equal(t, int64(7), snapshot.CurrentValue)
+
+	// 新しい戦略に復元すると現在値が引き継がれる
+	restored := NewCounterStrategy()
+	assert.NoError(t, restored.Initialize(part))
+	assert.NoError(t, restored.Restore(context.Background(), part, snapshot))
+	assert.Equal(t, int64(7), restored.GetCurrentValue())
+}
+
+func TestCounterStrategyPauseResume(t *testing.T) {
+	strategy := NewCounterStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	part.ReferenceValueInt = 5
+	assert.NoError(t, strategy.Initialize(part))
+
+	ctx := context.Background()
+	mockObserver := &MockStrategyObserver{}
+	strategy.observers[0] = mockObserver
+
+	assert.NoError(t, strategy.Evaluate(ctx, part, int64(2)))
+
+	// 一時停止中の入力は拒否され、値も通知も変わらない
+	assert.NoError(t, strategy.Pause())
+	err := strategy.Evaluate(ctx, part, int64(3))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "paused")
+	assert.Equal(t, int64(2), strategy.currentValue)
+	assert.Len(t, mockObserver.Events, 1)
+	assert.True(t, strategy.Snapshot().Paused)
+
+	// 再開後は続きから数える
+	assert.NoError(t, strategy.Resume())
+	assert.NoError(t, strategy.Evaluate(ctx, part, int64(3)))
+	assert.Equal(t, int64(5), strategy.currentValue)
+	assert.Equal(t, value.EventComplete, mockObserver.Events[1])
+}
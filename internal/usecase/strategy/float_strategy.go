@@ -0,0 +1,206 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// floatEqualityTolerance はEQ / NEQで等しいとみなす差の上限です
+// 測定値の丸め誤差(0.1 + 0.2 と 0.3 など)で一致しなくなるのを防ぎます
+const floatEqualityTolerance = 1e-9
+
+// FloatStrategy は小数の測定値を基準値と比較する条件評価戦略です
+// カウンターと異なり入力値は加算せず、最新の測定値として置き換えます
+type FloatStrategy struct {
+	currentValue float64
+	paused       bool
+	observers    []service.StrategyObserver
+	mu           sync.RWMutex
+}
+
+// NewFloatStrategy は新しいFloatStrategyを作成します
+func NewFloatStrategy() *FloatStrategy {
+	return &FloatStrategy{
+		observers: make([]service.StrategyObserver, 0),
+	}
+}
+
+// Initialize は戦略の初期化を行います
+func (s *FloatStrategy) Initialize(part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	s.currentValue = 0
+	s.AddObserver(condPart)
+	return nil
+}
+
+func (s *FloatStrategy) GetCurrentValue() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentValue
+}
+
+func (s *FloatStrategy) Start(ctx context.Context, part interface{}) error {
+	return nil
+}
+
+// Evaluate は最新の測定値で小数条件を評価します
+func (s *FloatStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	log := logger.DefaultLogger()
+
+	if params == nil {
+		return fmt.Errorf("invalid nil params: %v", params)
+	}
+
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+	measured, err := toFloat64(params)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("float condition is paused")
+	}
+	s.currentValue = measured
+	s.mu.Unlock()
+
+	reference := condPart.GetReferenceValueFloat()
+	satisfied := false
+	switch condPart.GetComparisonOperator() {
+	case value.ComparisonOperatorEQ:
+		satisfied = math.Abs(measured-reference) <= floatEqualityTolerance
+	case value.ComparisonOperatorNEQ:
+		satisfied = math.Abs(measured-reference) > floatEqualityTolerance
+	case value.ComparisonOperatorGT:
+		satisfied = measured > reference
+	case value.ComparisonOperatorGTE:
+		satisfied = measured >= reference
+	case value.ComparisonOperatorLT:
+		satisfied = measured < reference
+	case value.ComparisonOperatorLTE:
+		satisfied = measured <= reference
+	case value.ComparisonOperatorBetween:
+		satisfied = measured >= condPart.GetMinValueFloat() && measured <= condPart.GetMaxValueFloat()
+	default:
+		return fmt.Errorf("unsupported comparison operator for float: %v", condPart.GetComparisonOperator())
+	}
+
+	log.Debug("Float Evaluate",
+		zap.Bool("satisfied", satisfied),
+		zap.Float64("currentValue", measured),
+		zap.Float64("referenceValue", reference),
+		zap.Int("comparisonOperator", int(condPart.GetComparisonOperator())))
+
+	if satisfied {
+		s.NotifyUpdate(value.EventComplete)
+	} else {
+		s.NotifyUpdate(value.EventProcess)
+	}
+	return nil
+}
+
+// toFloat64 は評価値を小数に変換します
+func toFloat64(params interface{}) (float64, error) {
+	switch v := params.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("invalid params type: expected float64, got %T", params)
+	}
+}
+
+// Pause は再開するまで測定値の入力を受け付けないようにします
+func (s *FloatStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+// Resume は測定値の入力の受け付けを再開します
+func (s *FloatStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+// Snapshot は最新の測定値を保存します
+func (s *FloatStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return service.StrategySnapshot{FloatValue: s.currentValue, Paused: s.paused}
+}
+
+// Restore はスナップショットから最新の測定値を復元します
+func (s *FloatStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentValue = snapshot.FloatValue
+	s.paused = snapshot.Paused
+	return nil
+}
+
+// Cleanup は戦略のリソースを解放します
+func (s *FloatStrategy) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentValue = 0
+	s.paused = false
+	s.observers = make([]service.StrategyObserver, 0)
+	return nil
+}
+
+// AddObserver オブザーバーを追加します
+func (s *FloatStrategy) AddObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// RemoveObserver オブザーバーを削除します
+func (s *FloatStrategy) RemoveObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, obs := range s.observers {
+		if obs == observer {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			break
+		}
+	}
+}
+
+// NotifyUpdate オブザーバーに更新を通知します
+func (s *FloatStrategy) NotifyUpdate(event string) {
+	s.mu.RLock()
+	observers := make([]service.StrategyObserver, len(s.observers))
+	copy(observers, s.observers)
+	s.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnUpdated(event)
+	}
+}
@@ -0,0 +1,99 @@
+package strategy
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloatStrategyEvaluate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          interface{}
+		operator       value.ComparisonOperator
+		referenceValue float64
+		minValue       float64
+		maxValue       float64
+		expectedEvent  string
+	}{
+		{name: "EQ_Satisfied", input: 0.5, operator: value.ComparisonOperatorEQ, referenceValue: 0.5, expectedEvent: value.EventComplete},
+		{name: "EQ_WithinTolerance", input: 0.1 + 0.2, operator: value.ComparisonOperatorEQ, referenceValue: 0.3, expectedEvent: value.EventComplete},
+		{name: "EQ_Unsatisfied", input: 0.4, operator: value.ComparisonOperatorEQ, referenceValue: 0.5, expectedEvent: value.EventProcess},
+		{name: "NEQ_Satisfied", input: 0.4, operator: value.ComparisonOperatorNEQ, referenceValue: 0.5, expectedEvent: value.EventComplete},
+		{name: "GT_Satisfied", input: 0.95, operator: value.ComparisonOperatorGT, referenceValue: 0.9, expectedEvent: value.EventComplete},
+		{name: "GT_Unsatisfied", input: 0.9, operator: value.ComparisonOperatorGT, referenceValue: 0.9, expectedEvent: value.EventProcess},
+		{name: "GTE_Satisfied", input: 0.9, operator: value.ComparisonOperatorGTE, referenceValue: 0.9, expectedEvent: value.EventComplete},
+		{name: "LT_Satisfied", input: -1.5, operator: value.ComparisonOperatorLT, referenceValue: 0, expectedEvent: value.EventComplete},
+		{name: "LTE_Unsatisfied", input: 0.01, operator: value.ComparisonOperatorLTE, referenceValue: 0, expectedEvent: value.EventProcess},
+		{name: "Between_Satisfied", input: 36.6, operator: value.ComparisonOperatorBetween, minValue: 36, maxValue: 37, expectedEvent: value.EventComplete},
+		{name: "Between_Unsatisfied", input: 37.2, operator: value.ComparisonOperatorBetween, minValue: 36, maxValue: 37, expectedEvent: value.EventProcess},
+		{name: "Between_FractionalBounds", input: 0.75, operator: value.ComparisonOperatorBetween, minValue: 0.5, maxValue: 1.5, expectedEvent: value.EventComplete},
+		{name: "Between_BelowFractionalMin", input: 0.4, operator: value.ComparisonOperatorBetween, minValue: 0.5, maxValue: 1.5, expectedEvent: value.EventProcess},
+		{name: "Int64Input", input: int64(1), operator: value.ComparisonOperatorGTE, referenceValue: 0.5, expectedEvent: value.EventComplete},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := NewFloatStrategy()
+			mockObserver := &MockStrategyObserver{}
+			strategy.AddObserver(mockObserver)
+
+			part := entity.NewConditionPart(1, "Test Part")
+			part.ComparisonOperator = tc.operator
+			part.ReferenceValueFloat = tc.referenceValue
+			part.MinValueFloat = tc.minValue
+			part.MaxValueFloat = tc.maxValue
+
+			require.NoError(t, strategy.Evaluate(context.Background(), part, tc.input))
+			assert.Equal(t, []string{tc.expectedEvent}, mockObserver.Events)
+		})
+	}
+}
+
+func TestFloatStrategyReplacesValue(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewFloatStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	part.ReferenceValueFloat = 0.9
+
+	// 測定値は加算されず、最新の値で置き換えられる
+	require.NoError(t, strategy.Evaluate(ctx, part, 0.5))
+	require.NoError(t, strategy.Evaluate(ctx, part, 0.5))
+	assert.Equal(t, 0.5, strategy.GetCurrentValue())
+
+	snapshot := strategy.Snapshot()
+	assert.Equal(t, 0.5, snapshot.FloatValue)
+
+	restored := NewFloatStrategy()
+	require.NoError(t, restored.Restore(ctx, part, snapshot))
+	assert.Equal(t, 0.5, restored.GetCurrentValue())
+}
+
+func TestFloatStrategyEvaluateErrors(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewFloatStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+
+	err := strategy.Evaluate(ctx, part, "0.5")
+	assert.ErrorContains(t, err, "invalid params type")
+
+	err = strategy.Evaluate(ctx, part, nil)
+	assert.ErrorContains(t, err, "invalid nil params")
+
+	// 小数に対して集合の比較はサポートしない
+	part.ComparisonOperator = value.ComparisonOperatorIn
+	err = strategy.Evaluate(ctx, part, 0.5)
+	assert.ErrorContains(t, err, "unsupported comparison operator")
+
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	require.NoError(t, strategy.Pause())
+	assert.Error(t, strategy.Evaluate(ctx, part, 0.5))
+	require.NoError(t, strategy.Resume())
+	assert.NoError(t, strategy.Evaluate(ctx, part, 0.5))
+}
@@ -0,0 +1,217 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// SequenceStrategy は決められた順番で値が入力されたときに満たされる条件評価戦略です
+// 入力すべき順番はパーツのReferenceValueSetで指定し、何番目まで正しく入力されたかを現在値として返します
+type SequenceStrategy struct {
+	progress  int64
+	paused    bool
+	observers []service.StrategyObserver
+	mu        sync.RWMutex
+}
+
+// NewSequenceStrategy は新しいSequenceStrategyを作成します
+func NewSequenceStrategy() *SequenceStrategy {
+	return &SequenceStrategy{
+		observers: make([]service.StrategyObserver, 0),
+	}
+}
+
+// Initialize は戦略の初期化を行います
+func (s *SequenceStrategy) Initialize(part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	if len(condPart.GetSequence()) == 0 {
+		return fmt.Errorf("sequence must not be empty")
+	}
+
+	s.mu.Lock()
+	s.progress = 0
+	s.mu.Unlock()
+
+	s.AddObserver(condPart)
+	return nil
+}
+
+// GetCurrentValue は正しく入力された手順の数を返します
+func (s *SequenceStrategy) GetCurrentValue() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.progress
+}
+
+func (s *SequenceStrategy) Start(ctx context.Context, part interface{}) error {
+	return nil
+}
+
+// Evaluate は入力値が次に入力すべき値と一致するかを評価し、進み具合を更新します
+// 誤った入力の場合、パーツのOnMissに応じて最初からやり直すか、進み具合を保ちます
+func (s *SequenceStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	log := logger.DefaultLogger()
+
+	if params == nil {
+		return fmt.Errorf("invalid nil params: %v", params)
+	}
+
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+	input, err := toInt64(params)
+	if err != nil {
+		return err
+	}
+
+	sequence := condPart.GetSequence()
+	if len(sequence) == 0 {
+		return fmt.Errorf("sequence must not be empty")
+	}
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("sequence condition is paused")
+	}
+	if s.progress >= int64(len(sequence)) {
+		// 入力し終えた後の入力は進み具合を変えない
+		s.mu.Unlock()
+		s.NotifyUpdate(value.EventComplete)
+		return nil
+	}
+
+	switch {
+	case sequence[s.progress] == input:
+		s.progress++
+	case condPart.GetOnMiss() == value.SequenceMissStay:
+		// 進み具合を保ったまま次の入力を待つ
+	default:
+		// 最初からやり直す。誤った入力が最初の値であれば1つ目として数える
+		s.progress = 0
+		if sequence[0] == input {
+			s.progress = 1
+		}
+	}
+	progress := s.progress
+	s.mu.Unlock()
+
+	satisfied := progress >= int64(len(sequence))
+	log.Debug("Sequence Evaluate",
+		zap.Int64("input", input),
+		zap.Int64("progress", progress),
+		zap.Int("length", len(sequence)),
+		zap.Bool("satisfied", satisfied))
+
+	if satisfied {
+		s.NotifyUpdate(value.EventComplete)
+	} else {
+		s.NotifyUpdate(value.EventProcess)
+	}
+	return nil
+}
+
+// toInt64 は評価値を整数に変換します
+// JSONから受け取った数値はfloat64になるため、整数とみなせる値のみ受け付けます
+func toInt64(params interface{}) (int64, error) {
+	switch v := params.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("invalid params value: expected an integer, got %v", v)
+		}
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("invalid params type: expected int64, got %T", params)
+	}
+}
+
+// Pause は再開するまで入力を受け付けないようにします
+func (s *SequenceStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+// Resume は入力の受け付けを再開します
+func (s *SequenceStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+// Snapshot は進み具合を保存します
+func (s *SequenceStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return service.StrategySnapshot{CurrentValue: s.progress, Paused: s.paused}
+}
+
+// Restore はスナップショットから進み具合を復元します
+func (s *SequenceStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = snapshot.CurrentValue
+	s.paused = snapshot.Paused
+	return nil
+}
+
+// Cleanup は戦略のリソースを解放します
+func (s *SequenceStrategy) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.progress = 0
+	s.paused = false
+	s.observers = make([]service.StrategyObserver, 0)
+	return nil
+}
+
+// AddObserver オブザーバーを追加します
+func (s *SequenceStrategy) AddObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// RemoveObserver オブザーバーを削除します
+func (s *SequenceStrategy) RemoveObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, obs := range s.observers {
+		if obs == observer {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			break
+		}
+	}
+}
+
+// NotifyUpdate オブザーバーに更新を通知します
+func (s *SequenceStrategy) NotifyUpdate(event string) {
+	s.mu.RLock()
+	observers := make([]service.StrategyObserver, len(s.observers))
+	copy(observers, s.observers)
+	s.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnUpdated(event)
+	}
+}
@@ -0,0 +1,91 @@
+package strategy
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceStrategyEvaluate(t *testing.T) {
+	testCases := []struct {
+		name             string
+		onMiss           value.SequenceMissPolicy
+		inputs           []interface{}
+		expectedProgress int64
+		expectedLast     string
+	}{
+		{name: "InOrder", inputs: []interface{}{int64(3), int64(1), int64(4), int64(2)}, expectedProgress: 4, expectedLast: value.EventComplete},
+		{name: "Partial", inputs: []interface{}{int64(3), int64(1)}, expectedProgress: 2, expectedLast: value.EventProcess},
+		{name: "ResetOnMiss", inputs: []interface{}{int64(3), int64(1), int64(2)}, expectedProgress: 0, expectedLast: value.EventProcess},
+		{name: "ResetOnMissRestartsWithFirst", inputs: []interface{}{int64(3), int64(1), int64(3)}, expectedProgress: 1, expectedLast: value.EventProcess},
+		{name: "StayOnMiss", onMiss: value.SequenceMissStay, inputs: []interface{}{int64(3), int64(1), int64(2), int64(4)}, expectedProgress: 3, expectedLast: value.EventProcess},
+		{name: "JSONNumber", inputs: []interface{}{3.0, 1.0, 4.0, 2.0}, expectedProgress: 4, expectedLast: value.EventComplete},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			part := entity.NewConditionPart(1, "Buttons")
+			part.ReferenceValueSet = []int64{3, 1, 4, 2}
+			part.OnMiss = tc.onMiss
+
+			strategy := NewSequenceStrategy()
+			require.NoError(t, strategy.Initialize(part))
+			strategy.RemoveObserver(part)
+			mockObserver := &MockStrategyObserver{}
+			strategy.AddObserver(mockObserver)
+
+			for _, input := range tc.inputs {
+				require.NoError(t, strategy.Evaluate(context.Background(), part, input))
+			}
+			assert.Equal(t, tc.expectedProgress, strategy.GetCurrentValue())
+			require.Len(t, mockObserver.Events, len(tc.inputs))
+			assert.Equal(t, tc.expectedLast, mockObserver.Events[len(mockObserver.Events)-1])
+		})
+	}
+}
+
+func TestSequenceStrategyErrors(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewSequenceStrategy()
+
+	// 入力すべき順番がないパーツは初期化できない
+	assert.Error(t, strategy.Initialize(entity.NewConditionPart(1, "Empty")))
+
+	part := entity.NewConditionPart(2, "Buttons")
+	part.ReferenceValueSet = []int64{1, 2}
+	require.NoError(t, strategy.Initialize(part))
+	assert.Error(t, strategy.Evaluate(ctx, part, 1.5))
+	assert.Error(t, strategy.Evaluate(ctx, part, "1"))
+
+	require.NoError(t, strategy.Pause())
+	assert.Error(t, strategy.Evaluate(ctx, part, int64(1)))
+}
+
+func TestSequenceStrategySnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	part := entity.NewConditionPart(1, "Buttons")
+	part.ReferenceValueSet = []int64{3, 1, 4, 2}
+
+	strategy := NewSequenceStrategy()
+	require.NoError(t, strategy.Initialize(part))
+	strategy.RemoveObserver(part)
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(3)))
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+
+	restored := NewSequenceStrategy()
+	require.NoError(t, restored.Initialize(part))
+	restored.RemoveObserver(part)
+	require.NoError(t, restored.Restore(ctx, part, strategy.Snapshot()))
+	assert.Equal(t, int64(2), restored.GetCurrentValue())
+
+	// 復元後は続きの手順から入力する
+	mockObserver := &MockStrategyObserver{}
+	restored.AddObserver(mockObserver)
+	require.NoError(t, restored.Evaluate(ctx, part, int64(4)))
+	require.NoError(t, restored.Evaluate(ctx, part, int64(2)))
+	assert.Equal(t, []string{value.EventProcess, value.EventComplete}, mockObserver.Events)
+}
@@ -4,23 +4,41 @@ import (
 	"fmt"
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
 )
 
 // StrategyFactory は戦略を作成するファクトリの実装です
-type StrategyFactory struct{}
+type StrategyFactory struct {
+	clock clock.Clock
+}
 
-// NewStrategyFactory は新しいStrategyFactoryを作成します
+// NewStrategyFactory は実時間で動く戦略を作成する新しいStrategyFactoryを作成します
 func NewStrategyFactory() *StrategyFactory {
-	return &StrategyFactory{}
+	return NewStrategyFactoryWithClock(clock.Real())
+}
+
+// NewStrategyFactoryWithClock は指定したClockで動く戦略を作成する新しいStrategyFactoryを作成します
+func NewStrategyFactoryWithClock(c clock.Clock) *StrategyFactory {
+	return &StrategyFactory{clock: c}
 }
 
 // CreateStrategy は指定された種類の戦略を作成します
 func (f *StrategyFactory) CreateStrategy(kind value.ConditionKind) (service.PartStrategy, error) {
 	switch kind {
 	case value.KindTime:
-		return NewTimeStrategy(), nil
+		return NewTimeStrategyWithClock(f.clock), nil
 	case value.KindCounter:
 		return NewCounterStrategy(), nil
+	case value.KindFloat:
+		return NewFloatStrategy(), nil
+	case value.KindString:
+		return NewStringStrategy(), nil
+	case value.KindSustained:
+		return NewSustainedStrategyWithClock(f.clock), nil
+	case value.KindSequence:
+		return NewSequenceStrategy(), nil
+	case value.KindWindow:
+		return NewWindowStrategyWithClock(f.clock), nil
 	default:
 		return nil, fmt.Errorf("unknown condition kind: %v", kind)
 	}
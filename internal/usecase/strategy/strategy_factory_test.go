@@ -36,6 +36,36 @@ func TestCreateStrategy(t *testing.T) {
 			expectedType:  "*strategy.CounterStrategy",
 			expectedError: false,
 		},
+		{
+			name:          "KindFloat",
+			kind:          value.KindFloat,
+			expectedType:  "*strategy.FloatStrategy",
+			expectedError: false,
+		},
+		{
+			name:          "KindString",
+			kind:          value.KindString,
+			expectedType:  "*strategy.StringStrategy",
+			expectedError: false,
+		},
+		{
+			name:          "KindSustained",
+			kind:          value.KindSustained,
+			expectedType:  "*strategy.SustainedStrategy",
+			expectedError: false,
+		},
+		{
+			name:          "KindSequence",
+			kind:          value.KindSequence,
+			expectedType:  "*strategy.SequenceStrategy",
+			expectedError: false,
+		},
+		{
+			name:          "KindWindow",
+			kind:          value.KindWindow,
+			expectedType:  "*strategy.WindowStrategy",
+			expectedError: false,
+		},
 		{
 			name:          "KindUnspecified",
 			kind:          value.KindUnspecified,
@@ -76,6 +106,16 @@ func getTypeName(obj interface{}) string {
 		return "*strategy.TimeStrategy"
 	case *CounterStrategy:
 		return "*strategy.CounterStrategy"
+	case *FloatStrategy:
+		return "*strategy.FloatStrategy"
+	case *StringStrategy:
+		return "*strategy.StringStrategy"
+	case *SustainedStrategy:
+		return "*strategy.SustainedStrategy"
+	case *SequenceStrategy:
+		return "*strategy.SequenceStrategy"
+	case *WindowStrategy:
+		return "*strategy.WindowStrategy"
 	default:
 		return ""
 	}
@@ -0,0 +1,175 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// StringStrategy は文字列の入力値を基準値と比較する条件評価戦略です
+// 大小比較は意味を持たないため、一致・不一致と集合への所属のみを扱います
+type StringStrategy struct {
+	currentValue string
+	paused       bool
+	observers    []service.StrategyObserver
+	mu           sync.RWMutex
+}
+
+// NewStringStrategy は新しいStringStrategyを作成します
+func NewStringStrategy() *StringStrategy {
+	return &StringStrategy{
+		observers: make([]service.StrategyObserver, 0),
+	}
+}
+
+// Initialize は戦略の初期化を行います
+func (s *StringStrategy) Initialize(part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	s.currentValue = ""
+	s.AddObserver(condPart)
+	return nil
+}
+
+func (s *StringStrategy) GetCurrentValue() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentValue
+}
+
+func (s *StringStrategy) Start(ctx context.Context, part interface{}) error {
+	return nil
+}
+
+// Evaluate は最新の入力値で文字列条件を評価します
+func (s *StringStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	log := logger.DefaultLogger()
+
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+	input, ok := params.(string)
+	if !ok {
+		return fmt.Errorf("invalid params type: expected string, got %T", params)
+	}
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("string condition is paused")
+	}
+	s.currentValue = input
+	s.mu.Unlock()
+
+	satisfied := false
+	switch condPart.GetComparisonOperator() {
+	case value.ComparisonOperatorEQ:
+		satisfied = input == condPart.GetReferenceValueString()
+	case value.ComparisonOperatorNEQ:
+		satisfied = input != condPart.GetReferenceValueString()
+	case value.ComparisonOperatorIn:
+		satisfied = slices.Contains(condPart.GetReferenceStringSet(), input)
+	case value.ComparisonOperatorNotIn:
+		satisfied = !slices.Contains(condPart.GetReferenceStringSet(), input)
+	default:
+		return fmt.Errorf("unsupported comparison operator for string: %v", condPart.GetComparisonOperator())
+	}
+
+	log.Debug("String Evaluate",
+		zap.Bool("satisfied", satisfied),
+		zap.String("currentValue", input),
+		zap.String("referenceValue", condPart.GetReferenceValueString()),
+		zap.Int("comparisonOperator", int(condPart.GetComparisonOperator())))
+
+	if satisfied {
+		s.NotifyUpdate(value.EventComplete)
+	} else {
+		s.NotifyUpdate(value.EventProcess)
+	}
+	return nil
+}
+
+// Pause は再開するまで入力を受け付けないようにします
+func (s *StringStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+// Resume は入力の受け付けを再開します
+func (s *StringStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+// Snapshot は最新の入力値を保存します
+func (s *StringStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return service.StrategySnapshot{StringValue: s.currentValue, Paused: s.paused}
+}
+
+// Restore はスナップショットから最新の入力値を復元します
+func (s *StringStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentValue = snapshot.StringValue
+	s.paused = snapshot.Paused
+	return nil
+}
+
+// Cleanup は戦略のリソースを解放します
+func (s *StringStrategy) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentValue = ""
+	s.paused = false
+	s.observers = make([]service.StrategyObserver, 0)
+	return nil
+}
+
+// AddObserver オブザーバーを追加します
+func (s *StringStrategy) AddObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// RemoveObserver オブザーバーを削除します
+func (s *StringStrategy) RemoveObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, obs := range s.observers {
+		if obs == observer {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			break
+		}
+	}
+}
+
+// NotifyUpdate オブザーバーに更新を通知します
+func (s *StringStrategy) NotifyUpdate(event string) {
+	s.mu.RLock()
+	observers := make([]service.StrategyObserver, len(s.observers))
+	copy(observers, s.observers)
+	s.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnUpdated(event)
+	}
+}
@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringStrategyEvaluate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		operator       value.ComparisonOperator
+		referenceValue string
+		referenceSet   []string
+		expectedEvent  string
+	}{
+		{name: "EQ_Satisfied", input: "open", operator: value.ComparisonOperatorEQ, referenceValue: "open", expectedEvent: value.EventComplete},
+		{name: "EQ_Unsatisfied", input: "Open", operator: value.ComparisonOperatorEQ, referenceValue: "open", expectedEvent: value.EventProcess},
+		{name: "NEQ_Satisfied", input: "closed", operator: value.ComparisonOperatorNEQ, referenceValue: "open", expectedEvent: value.EventComplete},
+		{name: "NEQ_Unsatisfied", input: "open", operator: value.ComparisonOperatorNEQ, referenceValue: "open", expectedEvent: value.EventProcess},
+		{name: "In_Satisfied", input: "blue", operator: value.ComparisonOperatorIn, referenceSet: []string{"red", "blue"}, expectedEvent: value.EventComplete},
+		{name: "In_Unsatisfied", input: "green", operator: value.ComparisonOperatorIn, referenceSet: []string{"red", "blue"}, expectedEvent: value.EventProcess},
+		{name: "NotIn_Satisfied", input: "green", operator: value.ComparisonOperatorNotIn, referenceSet: []string{"red", "blue"}, expectedEvent: value.EventComplete},
+		{name: "NotIn_Unsatisfied", input: "red", operator: value.ComparisonOperatorNotIn, referenceSet: []string{"red", "blue"}, expectedEvent: value.EventProcess},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := NewStringStrategy()
+			mockObserver := &MockStrategyObserver{}
+			strategy.AddObserver(mockObserver)
+
+			part := entity.NewConditionPart(1, "Test Part")
+			part.ComparisonOperator = tc.operator
+			part.ReferenceValueString = tc.referenceValue
+			part.ReferenceStringSet = tc.referenceSet
+
+			require.NoError(t, strategy.Evaluate(context.Background(), part, tc.input))
+			assert.Equal(t, tc.input, strategy.GetCurrentValue())
+			assert.Equal(t, []string{tc.expectedEvent}, mockObserver.Events)
+		})
+	}
+}
+
+func TestStringStrategyEvaluateErrors(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewStringStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ComparisonOperator = value.ComparisonOperatorEQ
+
+	err := strategy.Evaluate(ctx, part, int64(1))
+	assert.ErrorContains(t, err, "invalid params type")
+
+	// 文字列の大小比較はサポートしない
+	part.ComparisonOperator = value.ComparisonOperatorGT
+	err = strategy.Evaluate(ctx, part, "a")
+	assert.ErrorContains(t, err, "unsupported comparison operator")
+}
+
+func TestStringStrategySnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewStringStrategy()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ComparisonOperator = value.ComparisonOperatorEQ
+	part.ReferenceValueString = "open"
+
+	require.NoError(t, strategy.Evaluate(ctx, part, "closed"))
+	require.NoError(t, strategy.Pause())
+
+	restored := NewStringStrategy()
+	require.NoError(t, restored.Restore(ctx, part, strategy.Snapshot()))
+	assert.Equal(t, "closed", restored.GetCurrentValue())
+	assert.Error(t, restored.Evaluate(ctx, part, "open"))
+}
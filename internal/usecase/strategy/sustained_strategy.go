@@ -0,0 +1,331 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SustainedStrategy はカウンターの比較が一定時間成り立ち続けたときに満たされる条件評価戦略です
+// 値はカウンターと同じく増分を加算し、比較が成り立たなくなると保持時間の計測をやり直します
+type SustainedStrategy struct {
+	currentValue int64
+	hold         time.Duration // 比較が成り立ち続ける必要のある時間
+	holding      bool          // 比較が成り立ち、保持時間を計測中かどうか
+	held         bool          // 保持時間を満了したかどうか
+	holdUntil    time.Time     // 保持時間の満了時刻
+	remaining    time.Duration // 一時停止時点での満了までの残り時間
+	paused       bool
+	clock        clock.Clock
+	ticker       clock.Ticker
+	stopChan     chan struct{}
+	observers    []service.StrategyObserver
+	mu           sync.RWMutex
+	log          *zap.Logger
+}
+
+// NewSustainedStrategy は実時間で動く新しいSustainedStrategyを作成します
+func NewSustainedStrategy() *SustainedStrategy {
+	return NewSustainedStrategyWithClock(clock.Real())
+}
+
+// NewSustainedStrategyWithClock は指定したClockで動く新しいSustainedStrategyを作成します
+func NewSustainedStrategyWithClock(c clock.Clock) *SustainedStrategy {
+	return &SustainedStrategy{
+		observers: make([]service.StrategyObserver, 0),
+		clock:     c,
+		stopChan:  make(chan struct{}),
+		log:       logger.DefaultLogger(),
+	}
+}
+
+// Initialize は戦略の初期化を行います
+func (s *SustainedStrategy) Initialize(part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	if condPart.GetDurationSeconds() <= 0 {
+		return fmt.Errorf("invalid hold duration: %d", condPart.GetDurationSeconds())
+	}
+
+	s.mu.Lock()
+	s.currentValue = 0
+	s.hold = time.Duration(condPart.GetDurationSeconds()) * time.Second
+	s.mu.Unlock()
+
+	s.AddObserver(condPart)
+	return nil
+}
+
+// GetCurrentValue は満了までの残り保持時間(ミリ秒)を返します
+// 比較が成り立っていない間は保持時間全体を返します
+func (s *SustainedStrategy) GetCurrentValue() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.remainingLocked().Milliseconds()
+}
+
+// remainingLocked は満了までの残り保持時間を返します。呼び出し側でロックを取得している必要があります
+func (s *SustainedStrategy) remainingLocked() time.Duration {
+	switch {
+	case s.held:
+		return 0
+	case s.holding && s.paused:
+		return s.remaining
+	case s.holding:
+		remaining := s.holdUntil.Sub(s.clock.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining
+	default:
+		return s.hold
+	}
+}
+
+// Start は初期値で比較が成り立つ場合に保持時間の計測を開始します
+func (s *SustainedStrategy) Start(ctx context.Context, part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	satisfied, err := compareInt(condPart, s.currentValue)
+	if err != nil {
+		return err
+	}
+	s.updateHoldLocked(satisfied)
+	return nil
+}
+
+// Evaluate はカウンターに増分を加算し、比較の結果に応じて保持時間の計測を開始・中断します
+// 条件は保持時間が満了した時点で満たされるため、入力ごとにはEventProcessを通知します
+func (s *SustainedStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	if params == nil {
+		return fmt.Errorf("invalid nil params: %v", params)
+	}
+
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+	increment, ok := params.(int64)
+	if !ok {
+		return fmt.Errorf("invalid params type: expected int64, got %T", params)
+	}
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("sustained condition is paused")
+	}
+	s.currentValue += increment
+	satisfied, err := compareInt(condPart, s.currentValue)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.updateHoldLocked(satisfied)
+	current := s.currentValue
+	held := s.held
+	s.mu.Unlock()
+
+	s.log.Debug("Sustained Evaluate",
+		zap.Bool("satisfied", satisfied),
+		zap.Int64("currentValue", current),
+		zap.Duration("hold", s.hold))
+
+	if held {
+		s.NotifyUpdate(value.EventComplete)
+	} else {
+		s.NotifyUpdate(value.EventProcess)
+	}
+	return nil
+}
+
+// updateHoldLocked は比較の結果に応じて保持時間の計測を開始・中断します
+// 呼び出し側でロックを取得している必要があります
+func (s *SustainedStrategy) updateHoldLocked(satisfied bool) {
+	switch {
+	case satisfied && !s.holding && !s.held:
+		s.holding = true
+		s.startTimerLocked(s.hold)
+	case !satisfied:
+		// 範囲を外れたら保持時間は最初から数え直す
+		s.stopTimerLocked()
+		s.holding = false
+		s.held = false
+	}
+}
+
+// startTimerLocked は指定した時間の後に満了するタイマーを開始します
+// 呼び出し側でロックを取得している必要があります
+func (s *SustainedStrategy) startTimerLocked(d time.Duration) {
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	s.holdUntil = s.clock.Now().Add(d)
+	s.ticker = s.clock.NewTicker(d)
+	go s.wait(s.ticker, s.stopChan)
+}
+
+// stopTimerLocked はタイマーを停止します。呼び出し側でロックを取得している必要があります
+func (s *SustainedStrategy) stopTimerLocked() {
+	if s.ticker == nil {
+		return
+	}
+	s.ticker.Stop()
+	s.ticker = nil
+	close(s.stopChan)
+	s.stopChan = make(chan struct{})
+}
+
+// wait は保持時間の満了を待ち、満了したらオブザーバーにタイムアウトを通知します
+// 入力による完了と区別してジャーナルから再生できるよう、タイマーによる完了はEventTimeoutで通知します
+func (s *SustainedStrategy) wait(ticker clock.Ticker, stopChan chan struct{}) {
+	select {
+	case <-ticker.C():
+		s.mu.Lock()
+		// 待っている間に中断・再開されて別のタイマーに切り替わっていないことを確認
+		if s.ticker != ticker || !s.holding {
+			s.mu.Unlock()
+			return
+		}
+		s.stopTimerLocked()
+		s.holding = false
+		s.held = true
+		s.mu.Unlock()
+
+		s.log.Debug("Sustained hold elapsed, notifying observers")
+		s.NotifyUpdate(value.EventTimeout)
+	case <-stopChan:
+	}
+}
+
+// Pause は保持時間の計測を止め、満了までの残り時間を保持します
+func (s *SustainedStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		return nil
+	}
+	if s.holding {
+		s.remaining = s.remainingLocked()
+		s.stopTimerLocked()
+	}
+	s.paused = true
+	return nil
+}
+
+// Resume は一時停止した保持時間の計測を残り時間から再開します
+func (s *SustainedStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return nil
+	}
+	s.paused = false
+	if s.holding {
+		s.startTimerLocked(s.remaining)
+		s.remaining = 0
+	}
+	return nil
+}
+
+// Snapshot はカウンターの値と保持時間の計測状態を保存します
+func (s *SustainedStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := service.StrategySnapshot{CurrentValue: s.currentValue, Paused: s.paused, Held: s.held}
+	if s.holding {
+		snapshot.Running = true
+		snapshot.RemainingMs = s.remainingLocked().Milliseconds()
+	}
+	return snapshot
+}
+
+// Restore はスナップショットからカウンターの値を復元し、保持時間の計測を残り時間から再開します
+// 一時停止中に保存された場合は、残り時間を保持したまま一時停止状態で復元します
+// 保持時間を満了済みの場合は満了状態も復元し、基準を外れる入力で未達成に戻せるようにします
+func (s *SustainedStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopTimerLocked()
+	s.currentValue = snapshot.CurrentValue
+	s.paused = snapshot.Paused
+	s.holding = snapshot.Running
+	s.held = snapshot.Held
+	if !s.holding {
+		return nil
+	}
+
+	remaining := time.Duration(snapshot.RemainingMs) * time.Millisecond
+	if s.paused {
+		s.remaining = remaining
+		return nil
+	}
+	s.startTimerLocked(remaining)
+	return nil
+}
+
+// Cleanup は戦略のリソースを解放します
+func (s *SustainedStrategy) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopTimerLocked()
+	s.currentValue = 0
+	s.holding = false
+	s.held = false
+	s.paused = false
+	s.remaining = 0
+	s.observers = make([]service.StrategyObserver, 0)
+	return nil
+}
+
+// AddObserver オブザーバーを追加します
+func (s *SustainedStrategy) AddObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// RemoveObserver オブザーバーを削除します
+func (s *SustainedStrategy) RemoveObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, obs := range s.observers {
+		if obs == observer {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			break
+		}
+	}
+}
+
+// NotifyUpdate オブザーバーに更新を通知します
+func (s *SustainedStrategy) NotifyUpdate(event string) {
+	s.mu.RLock()
+	observers := make([]service.StrategyObserver, len(s.observers))
+	copy(observers, s.observers)
+	s.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnUpdated(event)
+	}
+}
@@ -0,0 +1,164 @@
+package strategy
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSustainedPart はテスト用の持続条件のパーツを作成します
+func newSustainedPart(operator value.ComparisonOperator, reference, seconds int64) *entity.ConditionPart {
+	part := entity.NewConditionPart(1, "Sustained Part")
+	part.ComparisonOperator = operator
+	part.ReferenceValueInt = reference
+	part.DurationSeconds = seconds
+	return part
+}
+
+func TestSustainedStrategyInitialize(t *testing.T) {
+	strategy := NewSustainedStrategy()
+	assert.NoError(t, strategy.Initialize(newSustainedPart(value.ComparisonOperatorGTE, 5, 10)))
+	assert.Equal(t, int64(10000), strategy.GetCurrentValue())
+
+	err := strategy.Initialize(newSustainedPart(value.ComparisonOperatorGTE, 5, 0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid hold duration")
+}
+
+func TestSustainedStrategyHold(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newSustainedPart(value.ComparisonOperatorGTE, 5, 10)
+	strategy := NewSustainedStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	observer := &MockTimeStrategyObserver{}
+	strategy.AddObserver(observer)
+	require.NoError(t, strategy.Start(ctx, part))
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	// 基準に届くまでは計測しない
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(3)))
+	fake.Advance(time.Minute)
+	assert.Equal(t, int64(10000), strategy.GetCurrentValue())
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(2)))
+	fake.Advance(4 * time.Second)
+	assert.Equal(t, int64(6000), strategy.GetCurrentValue())
+
+	// 基準を外れると保持時間は最初から数え直す
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(-1)))
+	assert.Equal(t, int64(10000), strategy.GetCurrentValue())
+	fake.Advance(time.Minute)
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(9 * time.Second)
+	assert.Never(t, func() bool { return observer.eventCount() > 4 }, 50*time.Millisecond, time.Millisecond)
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool { return observer.eventCount() == 5 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(0), strategy.GetCurrentValue())
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	assert.Equal(t, []string{
+		value.EventProcess, value.EventProcess, value.EventProcess, value.EventProcess, value.EventTimeout,
+	}, observer.Events)
+}
+
+func TestSustainedStrategyStartInRange(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newSustainedPart(value.ComparisonOperatorLTE, 0, 3)
+	strategy := NewSustainedStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	observer := &MockTimeStrategyObserver{}
+	strategy.AddObserver(observer)
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	// 初期値で比較が成り立つ場合は開始と同時に計測する
+	require.NoError(t, strategy.Start(ctx, part))
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return observer.eventCount() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestSustainedStrategyPauseSnapshot(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newSustainedPart(value.ComparisonOperatorGTE, 1, 10)
+	strategy := NewSustainedStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	observer := &MockTimeStrategyObserver{}
+	strategy.AddObserver(observer)
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(4 * time.Second)
+
+	// 一時停止中は入力を受け付けず、保持時間も進まない
+	require.NoError(t, strategy.Pause())
+	assert.Error(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(time.Minute)
+	assert.Equal(t, int64(6000), strategy.GetCurrentValue())
+
+	snapshot := strategy.Snapshot()
+	assert.True(t, snapshot.Paused)
+	assert.True(t, snapshot.Running)
+	assert.Equal(t, int64(1), snapshot.CurrentValue)
+	assert.Equal(t, int64(6000), snapshot.RemainingMs)
+
+	// 別の戦略に復元しても残り時間から再開する
+	restoredClock := newFakeClock()
+	restored := NewSustainedStrategyWithClock(restoredClock)
+	require.NoError(t, restored.Initialize(part))
+	restoredObserver := &MockTimeStrategyObserver{}
+	restored.AddObserver(restoredObserver)
+	t.Cleanup(func() { _ = restored.Cleanup() })
+	require.NoError(t, restored.Restore(ctx, part, snapshot))
+	require.NoError(t, restored.Resume())
+	restoredClock.Advance(6 * time.Second)
+	assert.Eventually(t, func() bool { return restoredObserver.eventCount() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, strategy.Resume())
+	fake.Advance(6 * time.Second)
+	assert.Eventually(t, func() bool { return observer.eventCount() == 2 }, time.Second, time.Millisecond)
+}
+
+func TestSustainedStrategyRestoreHeld(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newSustainedPart(value.ComparisonOperatorGTE, 1, 3)
+	strategy := NewSustainedStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	observer := &MockTimeStrategyObserver{}
+	strategy.AddObserver(observer)
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return observer.eventCount() == 2 }, time.Second, time.Millisecond)
+
+	snapshot := strategy.Snapshot()
+	assert.True(t, snapshot.Held)
+	assert.False(t, snapshot.Running)
+
+	// 満了済みの状態で復元し、基準を満たす間は達成のまま、外れると未達成に戻る
+	restored := NewSustainedStrategyWithClock(newFakeClock())
+	require.NoError(t, restored.Initialize(part))
+	restoredObserver := &MockTimeStrategyObserver{}
+	restored.AddObserver(restoredObserver)
+	t.Cleanup(func() { _ = restored.Cleanup() })
+	require.NoError(t, restored.Restore(ctx, part, snapshot))
+	assert.Equal(t, int64(0), restored.GetCurrentValue())
+
+	require.NoError(t, restored.Evaluate(ctx, part, int64(1)))
+	require.NoError(t, restored.Evaluate(ctx, part, int64(-2)))
+	assert.Equal(t, int64(3000), restored.GetCurrentValue())
+
+	restoredObserver.mu.Lock()
+	defer restoredObserver.mu.Unlock()
+	assert.Equal(t, []string{value.EventComplete, value.EventProcess}, restoredObserver.Events)
+}
@@ -7,6 +7,7 @@ import (
 	"state_sample/internal/domain/service"
 	"state_sample/internal/domain/value"
 	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
 	"sync"
 	"time"
 
@@ -18,17 +19,27 @@ type TimeStrategy struct {
 	observers   []service.StrategyObserver
 	interval    time.Duration
 	isRunning   bool
-	ticker      *time.Ticker
+	paused      bool
+	remaining   time.Duration // 一時停止時点での発火までの残り時間
+	clock       clock.Clock
+	ticker      clock.Ticker
 	stopChan    chan struct{}
 	mu          sync.RWMutex
 	nextTrigger time.Time
 	log         *zap.Logger
 }
 
-// NewTimeStrategy は新しいTimeStrategyを作成します
+// NewTimeStrategy は実時間で動く新しいTimeStrategyを作成します
 func NewTimeStrategy() *TimeStrategy {
+	return NewTimeStrategyWithClock(clock.Real())
+}
+
+// NewTimeStrategyWithClock は指定したClockで動く新しいTimeStrategyを作成します
+func NewTimeStrategyWithClock(c clock.Clock) *TimeStrategy {
 	return &TimeStrategy{
 		observers: make([]service.StrategyObserver, 0),
+		clock:     c,
+		log:       logger.DefaultLogger(),
 	}
 }
 
@@ -43,11 +54,17 @@ func (s *TimeStrategy) Initialize(part interface{}) error {
 		return fmt.Errorf("invalid time interval: %d", condPart.GetReferenceValueInt())
 	}
 
-	s.log = logger.DefaultLogger()
+	// 前回のタイマーループが終了処理中の場合があるため、ロックを取って設定する
+	s.mu.Lock()
+	if s.log == nil {
+		s.log = logger.DefaultLogger()
+	}
 	duration := time.Duration(condPart.GetReferenceValueInt()) * time.Second
 	s.interval = duration
-	s.AddObserver(condPart)
 	s.stopChan = make(chan struct{})
+	s.mu.Unlock()
+
+	s.AddObserver(condPart)
 
 	return nil
 }
@@ -62,6 +79,12 @@ func (s *TimeStrategy) Start(ctx context.Context, part interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.startLocked(s.interval)
+}
+
+// startLocked は最初の発火までの時間を指定してタイマーを開始します
+// 2回目以降はintervalごとに発火します。呼び出し側でロックを取得している必要があります
+func (s *TimeStrategy) startLocked(firstDelay time.Duration) error {
 	if s.isRunning {
 		s.log.Debug("IntervalTimer is already running")
 		return nil
@@ -73,10 +96,13 @@ func (s *TimeStrategy) Start(ctx context.Context, part interface{}) error {
 		return fmt.Errorf("invalid interval: %v", s.interval)
 	}
 
-	s.log.Debug("Starting IntervalTimer", zap.Duration("interval", s.interval))
+	s.log.Debug("Starting IntervalTimer",
+		zap.Duration("interval", s.interval),
+		zap.Duration("first_delay", firstDelay))
 	s.isRunning = true
-	s.ticker = time.NewTicker(s.interval)
-	s.updateNextTrigger()
+	s.ticker = s.clock.NewTicker(firstDelay)
+	s.nextTrigger = s.clock.Now().Add(firstDelay)
+	s.log.Debug("Next event scheduled at", zap.Time("next_trigger", s.nextTrigger))
 
 	// stopChanが閉じられていないことを確認
 	select {
@@ -88,33 +114,141 @@ func (s *TimeStrategy) Start(ctx context.Context, part interface{}) error {
 		// 問題なし
 	}
 
-	go s.run()
+	// 一時停止と再開が続いた場合に古いループが新しいティッカーを拾わないよう、開始時点の値を渡す
+	go s.run(s.ticker, s.stopChan, s.interval)
 	return nil
 }
 
+// Evaluate は時間条件では入力値を使わないため何もしません
+// タイマーの停止・再開はPause / Resumeで行います
 func (s *TimeStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	return nil
+}
+
+// Pause はタイマーを止め、発火までの残り時間を保持します
+// タイマーが動作していない場合は何もしません
+func (s *TimeStrategy) Pause() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// TODO 再生、停止機能を追加するならここ
-	//s.isRunning = true
-	//s.ticker = time.NewTicker(s.interval)
-	//s.updateNextTrigger()
+	if !s.isRunning {
+		return nil
+	}
+
+	remaining := s.nextTrigger.Sub(s.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	s.stopLocked()
+	s.paused = true
+	s.remaining = remaining
 
+	s.log.Debug("IntervalTimer paused", zap.Duration("remaining", remaining))
 	return nil
 }
 
+// Resume は一時停止したタイマーを残り時間から再開します
+// 一時停止していない場合は何もしません
+func (s *TimeStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return nil
+	}
+
+	remaining := s.remaining
+	if remaining <= 0 {
+		// 一時停止時点で発火直前だった場合はすぐに発火させる
+		remaining = time.Nanosecond
+	}
+	s.paused = false
+	s.remaining = 0
+
+	s.log.Debug("IntervalTimer resumed", zap.Duration("remaining", remaining))
+	return s.startLocked(remaining)
+}
+
+// Snapshot はタイマーの動作状態と発火までの残り時間を保存します
+func (s *TimeStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.paused {
+		return service.StrategySnapshot{
+			Paused:      true,
+			RemainingMs: s.remaining.Milliseconds(),
+		}
+	}
+
+	if !s.isRunning {
+		return service.StrategySnapshot{}
+	}
+
+	remaining := s.nextTrigger.Sub(s.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return service.StrategySnapshot{
+		Running:     true,
+		RemainingMs: remaining.Milliseconds(),
+	}
+}
+
+// Restore はスナップショットからタイマーを再開します
+// タイマーは最初から計測し直さず、保存時の残り時間が経過した時点で発火します
+// 一時停止中に保存された場合は、残り時間を保持したまま一時停止状態で復元します
+func (s *TimeStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	remaining := time.Duration(snapshot.RemainingMs) * time.Millisecond
+
+	if snapshot.Paused {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.paused = true
+		s.remaining = remaining
+		return nil
+	}
+
+	if !snapshot.Running {
+		return nil
+	}
+
+	if remaining <= 0 {
+		// 保存時点で発火直前だった場合はすぐに発火させる
+		remaining = time.Nanosecond
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.startLocked(remaining)
+}
+
 // Cleanup はタイマーリソースを解放します
 func (s *TimeStrategy) Cleanup() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.isRunning {
+	s.paused = false
+	s.remaining = 0
+
+	// 一時停止中はタイマーが止まっていても、オブザーバーは必ずクリアする
+	if s.isRunning {
+		s.log.Debug("Stopping IntervalTimer")
+		s.stopLocked()
+	} else {
 		s.log.Debug("IntervalTimer is not running")
-		return nil
 	}
 
-	s.log.Debug("Stopping IntervalTimer")
+	// observersをクリア
+	s.observers = make([]service.StrategyObserver, 0)
+	s.log.Debug("Cleared observers")
+
+	return nil
+}
+
+// stopLocked はタイマーループを停止します。オブザーバーは保持したままです
+// 呼び出し側でロックを取得している必要があります
+func (s *TimeStrategy) stopLocked() {
 	s.isRunning = false
 
 	// タイマーを停止
@@ -138,32 +272,20 @@ func (s *TimeStrategy) Cleanup() error {
 	// 新しいstopChanを作成
 	s.stopChan = make(chan struct{})
 	s.log.Debug("Created new stopChan")
-
-	// observersをクリア
-	s.observers = make([]service.StrategyObserver, 0)
-	s.log.Debug("Cleared observers")
-
-	return nil
 }
 
 // 次のトリガー時間を更新します
 func (s *TimeStrategy) updateNextTrigger() {
-	s.nextTrigger = time.Now().Add(s.interval)
+	s.nextTrigger = s.clock.Now().Add(s.interval)
 	s.log.Debug("Next event scheduled at", zap.Time("next_trigger", s.nextTrigger))
 }
 
 // タイマーループを実行します
-func (s *TimeStrategy) run() {
+func (s *TimeStrategy) run(ticker clock.Ticker, stopChan chan struct{}, interval time.Duration) {
 	s.log.Debug("Starting timer loop")
 	defer s.log.Debug("Timer loop exited")
 
 	// タイマーが初期化されていることを確認
-	s.mu.RLock()
-	ticker := s.ticker
-	stopChan := s.stopChan
-	interval := s.interval
-	s.mu.RUnlock()
-
 	if ticker == nil {
 		s.log.Error("Timer is nil in run()")
 		return
@@ -174,11 +296,11 @@ func (s *TimeStrategy) run() {
 	// タイマーループ
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			s.log.Debug("Timer tick received")
-			// タイマーが停止されていないことを確認
+			// タイマーが停止されていないこと、一時停止後に別のループへ切り替わっていないことを確認
 			s.mu.RLock()
-			isRunning := s.isRunning
+			isRunning := s.isRunning && s.ticker == ticker
 			s.mu.RUnlock()
 
 			if !isRunning {
@@ -186,6 +308,12 @@ func (s *TimeStrategy) run() {
 				return
 			}
 
+			// 復元時は最初の発火だけ残り時間で動くため、以降はintervalに戻す
+			ticker.Reset(interval)
+			s.mu.Lock()
+			s.updateNextTrigger()
+			s.mu.Unlock()
+
 			s.log.Debug("Notifying observers about timeout event")
 			s.NotifyUpdate(value.EventTimeout)
 			s.log.Debug("Notification complete")
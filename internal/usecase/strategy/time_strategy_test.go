@@ -4,6 +4,8 @@ import (
 	"context"
 	"state_sample/internal/domain/entity"
 	"state_sample/internal/domain/value"
+	"state_sample/internal/lib/clock"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,13 +15,28 @@ import (
 // MockStrategyObserver は StrategyObserver インターフェースのモック実装です
 type MockTimeStrategyObserver struct {
 	Events []string
+	mu     sync.Mutex
 }
 
 // OnUpdated はイベントを記録します
 func (m *MockTimeStrategyObserver) OnUpdated(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Events = append(m.Events, event)
 }
 
+// eventCount はタイマーのゴルーチンから記録されたイベント数を返します
+func (m *MockTimeStrategyObserver) eventCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.Events)
+}
+
+// newFakeClock はテスト用のFakeクロックを作成します
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
 func TestNewTimeStrategy(t *testing.T) {
 	// 新しいTimeStrategyを作成
 	strategy := NewTimeStrategy()
@@ -133,7 +150,7 @@ func TestTimeStrategyCleanup(t *testing.T) {
 	assert.Len(t, strategy.observers, 2) // 1つはpart、1つはmockObserver
 
 	strategy.isRunning = true
-	strategy.ticker = time.NewTicker(1 * time.Second)
+	strategy.ticker = strategy.clock.NewTicker(1 * time.Second)
 
 	// クリーンアップ
 	err = strategy.Cleanup()
@@ -229,8 +246,9 @@ func TestTimeStrategyObserver(t *testing.T) {
 }
 
 func TestTimeStrategyUpdateNextTrigger(t *testing.T) {
-	// 新しいTimeStrategyを作成
-	strategy := NewTimeStrategy()
+	// Fakeクロックで動くTimeStrategyを作成
+	fake := newFakeClock()
+	strategy := NewTimeStrategyWithClock(fake)
 
 	// テスト用のConditionPartを作成
 	part := entity.NewConditionPart(1, "Test Part")
@@ -241,32 +259,25 @@ func TestTimeStrategyUpdateNextTrigger(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 次のトリガー時間を更新
-	now := time.Now()
 	strategy.updateNextTrigger()
 
 	// 次のトリガー時間が現在時刻から5秒後に設定されていることを確認
-	// 厳密な時間比較は難しいので、前後1秒の範囲内であることを確認
-	expectedTime := now.Add(5 * time.Second)
-	assert.True(t, strategy.nextTrigger.After(expectedTime.Add(-1*time.Second)))
-	assert.True(t, strategy.nextTrigger.Before(expectedTime.Add(1*time.Second)))
+	assert.Equal(t, fake.Now().Add(5*time.Second), strategy.nextTrigger)
 }
 
-// タイマーループのテストは複雑なので、簡易的なテストにとどめる
 func TestTimeStrategyRun(t *testing.T) {
-	// 新しいTimeStrategyを作成
-	strategy := NewTimeStrategy()
+	// Fakeクロックで動くTimeStrategyを作成
+	fake := newFakeClock()
+	strategy := NewTimeStrategyWithClock(fake)
 
 	// テスト用のConditionPartを作成
 	part := entity.NewConditionPart(1, "Test Part")
-	part.ReferenceValueInt = 1 // 1秒
+	part.ReferenceValueInt = 5 // 5秒
 
 	// 初期化（これによりlogが設定される）
 	err := strategy.Initialize(part)
 	assert.NoError(t, err)
 
-	// インターバルを短く設定
-	strategy.interval = 100 * time.Millisecond
-
 	// モックオブザーバーの作成
 	mockObserver := &MockTimeStrategyObserver{}
 	strategy.AddObserver(mockObserver)
@@ -277,16 +288,117 @@ func TestTimeStrategyRun(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, strategy.isRunning)
 
-	// 少し長めに待機してタイマーイベントが確実に発生するのを待つ
-	time.Sleep(200 * time.Millisecond)
+	// 5秒進めるとタイマーイベントが発生する
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return mockObserver.eventCount() == 1 }, time.Second, time.Millisecond,
+		"タイマーイベントが発生していません")
 
 	// タイマーループを停止
 	err = strategy.Cleanup()
 	assert.NoError(t, err)
+	assert.Equal(t, value.EventTimeout, mockObserver.Events[0])
+}
+
+func TestTimeStrategySnapshotRestore(t *testing.T) {
+	fake := newFakeClock()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ReferenceValueInt = 5 // 5秒
+
+	strategy := NewTimeStrategyWithClock(fake)
+	assert.NoError(t, strategy.Initialize(part))
+
+	// 停止中のタイマーは残り時間を持たない
+	assert.False(t, strategy.Snapshot().Running)
+
+	assert.NoError(t, strategy.Start(context.Background(), part))
+	fake.Advance(2 * time.Second)
+	snapshot := strategy.Snapshot()
+	assert.NoError(t, strategy.Cleanup())
+	assert.True(t, snapshot.Running)
+	assert.Equal(t, int64(3000), snapshot.RemainingMs)
+
+	// 残り時間から再開され、intervalを待たずに発火する
+	restored := NewTimeStrategyWithClock(fake)
+	assert.NoError(t, restored.Initialize(part))
+	mockObserver := &MockTimeStrategyObserver{}
+	restored.AddObserver(mockObserver)
+
+	assert.NoError(t, restored.Restore(context.Background(), part, snapshot))
+	assert.True(t, restored.isRunning)
+	assert.Equal(t, int64(3000), restored.Snapshot().RemainingMs)
+
+	fake.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return mockObserver.eventCount() == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, restored.Cleanup())
+	assert.Equal(t, []string{value.EventTimeout}, mockObserver.Events)
+}
+
+func TestTimeStrategyPauseResume(t *testing.T) {
+	fake := newFakeClock()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ReferenceValueInt = 10 // 10秒
 
-	// タイマーイベントが発生したことを確認
-	assert.GreaterOrEqual(t, len(mockObserver.Events), 1, "タイマーイベントが発生していません")
-	if len(mockObserver.Events) > 0 {
-		assert.Equal(t, value.EventTimeout, mockObserver.Events[0])
+	strategy := NewTimeStrategyWithClock(fake)
+	assert.NoError(t, strategy.Initialize(part))
+	mockObserver := &MockTimeStrategyObserver{}
+	strategy.AddObserver(mockObserver)
+
+	// 動作していないタイマーの一時停止は何もしない
+	assert.NoError(t, strategy.Pause())
+	assert.False(t, strategy.Snapshot().Paused)
+
+	assert.NoError(t, strategy.Start(context.Background(), part))
+	fake.Advance(4 * time.Second)
+
+	// 一時停止中は時間が進んでも発火しない
+	assert.NoError(t, strategy.Pause())
+	assert.False(t, strategy.isRunning)
+	fake.Advance(time.Minute)
+	assert.Never(t, func() bool { return mockObserver.eventCount() > 0 }, 50*time.Millisecond, time.Millisecond)
+
+	snapshot := strategy.Snapshot()
+	assert.True(t, snapshot.Paused)
+	assert.Equal(t, int64(6000), snapshot.RemainingMs)
+
+	// 再開すると残り時間が経過した時点で発火する
+	assert.NoError(t, strategy.Resume())
+	assert.True(t, strategy.isRunning)
+	fake.Advance(5 * time.Second)
+	assert.Never(t, func() bool { return mockObserver.eventCount() > 0 }, 50*time.Millisecond, time.Millisecond)
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool { return mockObserver.eventCount() == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, strategy.Cleanup())
+	assert.Equal(t, []string{value.EventTimeout}, mockObserver.Events)
+}
+
+func TestTimeStrategyPauseThenReset(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := entity.NewConditionPart(1, "Test Part")
+	part.ReferenceValueInt = 5 // 5秒
+
+	strategy := NewTimeStrategyWithClock(fake)
+	assert.NoError(t, part.SetStrategy(strategy))
+
+	// 一時停止したままリセットしても、パーツはオブザーバーとして重複して登録されない
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, part.Activate(ctx))
+		assert.NoError(t, part.Pause(ctx))
+		assert.NoError(t, part.Reset(ctx))
 	}
+	assert.Len(t, strategy.observers, 1)
+
+	assert.NoError(t, part.Activate(ctx))
+	mockObserver := &MockTimeStrategyObserver{}
+	strategy.AddObserver(mockObserver)
+	assert.Len(t, strategy.observers, 2) // 1つはpart、1つはmockObserver
+
+	// タイムアウトはちょうど1回だけ通知される
+	fake.Advance(5 * time.Second)
+	assert.Eventually(t, func() bool { return mockObserver.eventCount() == 1 }, time.Second, time.Millisecond)
+	assert.Eventually(t, part.IsSatisfied, time.Second, time.Millisecond)
+	assert.Never(t, func() bool { return mockObserver.eventCount() > 1 }, 50*time.Millisecond, time.Millisecond)
+
+	assert.NoError(t, strategy.Cleanup())
 }
@@ -0,0 +1,343 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/service"
+	"state_sample/internal/domain/value"
+	logger "state_sample/internal/lib"
+	"state_sample/internal/lib/clock"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// windowEvent は時間窓に入っている1件の入力です
+type windowEvent struct {
+	count     int64
+	expiresAt time.Time // 窓から外れる時刻
+}
+
+// WindowStrategy は直近の一定時間内の入力回数で評価する条件評価戦略です
+// 入力は時間窓の長さが経過すると自身のタイマーで窓から外れ、窓内の回数を現在値として返します
+type WindowStrategy struct {
+	part      *entity.ConditionPart
+	window    time.Duration // 時間窓の長さ
+	events    []windowEvent // 窓内の入力(古い順)
+	paused    bool
+	pausedAt  time.Time // 一時停止した時刻
+	clock     clock.Clock
+	ticker    clock.Ticker
+	stopChan  chan struct{}
+	observers []service.StrategyObserver
+	mu        sync.RWMutex
+	log       *zap.Logger
+}
+
+// NewWindowStrategy は実時間で動く新しいWindowStrategyを作成します
+func NewWindowStrategy() *WindowStrategy {
+	return NewWindowStrategyWithClock(clock.Real())
+}
+
+// NewWindowStrategyWithClock は指定したClockで動く新しいWindowStrategyを作成します
+func NewWindowStrategyWithClock(c clock.Clock) *WindowStrategy {
+	return &WindowStrategy{
+		observers: make([]service.StrategyObserver, 0),
+		clock:     c,
+		stopChan:  make(chan struct{}),
+		log:       logger.DefaultLogger(),
+	}
+}
+
+// Initialize は戦略の初期化を行います
+func (s *WindowStrategy) Initialize(part interface{}) error {
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+
+	if condPart.GetDurationSeconds() <= 0 {
+		return fmt.Errorf("invalid window duration: %d", condPart.GetDurationSeconds())
+	}
+
+	s.mu.Lock()
+	s.part = condPart
+	s.window = time.Duration(condPart.GetDurationSeconds()) * time.Second
+	s.events = nil
+	s.mu.Unlock()
+
+	s.AddObserver(condPart)
+	return nil
+}
+
+// GetCurrentValue は時間窓内の入力回数を返します
+func (s *WindowStrategy) GetCurrentValue() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.countLocked()
+}
+
+// countLocked は時間窓内の入力回数を返します。呼び出し側でロックを取得している必要があります
+func (s *WindowStrategy) countLocked() int64 {
+	var count int64
+	for _, event := range s.events {
+		count += event.count
+	}
+	return count
+}
+
+func (s *WindowStrategy) Start(ctx context.Context, part interface{}) error {
+	return nil
+}
+
+// Evaluate は入力を時間窓に加え、窓内の回数を比較します
+// 増分は入力された回数として扱うため、正の値のみ受け付けます
+func (s *WindowStrategy) Evaluate(ctx context.Context, part interface{}, params interface{}) error {
+	if params == nil {
+		return fmt.Errorf("invalid nil params: %v", params)
+	}
+
+	condPart, ok := part.(*entity.ConditionPart)
+	if !ok {
+		return fmt.Errorf("invalid part type: expected *entity.ConditionPart, got %T", part)
+	}
+	increment, ok := params.(int64)
+	if !ok {
+		return fmt.Errorf("invalid params type: expected int64, got %T", params)
+	}
+	if increment <= 0 {
+		return fmt.Errorf("invalid increment for window condition: %d", increment)
+	}
+
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
+		return fmt.Errorf("window condition is paused")
+	}
+	s.events = append(s.events, windowEvent{count: increment, expiresAt: s.clock.Now().Add(s.window)})
+	if s.ticker == nil {
+		s.startTimerLocked()
+	}
+	count := s.countLocked()
+	s.mu.Unlock()
+
+	return s.notifyCount(condPart, count, value.EventComplete, value.EventProcess)
+}
+
+// notifyCount は窓内の回数を比較し、満たされた場合はsatisfiedEvent、満たされない場合はunsatisfiedEventをオブザーバーに通知します
+func (s *WindowStrategy) notifyCount(condPart *entity.ConditionPart, count int64, satisfiedEvent, unsatisfiedEvent string) error {
+	satisfied, err := compareInt(condPart, count)
+	if err != nil {
+		return err
+	}
+
+	s.log.Debug("Window Evaluate",
+		zap.Bool("satisfied", satisfied),
+		zap.Int64("count", count),
+		zap.Duration("window", s.window))
+
+	if satisfied {
+		s.NotifyUpdate(satisfiedEvent)
+	} else {
+		s.NotifyUpdate(unsatisfiedEvent)
+	}
+	return nil
+}
+
+// startTimerLocked は最も古い入力が窓から外れる時刻に発火するタイマーを開始します
+// 呼び出し側でロックを取得している必要があります
+func (s *WindowStrategy) startTimerLocked() {
+	if len(s.events) == 0 {
+		return
+	}
+	d := s.events[0].expiresAt.Sub(s.clock.Now())
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	s.ticker = s.clock.NewTicker(d)
+	go s.run(s.ticker, s.stopChan)
+}
+
+// stopTimerLocked はタイマーを停止します。呼び出し側でロックを取得している必要があります
+func (s *WindowStrategy) stopTimerLocked() {
+	if s.ticker == nil {
+		return
+	}
+	s.ticker.Stop()
+	s.ticker = nil
+	close(s.stopChan)
+	s.stopChan = make(chan struct{})
+}
+
+// run は最も古い入力が窓から外れるのを待ち、窓内の回数が変わったら入力と同じように評価し直してオブザーバーに通知します
+// 満たされているかどうかが変わらない場合は入力と同じくEventComplete / EventProcessで通知し、状態は変えません
+// 期限切れで満たされなくなった場合はEventExpire、満たされるようになった場合(LTなど)はEventTimeoutで通知します
+// 状態を変える通知だけを入力による遷移と区別することで、ジャーナルから再生できるようにしています
+func (s *WindowStrategy) run(ticker clock.Ticker, stopChan chan struct{}) {
+	select {
+	case <-ticker.C():
+		s.mu.Lock()
+		// 待っている間に停止・再開されて別のタイマーに切り替わっていないことを確認
+		if s.ticker != ticker {
+			s.mu.Unlock()
+			return
+		}
+		s.stopTimerLocked()
+		before := s.countLocked()
+		s.expireLocked()
+		s.startTimerLocked()
+		count := s.countLocked()
+		condPart := s.part
+		s.mu.Unlock()
+
+		if count == before {
+			return
+		}
+		s.log.Debug("Window events expired", zap.Int64("count", count))
+		wasSatisfied, err := compareInt(condPart, before)
+		if err == nil {
+			if wasSatisfied {
+				err = s.notifyCount(condPart, count, value.EventComplete, value.EventExpire)
+			} else {
+				err = s.notifyCount(condPart, count, value.EventTimeout, value.EventProcess)
+			}
+		}
+		if err != nil {
+			s.log.Error("Window expire failed", zap.Error(err))
+		}
+	case <-stopChan:
+	}
+}
+
+// expireLocked は窓から外れた入力を取り除きます。呼び出し側でロックを取得している必要があります
+func (s *WindowStrategy) expireLocked() {
+	now := s.clock.Now()
+	i := 0
+	for i < len(s.events) && !s.events[i].expiresAt.After(now) {
+		i++
+	}
+	s.events = s.events[i:]
+}
+
+// Pause は窓から外れるまでの時間の経過を止めます
+func (s *WindowStrategy) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		return nil
+	}
+	s.stopTimerLocked()
+	s.paused = true
+	s.pausedAt = s.clock.Now()
+	return nil
+}
+
+// Resume は一時停止していた時間だけ各入力が窓から外れる時刻を遅らせ、タイマーを再開します
+func (s *WindowStrategy) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return nil
+	}
+	s.paused = false
+	elapsed := s.clock.Now().Sub(s.pausedAt)
+	for i := range s.events {
+		s.events[i].expiresAt = s.events[i].expiresAt.Add(elapsed)
+	}
+	s.startTimerLocked()
+	return nil
+}
+
+// Snapshot は窓内の入力と、それぞれが窓から外れるまでの残り時間を保存します
+func (s *WindowStrategy) Snapshot() service.StrategySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	if s.paused {
+		now = s.pausedAt
+	}
+	snapshot := service.StrategySnapshot{CurrentValue: s.countLocked(), Paused: s.paused}
+	for _, event := range s.events {
+		remaining := event.expiresAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		snapshot.Window = append(snapshot.Window, service.WindowEventSnapshot{
+			Count:       event.count,
+			RemainingMs: remaining.Milliseconds(),
+		})
+	}
+	return snapshot
+}
+
+// Restore はスナップショットから窓内の入力を復元し、残り時間からタイマーを再開します
+// 一時停止中に保存された場合は、残り時間を保持したまま一時停止状態で復元します
+func (s *WindowStrategy) Restore(ctx context.Context, part interface{}, snapshot service.StrategySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopTimerLocked()
+	now := s.clock.Now()
+	s.events = make([]windowEvent, 0, len(snapshot.Window))
+	for _, event := range snapshot.Window {
+		s.events = append(s.events, windowEvent{
+			count:     event.Count,
+			expiresAt: now.Add(time.Duration(event.RemainingMs) * time.Millisecond),
+		})
+	}
+	s.paused = snapshot.Paused
+	if s.paused {
+		s.pausedAt = now
+		return nil
+	}
+	s.startTimerLocked()
+	return nil
+}
+
+// Cleanup は戦略のリソースを解放します
+func (s *WindowStrategy) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopTimerLocked()
+	s.events = nil
+	s.paused = false
+	s.observers = make([]service.StrategyObserver, 0)
+	return nil
+}
+
+// AddObserver オブザーバーを追加します
+func (s *WindowStrategy) AddObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// RemoveObserver オブザーバーを削除します
+func (s *WindowStrategy) RemoveObserver(observer service.StrategyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, obs := range s.observers {
+		if obs == observer {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			break
+		}
+	}
+}
+
+// NotifyUpdate オブザーバーに更新を通知します
+func (s *WindowStrategy) NotifyUpdate(event string) {
+	s.mu.RLock()
+	observers := make([]service.StrategyObserver, len(s.observers))
+	copy(observers, s.observers)
+	s.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.OnUpdated(event)
+	}
+}
@@ -0,0 +1,146 @@
+package strategy
+
+import (
+	"context"
+	"state_sample/internal/domain/entity"
+	"state_sample/internal/domain/value"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWindowPart はテスト用の時間窓条件のパーツを作成します
+func newWindowPart(reference, seconds int64) *entity.ConditionPart {
+	part := entity.NewConditionPart(1, "Window Part")
+	part.ComparisonOperator = value.ComparisonOperatorGTE
+	part.ReferenceValueInt = reference
+	part.DurationSeconds = seconds
+	return part
+}
+
+func TestWindowStrategyInitialize(t *testing.T) {
+	strategy := NewWindowStrategy()
+	assert.NoError(t, strategy.Initialize(newWindowPart(5, 3)))
+	assert.Equal(t, int64(0), strategy.GetCurrentValue())
+
+	err := strategy.Initialize(newWindowPart(5, 0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid window duration")
+}
+
+func TestWindowStrategyExpire(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newWindowPart(3, 3)
+	strategy := NewWindowStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	observer := &MockTimeStrategyObserver{}
+	strategy.AddObserver(observer)
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	assert.Error(t, strategy.Evaluate(ctx, part, int64(0)))
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(2 * time.Second)
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(1)))
+	assert.Equal(t, int64(2), strategy.GetCurrentValue())
+
+	// 最初の入力は3秒経つと窓から外れ、回数の変化が入力と同じく通知される
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool { return strategy.GetCurrentValue() == int64(1) }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return observer.eventCount() == 3 }, time.Second, time.Millisecond)
+
+	// 窓内に3回そろうと満たされる
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(2)))
+	assert.Equal(t, int64(3), strategy.GetCurrentValue())
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	assert.Equal(t, []string{
+		value.EventProcess, value.EventProcess, value.EventProcess, value.EventComplete,
+	}, observer.Events)
+}
+
+func TestWindowStrategyExpireEvents(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name     string
+		operator value.ComparisonOperator
+		inputs   []int64 // 1秒おきの入力
+		expected string  // 最初の入力が窓から外れた時の通知
+	}{
+		// 満たされたまま回数が減っても状態は変わらない
+		{name: "StaysSatisfied", operator: value.ComparisonOperatorGTE, inputs: []int64{1, 3}, expected: value.EventComplete},
+		// 満たされなくなった場合は期限切れとして差し戻す
+		{name: "NoLongerSatisfied", operator: value.ComparisonOperatorGTE, inputs: []int64{1, 2}, expected: value.EventExpire},
+		// 回数が減って満たされるようになった場合はタイムアウトとして満たす
+		{name: "BecomesSatisfied", operator: value.ComparisonOperatorLT, inputs: []int64{2, 1}, expected: value.EventTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeClock()
+			part := newWindowPart(3, 3)
+			part.ComparisonOperator = tc.operator
+			strategy := NewWindowStrategyWithClock(fake)
+			require.NoError(t, strategy.Initialize(part))
+			observer := &MockTimeStrategyObserver{}
+			strategy.AddObserver(observer)
+			t.Cleanup(func() { _ = strategy.Cleanup() })
+
+			for _, input := range tc.inputs {
+				require.NoError(t, strategy.Evaluate(ctx, part, input))
+				fake.Advance(time.Second)
+			}
+			fake.Advance(time.Second)
+			assert.Eventually(t, func() bool { return observer.eventCount() == len(tc.inputs)+1 }, time.Second, time.Millisecond)
+
+			observer.mu.Lock()
+			defer observer.mu.Unlock()
+			assert.Equal(t, tc.expected, observer.Events[len(tc.inputs)])
+		})
+	}
+}
+
+func TestWindowStrategyPauseSnapshot(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeClock()
+	part := newWindowPart(5, 10)
+	strategy := NewWindowStrategyWithClock(fake)
+	require.NoError(t, strategy.Initialize(part))
+	t.Cleanup(func() { _ = strategy.Cleanup() })
+
+	require.NoError(t, strategy.Evaluate(ctx, part, int64(2)))
+	fake.Advance(4 * time.Second)
+
+	// 一時停止中は入力を受け付けず、入力も窓から外れない
+	require.NoError(t, strategy.Pause())
+	assert.Error(t, strategy.Evaluate(ctx, part, int64(1)))
+	fake.Advance(time.Minute)
+	assert.Equal(t, int64(2), strategy.GetCurrentValue())
+
+	snapshot := strategy.Snapshot()
+	assert.True(t, snapshot.Paused)
+	assert.Equal(t, int64(2), snapshot.CurrentValue)
+	require.Len(t, snapshot.Window, 1)
+	assert.Equal(t, int64(6000), snapshot.Window[0].RemainingMs)
+
+	// 別の戦略に復元しても残り時間で窓から外れる
+	restoredClock := newFakeClock()
+	restored := NewWindowStrategyWithClock(restoredClock)
+	require.NoError(t, restored.Initialize(part))
+	t.Cleanup(func() { _ = restored.Cleanup() })
+	require.NoError(t, restored.Restore(ctx, part, snapshot))
+	require.NoError(t, restored.Resume())
+	assert.Equal(t, int64(2), restored.GetCurrentValue())
+	restoredClock.Advance(6 * time.Second)
+	assert.Eventually(t, func() bool { return restored.GetCurrentValue() == int64(0) }, time.Second, time.Millisecond)
+
+	require.NoError(t, strategy.Resume())
+	fake.Advance(5 * time.Second)
+	assert.Never(t, func() bool { return strategy.GetCurrentValue() == int64(0) }, 50*time.Millisecond, time.Millisecond)
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool { return strategy.GetCurrentValue() == int64(0) }, time.Second, time.Millisecond)
+}
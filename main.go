@@ -1,18 +1,56 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"state_sample/internal/domain/entity"
 	logger "state_sample/internal/lib"
 	"state_sample/internal/ui"
 	"state_sample/internal/usecase/state"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
 func main() {
 	log := logger.DefaultLogger()
-	facade := state.NewStateFacade()
+
+	scenarioPath := flag.String("scenario", "", "path to a scenario file (.yaml / .yml / .json)")
+	snapshotPath := flag.String("snapshot", "", "path to a snapshot file restored at startup and saved on shutdown")
+	flag.Parse()
+
+	// セッションごとにフェーズツリーを構築する
+	// シナリオファイルが指定されていればそこからフェーズを構築する
+	newFacade := func() (*state.GameFacade, error) {
+		return state.NewStateFacade(), nil
+	}
+	if *scenarioPath != "" {
+		newFacade = func() (*state.GameFacade, error) {
+			return state.NewStateFacadeFromScenario(*scenarioPath)
+		}
+	}
+
+	sessions := state.NewSessionManager(newFacade)
+	session, err := sessions.Create(state.DefaultSessionID)
+	if err != nil {
+		log.Fatal("Failed to create default session", zap.String("scenario", *scenarioPath), zap.Error(err))
+	}
+
+	// スナップショットファイルが指定されていれば、前回終了時の状態から再開する
+	// 保存と復元の対象はデフォルトセッションのみで、APIで作成したセッションは終了時に破棄される
+	if *snapshotPath != "" {
+		if err := restoreSnapshot(session.Facade, *snapshotPath); err != nil {
+			log.Fatal("Failed to restore snapshot", zap.String("snapshot", *snapshotPath), zap.Error(err))
+		}
+		go saveSnapshotOnSignal(session.Facade, *snapshotPath)
+	}
+
 	// サーバーの初期化
-	server := ui.NewStateServer(facade)
+	server := ui.NewStateServer(sessions)
 
 	// サーバーの起動（ポート8080で待ち受け）
 	log.Debug("Starting server on :8080")
@@ -20,3 +58,41 @@ func main() {
 		log.Error("Server error", zap.Error(err))
 	}
 }
+
+// restoreSnapshot はスナップショットファイルからゲーム状態を復元します
+// ファイルが存在しない場合は何もしません
+func restoreSnapshot(facade *state.GameFacade, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot entity.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	return facade.Restore(context.Background(), &snapshot)
+}
+
+// saveSnapshotOnSignal は終了シグナルを受け取った時にゲーム状態をファイルに保存して終了します
+func saveSnapshotOnSignal(facade *state.GameFacade, path string) {
+	log := logger.DefaultLogger()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	data, err := json.MarshalIndent(facade.Snapshot(), "", "  ")
+	if err != nil {
+		log.Error("Failed to encode snapshot", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Error("Failed to save snapshot", zap.String("snapshot", path), zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Snapshot saved", zap.String("snapshot", path))
+	os.Exit(0)
+}
@@ -0,0 +1,9 @@
+// Package scenarios はリポジトリに同梱するシナリオファイルを埋め込みます
+package scenarios
+
+import _ "embed"
+
+// Default はNewStateFacadeが使うデフォルトのシナリオ(default.yaml)です
+//
+//go:embed default.yaml
+var Default []byte